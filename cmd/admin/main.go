@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"demo-service/internal/config"
+	"demo-service/internal/database"
+	"demo-service/internal/repository"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `Usage: admin [flags] <command> USERNAME
+
+Grant or revoke administrator rights. Rights are stored on the user and
+checked on every admin-only request, so a change takes effect immediately.
+
+Commands:
+  grant USERNAME    Make the user an administrator
+  revoke USERNAME   Take administrator rights away
+
+Flags:
+`
+
+func main() {
+	timeout := flag.Duration("timeout", 30*time.Second, "overall timeout for the command")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) != 2 || (args[0] != "grant" && args[0] != "revoke") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := config.Load(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	connectCtx, connectCancel := context.WithTimeout(context.Background(), config.AppConfig.DBConnectTimeout)
+	err := database.Init(connectCtx, config.AppConfig.DatabaseURL, database.PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1})
+	connectCancel()
+	if err != nil {
+		logrus.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	user, err := repository.NewUserRepository().SetAdmin(ctx, args[1], args[0] == "grant")
+	if errors.Is(err, repository.ErrUserNotFound) {
+		logrus.Fatalf("User %q not found", args[1])
+	}
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	if user.IsAdmin {
+		fmt.Printf("%s is an administrator\n", user.Username)
+	} else {
+		fmt.Printf("%s is not an administrator\n", user.Username)
+	}
+}
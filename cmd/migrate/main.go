@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"demo-service/internal/config"
+	"demo-service/internal/database"
+	"demo-service/internal/repository"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `Usage: migrate [flags] <command> [args]
+
+Commands:
+  status          Show applied and pending migrations
+  up [N]          Apply all (or N) pending migrations
+  down [N]        Roll back the last (or N) applied migrations
+  goto VERSION    Migrate up or down to VERSION (0 rolls back everything)
+  redo            Roll back and re-apply the last applied migration
+  create NAME     Create empty up/down migration files for every dialect
+
+Flags:
+`
+
+func main() {
+	dir := flag.String("dir", "internal/database/migrations", "migrations root with one subdirectory per dialect (create only)")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall timeout for the command")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// create работает только с файлами и не требует подключения к БД
+	if args[0] == "create" {
+		if len(args) != 2 {
+			log.Fatal("create requires exactly one NAME argument")
+		}
+		files, err := database.CreateMigration(*dir, args[1])
+		for _, file := range files {
+			fmt.Println("Created", file)
+		}
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		return
+	}
+
+	if err := config.Load(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	connectCtx, connectCancel := context.WithTimeout(context.Background(), config.AppConfig.DBConnectTimeout)
+	err := database.Init(connectCtx, config.AppConfig.DatabaseURL, database.PoolConfig{MaxOpenConns: 2, MaxIdleConns: 1})
+	connectCancel()
+	if err != nil {
+		logrus.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	migrator, err := database.NewMigrator(database.DB, database.CurrentDialect)
+	if err != nil {
+		logrus.Fatalf("Failed to load migrations: %v", err)
+	}
+	// Цены, перенесенные миграцией 0013, получают валюту по умолчанию
+	hooks, err := repository.MigrationHooks(config.AppConfig.DefaultCurrency)
+	if err != nil {
+		logrus.Fatalf("Invalid PRODUCT_DEFAULT_CURRENCY: %v", err)
+	}
+	for version, hook := range hooks {
+		migrator.AfterUp(version, hook)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := run(ctx, migrator, args); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, migrator *database.Migrator, args []string) error {
+	switch args[0] {
+	case "status":
+		return printStatus(ctx, migrator)
+
+	case "up":
+		n, err := optionalCount(args, 0)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(ctx, n)
+		printMigrations("Applied", applied)
+		if err == nil && len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return err
+
+	case "down":
+		n, err := optionalCount(args, 1)
+		if err != nil {
+			return err
+		}
+		reverted, err := migrator.Down(ctx, n)
+		printMigrations("Rolled back", reverted)
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("No applied migrations")
+		}
+		return err
+
+	case "goto":
+		if len(args) != 2 {
+			return fmt.Errorf("goto requires exactly one VERSION argument")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		applied, reverted, err := migrator.Goto(ctx, version)
+		printMigrations("Rolled back", reverted)
+		printMigrations("Applied", applied)
+		return err
+
+	case "redo":
+		migration, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Redone %04d_%s\n", migration.Version, migration.Name)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func optionalCount(args []string, defaultValue int) (int, error) {
+	if len(args) < 2 {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid count %q", args[1])
+	}
+	return n, nil
+}
+
+func printMigrations(action string, migrations []database.Migration) {
+	for _, m := range migrations {
+		fmt.Printf("%s %04d_%s\n", action, m.Version, m.Name)
+	}
+}
+
+func printStatus(ctx context.Context, migrator *database.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state := "pending"
+		appliedAt := "-"
+		if s.Applied {
+			state = "applied"
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Modified {
+			state += " (modified)"
+		}
+		if s.Missing {
+			state += " (missing)"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+
+	return w.Flush()
+}
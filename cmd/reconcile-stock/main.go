@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"demo-service/internal/config"
+	"demo-service/internal/database"
+	"demo-service/internal/repository"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `Usage: reconcile-stock [flags]
+
+Verify that the stock of every product equals the sum of its stock movements
+and print the products that drifted. Exits with status 1 if drift is found.
+
+Flags:
+`
+
+func main() {
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall timeout for the reconciliation")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := config.Load(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	connectCtx, connectCancel := context.WithTimeout(context.Background(), config.AppConfig.DBConnectTimeout)
+	err := database.Init(connectCtx, config.AppConfig.DatabaseURL, database.PoolConfig{MaxOpenConns: 2, MaxIdleConns: 1})
+	connectCancel()
+	if err != nil {
+		logrus.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	drifts, err := repository.NewStockMovementRepository().Reconcile(ctx)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if len(drifts) == 0 {
+		fmt.Println("Stock of all products matches the ledger")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PRODUCT\tSTOCK\tLEDGER\tDRIFT")
+	for _, d := range drifts {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%+d\n", d.ProductID, d.Stock, d.LedgerStock, d.Stock-d.LedgerStock)
+	}
+	w.Flush()
+
+	fmt.Printf("%d product(s) drifted from the ledger\n", len(drifts))
+	// defer не выполнится после os.Exit
+	database.Close()
+	os.Exit(1)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"net/http"
+	"testing"
+)
+
+func TestBootstrapAdmin(t *testing.T) {
+	for _, backend := range []string{"memory", "database"} {
+		t.Run(backend, func(t *testing.T) {
+			t.Setenv("BOOTSTRAP_ADMIN_USERNAME", "root")
+			t.Setenv("BOOTSTRAP_ADMIN_PASSWORD", "secret123")
+			s := newTestServer(t, backend)
+
+			// Повторный запуск не мешает: пользователь уже есть
+			for i := 0; i < 2; i++ {
+				if err := s.app.bootstrapAdmin(context.Background()); err != nil {
+					t.Fatalf("bootstrap admin: %v", err)
+				}
+			}
+
+			credentials := map[string]string{"username": "root", "password": "secret123"}
+			var response model.AuthResponse
+			decode(t, s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", credentials), &response)
+			if !response.User.IsAdmin {
+				t.Fatalf("bootstrap user is not an administrator")
+			}
+			s.token = response.Token
+
+			s.expect(http.StatusOK, http.MethodGet, "/api/v1/products?include_deleted=true", nil)
+			s.expect(http.StatusNotFound, http.MethodDelete, "/api/v1/exchange-rates/999", nil)
+		})
+	}
+}
+
+func TestAdminRightsFromStore(t *testing.T) {
+	for _, backend := range []string{"memory", "database"} {
+		t.Run(backend, func(t *testing.T) {
+			var users repository.UserStore
+			s := newTestServer(t, backend, func(stores *repository.Stores) {
+				users = stores.Users
+			})
+			ctx := context.Background()
+
+			s.login("alice")
+			s.expect(http.StatusForbidden, http.MethodGet, "/api/v1/products?include_deleted=true", nil)
+			s.expect(http.StatusForbidden, http.MethodDelete, "/api/v1/exchange-rates/999", nil)
+
+			// Права выданы после входа: токен тот же, действуют сразу
+			if _, err := users.SetAdmin(ctx, "alice", true); err != nil {
+				t.Fatalf("grant: %v", err)
+			}
+			s.expect(http.StatusOK, http.MethodGet, "/api/v1/products?include_deleted=true", nil)
+			s.expect(http.StatusNotFound, http.MethodDelete, "/api/v1/exchange-rates/999", nil)
+
+			// Отзыв не ждет истечения токена
+			if _, err := users.SetAdmin(ctx, "alice", false); err != nil {
+				t.Fatalf("revoke: %v", err)
+			}
+			s.expect(http.StatusForbidden, http.MethodGet, "/api/v1/products?include_deleted=true", nil)
+			s.expect(http.StatusForbidden, http.MethodDelete, "/api/v1/exchange-rates/999", nil)
+		})
+	}
+}
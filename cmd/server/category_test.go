@@ -0,0 +1,111 @@
+package main
+
+import (
+	"demo-service/internal/model"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestCategories(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		create := func(name string, parentID *int64) model.Category {
+			t.Helper()
+			var category model.Category
+			body := map[string]interface{}{"name": name, "parent_id": parentID}
+			decode(t, s.expect(http.StatusCreated, http.MethodPost, "/api/v1/categories", body), &category)
+			return category
+		}
+		kitchen := create("Kitchen", nil)
+		appliances := create("Appliances", &kitchen.ID)
+		kettles := create("Kettles", &appliances.ID)
+		garden := create("Garden", nil)
+
+		// Имена уникальны только среди соседей
+		s.expect(http.StatusConflict, http.MethodPost, "/api/v1/categories", map[string]interface{}{"name": "Appliances", "parent_id": kitchen.ID})
+		create("Appliances", &garden.ID)
+		s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/categories", map[string]interface{}{"name": "Orphan", "parent_id": 999})
+
+		var category model.Category
+		decode(t, s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/categories/%d", kettles.ID), nil), &category)
+		if got := categoryNames(category.Path); !slices.Equal(got, []string{"Kitchen", "Appliances", "Kettles"}) {
+			t.Fatalf("path = %v", got)
+		}
+		var tree model.CategoryTreeResponse
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/categories", nil), &tree)
+		if len(tree.Categories) != 2 || tree.Categories[0].Name != "Garden" || tree.Categories[1].Children[0].Children[0].ID != kettles.ID {
+			t.Fatalf("tree = %+v", tree.Categories)
+		}
+
+		electric := s.createProduct("Electric kettle", "30.00", 1)
+		pan := s.createProduct("Pan", "20.00", 1)
+		hose := s.createProduct("Hose", "15.00", 1)
+		setCategories := func(product model.Product, ids ...int64) model.Product {
+			t.Helper()
+			var updated model.Product
+			path := fmt.Sprintf("/api/v1/products/%d/categories", product.ID)
+			decode(t, s.expect(http.StatusOK, http.MethodPut, path, map[string]interface{}{"category_ids": ids}), &updated)
+			return updated
+		}
+		updated := setCategories(electric, kettles.ID)
+		if len(updated.Categories) != 1 || !slices.Equal(categoryNames(updated.Categories[0].Path), []string{"Kitchen", "Appliances", "Kettles"}) {
+			t.Fatalf("product categories = %+v", updated.Categories)
+		}
+		setCategories(pan, kitchen.ID)
+		setCategories(hose, garden.ID)
+		s.expect(http.StatusBadRequest, http.MethodPut, fmt.Sprintf("/api/v1/products/%d/categories", pan.ID), map[string]interface{}{"category_ids": []int64{999}})
+
+		inCategory := func(id int64, subcategories bool) []int64 {
+			t.Helper()
+			var response model.ProductListResponse
+			path := fmt.Sprintf("/api/v1/products?category=%d&include_subcategories=%t&sort=id", id, subcategories)
+			decode(t, s.expect(http.StatusOK, http.MethodGet, path, nil), &response)
+			return productIDs(response.Products)
+		}
+		if got := inCategory(kitchen.ID, false); !slices.Equal(got, []int64{pan.ID}) {
+			t.Errorf("kitchen: %v, want [%d]", got, pan.ID)
+		}
+		if got, want := inCategory(kitchen.ID, true), []int64{electric.ID, pan.ID}; !slices.Equal(got, want) {
+			t.Errorf("kitchen with subcategories: %v, want %v", got, want)
+		}
+
+		// Перенос поддерева меняет выборку по родителям
+		move := func(id int64, parentID *int64) int {
+			return s.do(http.MethodPost, fmt.Sprintf("/api/v1/categories/%d/move", id), map[string]interface{}{"parent_id": parentID}).Code
+		}
+		if code := move(kitchen.ID, &kettles.ID); code != http.StatusConflict {
+			t.Errorf("move into own subtree: status %d, want 409", code)
+		}
+		if code := move(kettles.ID, &garden.ID); code != http.StatusOK {
+			t.Fatalf("move kettles: status %d", code)
+		}
+		if got := inCategory(kitchen.ID, true); !slices.Equal(got, []int64{pan.ID}) {
+			t.Errorf("kitchen after move: %v, want [%d]", got, pan.ID)
+		}
+		if got, want := inCategory(garden.ID, true), []int64{electric.ID, hose.ID}; !slices.Equal(got, want) {
+			t.Errorf("garden after move: %v, want %v", got, want)
+		}
+		if code := move(kettles.ID, nil); code != http.StatusOK {
+			t.Fatalf("move kettles to root: status %d", code)
+		}
+
+		// Удалить можно только лист, продукты остаются без категории
+		s.expect(http.StatusConflict, http.MethodDelete, fmt.Sprintf("/api/v1/categories/%d", kitchen.ID), nil)
+		s.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/api/v1/categories/%d", kettles.ID), nil)
+		if product := s.getProduct(electric.ID); len(product.Categories) != 0 {
+			t.Errorf("categories after delete = %+v", product.Categories)
+		}
+		s.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/api/v1/categories/%d", kettles.ID), nil)
+		s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/products?category=%d", kettles.ID), nil)
+	})
+}
+
+func categoryNames(path []model.CategoryRef) []string {
+	names := make([]string, len(path))
+	for i, ref := range path {
+		names[i] = ref.Name
+	}
+	return names
+}
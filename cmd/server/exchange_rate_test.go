@@ -0,0 +1,86 @@
+package main
+
+import (
+	"demo-service/internal/model"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestExchangeRates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		rates := map[string]interface{}{"rates": []map[string]interface{}{{"base": "USD", "quote": "EUR", "rate": "0.9"}}}
+		s.expect(http.StatusForbidden, http.MethodPost, "/api/v1/exchange-rates", rates)
+		product := s.createProduct("Kettle", "10.00", 1)
+		productURL := fmt.Sprintf("/api/v1/products/%d", product.ID)
+		s.expect(http.StatusUnprocessableEntity, http.MethodGet, productURL+"?currency=EUR", nil)
+
+		s.loginAdmin("root")
+		older := time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second)
+		newer := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+		rates = map[string]interface{}{"rates": []map[string]interface{}{
+			{"base": "USD", "quote": "EUR", "rate": "0.9215", "rate_at": older},
+			{"base": "USD", "quote": "EUR", "rate": "0.95", "rate_at": newer},
+		}}
+		var uploaded model.ExchangeRateListResponse
+		decode(t, s.expect(http.StatusOK, http.MethodPost, "/api/v1/exchange-rates", rates), &uploaded)
+		if len(uploaded.Rates) != 2 {
+			t.Fatalf("uploaded %d rates, want 2", len(uploaded.Rates))
+		}
+
+		// Действует последний курс пары, более ранний - для at в прошлом
+		var list model.ExchangeRateListResponse
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/exchange-rates", nil), &list)
+		if len(list.Rates) != 1 || list.Rates[0].Rate != "0.95" {
+			t.Fatalf("current rates = %+v, want USD/EUR 0.95", list.Rates)
+		}
+		at := url.QueryEscape(older.Add(time.Minute).Format(time.RFC3339))
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/exchange-rates?at="+at, nil), &list)
+		if len(list.Rates) != 1 || list.Rates[0].Rate != "0.9215" {
+			t.Fatalf("rates at %s = %+v, want USD/EUR 0.9215", at, list.Rates)
+		}
+
+		converted := s.convertedPrice(productURL + "?currency=EUR")
+		if converted.PriceMinor != 950 || converted.Source != model.PriceSourceExchangeRate {
+			t.Fatalf("converted price = %+v, want 950 from exchange_rate", converted)
+		}
+		if converted := s.convertedPrice(productURL + "?currency=USD"); converted.PriceMinor != 1000 || converted.Source != model.PriceSourceNative {
+			t.Fatalf("price in own currency = %+v, want native 1000", converted)
+		}
+
+		// Обратный курс: EUR -> USD по курсу USD/EUR
+		euroProduct := s.createProductIn("Mug", "9.50", "EUR")
+		if converted := s.convertedPrice(fmt.Sprintf("/api/v1/products/%d?currency=USD", euroProduct.ID)); converted.PriceMinor != 1000 {
+			t.Fatalf("inverse conversion = %+v, want 1000", converted)
+		}
+
+		// CSV заменяет курс пары с тем же rate_at
+		csv := "base,quote,rate,rate_at\nUSD,EUR,0.96," + newer.Format(time.RFC3339) + "\n"
+		s.expect(http.StatusOK, http.MethodPost, "/api/v1/exchange-rates", csv, "Content-Type", "text/csv")
+		if converted := s.convertedPrice(productURL + "?currency=EUR"); converted.PriceMinor != 960 {
+			t.Fatalf("converted price after CSV = %+v, want 960", converted)
+		}
+		s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/exchange-rates", "base,quote,rate\nUSD,EUR,-1\n", "Content-Type", "text/csv")
+
+		// Удаление курса возвращает предыдущий
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/exchange-rates", nil), &list)
+		s.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/api/v1/exchange-rates/%d", list.Rates[0].ID), nil)
+		if converted := s.convertedPrice(productURL + "?currency=EUR"); converted.PriceMinor != 922 {
+			t.Fatalf("converted price after delete = %+v, want 922", converted)
+		}
+		s.expect(http.StatusNotFound, http.MethodDelete, fmt.Sprintf("/api/v1/exchange-rates/%d", list.Rates[0].ID), nil)
+	})
+}
+
+func (s *testServer) convertedPrice(path string) model.ConvertedPrice {
+	s.t.Helper()
+	var product model.Product
+	decode(s.t, s.expect(http.StatusOK, http.MethodGet, path, nil), &product)
+	if product.ConvertedPrice == nil {
+		s.t.Fatalf("GET %s: no converted_price", path)
+	}
+	return *product.ConvertedPrice
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestReadiness(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		// Сервер отвечает сразу, но готов только после подключения к хранилищу
+		s.expect(http.StatusOK, http.MethodGet, "/health", nil)
+		s.expect(http.StatusServiceUnavailable, http.MethodGet, "/ready", nil)
+		s.app.health.SetReady()
+		s.expect(http.StatusOK, http.MethodGet, "/ready", nil)
+	})
+}
@@ -5,9 +5,13 @@ import (
 	"demo-service/internal/config"
 	"demo-service/internal/database"
 	"demo-service/internal/handler"
+	"demo-service/internal/metrics"
 	"demo-service/internal/middleware"
+	"demo-service/internal/model"
 	"demo-service/internal/repository"
 	"demo-service/internal/service"
+	"demo-service/internal/storage"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -16,6 +20,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
@@ -49,27 +55,27 @@ func main() {
 
 	setupLogging()
 
-	if err := database.Init(config.AppConfig.DatabaseURL); err != nil {
-		logrus.Fatalf("Failed to initialize database: %v", err)
+	if !model.ValidCurrency(config.AppConfig.DefaultCurrency) {
+		logrus.Fatalf("Invalid PRODUCT_DEFAULT_CURRENCY: %s is not an ISO 4217 currency code", config.AppConfig.DefaultCurrency)
+	}
+	if err := service.ValidatePriceRounding(); err != nil {
+		logrus.Fatalf("Invalid price rounding: %v", err)
 	}
+
+	stores := setupStorage()
 	defer database.Close()
+	blobs := setupBlobStore()
 
-	userRepo := repository.NewUserRepository()
-	productRepo := repository.NewProductRepository()
+	// Контекст фоновых задач, отменяется при остановке сервера
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
 
-	authService := service.NewAuthService(userRepo)
-	productService := service.NewProductService(productRepo)
-
-	authHandler := handler.NewAuthHandler(authService)
-	productHandler := handler.NewProductHandler(productService)
-	healthHandler := handler.NewHealthHandler()
-
-	router := setupRouter(authHandler, productHandler, healthHandler)
+	app := newApp(stores, blobs)
 
 	// Создаем HTTP сервер
 	srv := &http.Server{
 		Addr:         ":" + config.AppConfig.ServerPort,
-		Handler:      router,
+		Handler:      app.router,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -83,6 +89,23 @@ func main() {
 		}
 	}()
 
+	// Сервер уже принимает запросы, /ready отвечает 503 до подключения к БД
+	go func() {
+		prepareStorage()
+		if err := app.bootstrapAdmin(bgCtx); err != nil {
+			logrus.Fatalf("Failed to bootstrap administrator: %v", err)
+		}
+		app.health.SetReady()
+		logrus.Info("Service is ready")
+
+		if config.AppConfig.ProductRetention > 0 && config.AppConfig.ProductPurgeInterval > 0 {
+			go app.productService.RunPurge(bgCtx, config.AppConfig.ProductPurgeInterval, config.AppConfig.ProductRetention)
+		}
+		if config.AppConfig.ReservationSweep > 0 {
+			go app.productService.RunReservationSweeper(bgCtx, config.AppConfig.ReservationSweep)
+		}
+	}()
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -96,10 +119,143 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logrus.Fatalf("Server forced to shutdown: %v", err)
 	}
+	// Фоновые импорты сохраняют задачи как прерванные
+	if err := app.productService.StopImports(ctx); err != nil {
+		logrus.Errorf("Background imports did not stop in time: %v", err)
+	}
 
 	logrus.Info("Server exited")
 }
 
+// application - сервисы и HTTP-обработчики, собранные поверх хранилищ.
+type application struct {
+	router         *gin.Engine
+	authService    *service.AuthService
+	productService *service.ProductService
+	health         *handler.HealthHandler
+}
+
+func newApp(stores repository.Stores, blobs storage.BlobStore) *application {
+	authService := service.NewAuthService(stores.Users, stores.Tx)
+	productService := service.NewProductService(service.ProductStores{
+		Products:       stores.Products,
+		History:        stores.ProductHistory,
+		ImportJobs:     stores.ProductImports,
+		Categories:     stores.Categories,
+		Images:         stores.ProductImages,
+		Prices:         stores.ProductPrices,
+		ExchangeRates:  stores.ExchangeRates,
+		Reservations:   stores.Reservations,
+		StockMovements: stores.StockMovements,
+		Tx:             stores.Tx,
+	}, blobs)
+	categoryService := service.NewCategoryService(stores.Categories, stores.Tx)
+	exchangeRateService := service.NewExchangeRateService(stores.ExchangeRates, stores.Tx)
+
+	authHandler := handler.NewAuthHandler(authService)
+	productHandler := handler.NewProductHandler(productService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+	exchangeRateHandler := handler.NewExchangeRateHandler(exchangeRateService)
+	healthHandler := handler.NewHealthHandler()
+
+	return &application{
+		router:         setupRouter(authService.IsAdmin, authHandler, productHandler, categoryHandler, exchangeRateHandler, healthHandler),
+		authService:    authService,
+		productService: productService,
+		health:         healthHandler,
+	}
+}
+
+// bootstrapAdmin создает администратора из BOOTSTRAP_ADMIN_USERNAME и
+// BOOTSTRAP_ADMIN_PASSWORD. Для хранилища в памяти это единственный способ
+// получить администратора: cmd/admin работает только с БД.
+func (a *application) bootstrapAdmin(ctx context.Context) error {
+	username, password := config.AppConfig.AdminUsername, config.AppConfig.AdminPassword
+	if username == "" && password == "" {
+		return nil
+	}
+	if username == "" || password == "" {
+		return errors.New("BOOTSTRAP_ADMIN_USERNAME and BOOTSTRAP_ADMIN_PASSWORD must be set together")
+	}
+
+	user, err := a.authService.EnsureAdmin(ctx, username, password)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("User %s is an administrator", user.Username)
+	return nil
+}
+
+func setupStorage() repository.Stores {
+	switch config.AppConfig.StorageBackend {
+	case "memory":
+		logrus.Warn("Using in-memory storage, data will be lost on restart")
+		return repository.NewMemoryStores()
+	case "database":
+	default:
+		logrus.Fatalf("Unknown storage backend: %s", config.AppConfig.StorageBackend)
+	}
+
+	pool := dbPoolConfig()
+	if err := database.Open(config.AppConfig.DatabaseURL, pool); err != nil {
+		logrus.Fatalf("Failed to initialize database: %v", err)
+	}
+	database.QueryTimeout = config.AppConfig.DBQueryTimeout
+	metrics.RegisterDBStats(database.DB, "primary")
+
+	if err := database.InitReplicas(config.AppConfig.DatabaseReadURLs, pool, config.AppConfig.ReplicaHealthInterval); err != nil {
+		logrus.Fatalf("Failed to initialize read replicas: %v", err)
+	}
+	for name, db := range database.Replicas.DBs() {
+		metrics.RegisterDBStats(db, name)
+	}
+	if len(config.AppConfig.DatabaseReadURLs) > 0 {
+		logrus.Infof("Routing reads to %d replica(s)", len(config.AppConfig.DatabaseReadURLs))
+	}
+
+	isolation, err := database.ParseIsolationLevel(config.AppConfig.DBTxIsolation)
+	if err != nil {
+		logrus.Fatalf("Invalid DB_TX_ISOLATION: %v", err)
+	}
+	txManager := database.NewTxManager(isolation, config.AppConfig.DBTxMaxRetries)
+
+	return repository.NewStores(txManager)
+}
+
+// setupBlobStore открывает хранилище файлов продуктов (BLOB_STORAGE).
+func setupBlobStore() storage.BlobStore {
+	switch config.AppConfig.BlobStorage {
+	case "local":
+		store, err := storage.NewLocalStore(config.AppConfig.BlobLocalDir)
+		if err != nil {
+			logrus.Fatalf("Failed to initialize blob storage: %v", err)
+		}
+		return store
+	case "s3":
+	default:
+		logrus.Fatalf("Unknown blob storage: %s", config.AppConfig.BlobStorage)
+	}
+
+	store, err := storage.NewS3Store(storage.S3Config{
+		Endpoint:        config.AppConfig.S3Endpoint,
+		Region:          config.AppConfig.S3Region,
+		Bucket:          config.AppConfig.S3Bucket,
+		AccessKeyID:     config.AppConfig.S3AccessKeyID,
+		SecretAccessKey: config.AppConfig.S3SecretAccessKey,
+		PathStyle:       config.AppConfig.S3PathStyle,
+	})
+	if err != nil {
+		logrus.Fatalf("Failed to initialize blob storage: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := store.EnsureBucket(ctx); err != nil {
+		logrus.Fatalf("Failed to initialize blob storage: %v", err)
+	}
+	return store
+}
+
 func setupLogging() {
 	level, err := logrus.ParseLevel(config.AppConfig.LogLevel)
 	if err != nil {
@@ -117,20 +273,36 @@ func setupLogging() {
 }
 
 func setupRouter(
+	admins middleware.AdminChecker,
 	authHandler *handler.AuthHandler,
 	productHandler *handler.ProductHandler,
+	categoryHandler *handler.CategoryHandler,
+	exchangeRateHandler *handler.ExchangeRateHandler,
 	healthHandler *handler.HealthHandler,
 ) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
+	// Теги binding моделей, которых нет среди стандартных проверок
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		if err := model.RegisterValidations(v); err != nil {
+			logrus.Fatalf("Failed to register validations: %v", err)
+		}
+	}
+
 	router := gin.New()
 
 	router.Use(gin.Recovery())
 	router.Use(middleware.LoggingMiddleware())
 	router.Use(middleware.MetricsMiddleware())
 	router.Use(middleware.RateLimitMiddleware())
+	router.Use(middleware.AmountsMiddleware())
+
+	if database.Replicas != nil && config.AppConfig.ReadYourWritesWindow > 0 {
+		router.Use(middleware.ReadYourWritesMiddleware(config.AppConfig.ReadYourWritesWindow))
+	}
 
 	router.GET("/health", healthHandler.HealthCheck)
+	router.GET("/ready", healthHandler.ReadinessCheck)
 
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
@@ -145,15 +317,93 @@ func setupRouter(
 		}
 
 		products := v1.Group("/products")
-		products.Use(middleware.AuthMiddleware())
+		products.Use(middleware.AuthMiddleware(admins))
 		{
 			products.POST("", productHandler.Create)
 			products.GET("", productHandler.List)
+			products.POST("/batch", productHandler.Batch)
+			products.GET("/export", productHandler.Export)
+			products.POST("/import", productHandler.Import)
+			products.GET("/imports/:id", productHandler.GetImportJob)
+			products.GET("/suggest", productHandler.Suggest)
+			products.GET("/by-sku/:sku", productHandler.GetBySKU)
+			products.PUT("/by-sku/:sku", productHandler.UpsertBySKU)
 			products.GET("/:id", productHandler.GetByID)
 			products.PUT("/:id", productHandler.Update)
 			products.DELETE("/:id", productHandler.Delete)
+			products.POST("/:id/restore", productHandler.Restore)
+			products.GET("/:id/history", productHandler.History)
+			products.PUT("/:id/categories", productHandler.SetCategories)
+			products.POST("/:id/images", productHandler.UploadImage)
+			products.GET("/:id/images/:imageId", productHandler.GetImage)
+			products.GET("/:id/images/:imageId/thumbnail", productHandler.GetImageThumbnail)
+			products.DELETE("/:id/images/:imageId", productHandler.DeleteImage)
+			products.GET("/:id/prices", productHandler.ListPrices)
+			products.PUT("/:id/prices/:currency", productHandler.SetPrice)
+			products.DELETE("/:id/prices/:currency", productHandler.DeletePrice)
+			products.POST("/:id/stock/adjust", productHandler.AdjustStock)
+			products.GET("/:id/stock/movements", productHandler.StockMovements)
+			products.POST("/:id/reservations", productHandler.Reserve)
+			products.GET("/:id/reservations/:reservationId", productHandler.GetReservation)
+			products.POST("/:id/reservations/:reservationId/commit", productHandler.CommitReservation)
+			products.POST("/:id/reservations/:reservationId/release", productHandler.ReleaseReservation)
+		}
+
+		categories := v1.Group("/categories")
+		categories.Use(middleware.AuthMiddleware(admins))
+		{
+			categories.POST("", categoryHandler.Create)
+			categories.GET("", categoryHandler.List)
+			categories.GET("/:id", categoryHandler.GetByID)
+			categories.PUT("/:id", categoryHandler.Update)
+			categories.DELETE("/:id", categoryHandler.Delete)
+			categories.POST("/:id/move", categoryHandler.Move)
+		}
+
+		exchangeRates := v1.Group("/exchange-rates")
+		exchangeRates.Use(middleware.AuthMiddleware(admins))
+		{
+			exchangeRates.GET("", exchangeRateHandler.List)
+			exchangeRates.POST("", middleware.AdminMiddleware(), exchangeRateHandler.Upload)
+			exchangeRates.DELETE("/:id", middleware.AdminMiddleware(), exchangeRateHandler.Delete)
 		}
 	}
 
 	return router
 }
+
+// prepareStorage ждет доступности БД не дольше DB_CONNECT_TIMEOUT
+// и применяет миграции.
+func prepareStorage() {
+	if database.DB == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.DBConnectTimeout)
+	defer cancel()
+
+	if err := database.WaitForConnection(ctx); err != nil {
+		logrus.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	if config.AppConfig.AutoMigrate {
+		hooks, err := repository.MigrationHooks(config.AppConfig.DefaultCurrency)
+		if err != nil {
+			logrus.Fatalf("Invalid PRODUCT_DEFAULT_CURRENCY: %v", err)
+		}
+		if err := database.RunMigrations(hooks); err != nil {
+			logrus.Fatalf("Failed to run migrations: %v", err)
+		}
+	} else {
+		logrus.Info("Automatic migrations are disabled")
+	}
+}
+
+func dbPoolConfig() database.PoolConfig {
+	return database.PoolConfig{
+		MaxOpenConns:    config.AppConfig.DBMaxOpenConns,
+		MaxIdleConns:    config.AppConfig.DBMaxIdleConns,
+		ConnMaxIdleTime: config.AppConfig.DBConnMaxIdleTime,
+		ConnMaxLifetime: config.AppConfig.DBConnMaxLifetime,
+	}
+}
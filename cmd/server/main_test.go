@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"demo-service/internal/config"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logrus.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testServer - роутер сервиса поверх хранилища в памяти или файла SQLite.
+type testServer struct {
+	t      *testing.T
+	app    *application
+	router http.Handler
+	token  string
+}
+
+// forEachBackend выполняет fn для обоих хранилищ: их поведение должно совпадать.
+func forEachBackend(t *testing.T, fn func(t *testing.T, s *testServer)) {
+	for _, backend := range []string{"memory", "database"} {
+		t.Run(backend, func(t *testing.T) {
+			fn(t, newTestServer(t, backend))
+		})
+	}
+}
+
+// newTestServer создает сервер; wrap позволяет подменить хранилища.
+func newTestServer(t *testing.T, backend string, wrap ...func(stores *repository.Stores)) *testServer {
+	t.Setenv("STORAGE_BACKEND", backend)
+	t.Setenv("DATABASE_URL", "sqlite://"+filepath.Join(t.TempDir(), "test.db"))
+	t.Setenv("DATABASE_READ_URLS", "")
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("RATE_LIMIT_RPS", "100000")
+	t.Setenv("BLOB_STORAGE", "local")
+	t.Setenv("BLOB_LOCAL_DIR", t.TempDir())
+	if err := config.Load(); err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	stores := repository.NewMemoryStores()
+	if backend == "database" {
+		// setupStorage регистрирует метрики пула, повторно это сделать нельзя
+		if err := database.Open(config.AppConfig.DatabaseURL, dbPoolConfig()); err != nil {
+			t.Fatalf("open database: %v", err)
+		}
+		t.Cleanup(func() {
+			database.Close()
+			database.DB = nil
+		})
+		prepareStorage()
+
+		isolation, err := database.ParseIsolationLevel(config.AppConfig.DBTxIsolation)
+		if err != nil {
+			t.Fatalf("parse isolation level: %v", err)
+		}
+		stores = repository.NewStores(database.NewTxManager(isolation, config.AppConfig.DBTxMaxRetries))
+	}
+
+	for _, fn := range wrap {
+		fn(&stores)
+	}
+	app := newApp(stores, setupBlobStore())
+	return &testServer{t: t, app: app, router: app.router}
+}
+
+// do выполняет запрос с токеном последнего login; headers - пары имя, значение.
+// Строка body отправляется как есть, остальное - в JSON.
+func (s *testServer) do(method, path string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var reader io.Reader
+	if raw, ok := body.(string); ok {
+		reader = strings.NewReader(raw)
+	} else if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect выполняет запрос и проверяет код ответа.
+func (s *testServer) expect(status int, method, path string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	rec := s.do(method, path, body, headers...)
+	if rec.Code != status {
+		s.t.Fatalf("%s %s: status %d, want %d; body: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+	return rec
+}
+
+// login регистрирует пользователя и запоминает его токен.
+func (s *testServer) login(username string) {
+	s.t.Helper()
+	credentials := map[string]string{"username": username, "password": "secret123"}
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/register", credentials)
+	var response model.AuthResponse
+	decode(s.t, s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", credentials), &response)
+	s.token = response.Token
+}
+
+// loginAdmin входит администратором username, созданным bootstrapAdmin.
+func (s *testServer) loginAdmin(username string) {
+	s.t.Helper()
+	config.AppConfig.AdminUsername, config.AppConfig.AdminPassword = username, "secret123"
+	if err := s.app.bootstrapAdmin(context.Background()); err != nil {
+		s.t.Fatalf("bootstrap admin: %v", err)
+	}
+	credentials := map[string]string{"username": username, "password": "secret123"}
+	var response model.AuthResponse
+	decode(s.t, s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", credentials), &response)
+	s.token = response.Token
+}
+
+func (s *testServer) createProduct(name, price string, stock int) model.Product {
+	s.t.Helper()
+	var product model.Product
+	body := map[string]interface{}{"name": name, "price": price, "stock": stock}
+	decode(s.t, s.expect(http.StatusCreated, http.MethodPost, "/api/v1/products", body), &product)
+	return product
+}
+
+// createProductIn создает продукт с ценой в currency.
+func (s *testServer) createProductIn(name, price, currency string) model.Product {
+	s.t.Helper()
+	var product model.Product
+	body := map[string]interface{}{"name": name, "price": price, "currency": currency}
+	decode(s.t, s.expect(http.StatusCreated, http.MethodPost, "/api/v1/products", body), &product)
+	return product
+}
+
+func (s *testServer) getProduct(id int64) model.Product {
+	s.t.Helper()
+	var product model.Product
+	decode(s.t, s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/products/%d", id), nil), &product)
+	return product
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body.String(), err)
+	}
+}
+
+func productIDs(products []model.Product) []int64 {
+	ids := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	return ids
+}
+
+func TestAuth(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/products", nil)
+
+		s.login("alice")
+		s.expect(http.StatusOK, http.MethodGet, "/api/v1/products", nil)
+
+		credentials := map[string]string{"username": "alice", "password": "secret123"}
+		s.expect(http.StatusConflict, http.MethodPost, "/api/v1/auth/register", credentials)
+		credentials["password"] = "wrong-password"
+		s.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login", credentials)
+
+		// Права администратора не выдаются регистрацией
+		s.expect(http.StatusForbidden, http.MethodPost, "/api/v1/exchange-rates", map[string]interface{}{})
+
+		s.token = "not-a-token"
+		s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/products", nil)
+	})
+}
+
+func TestProductCRUD(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+
+		product := s.createProduct("Kettle", "19.99", 5)
+		if product.PriceMinor != 1999 || product.Currency != "USD" || product.Version != 1 {
+			t.Fatalf("created product = %+v", product)
+		}
+		path := fmt.Sprintf("/api/v1/products/%d", product.ID)
+
+		rec := s.expect(http.StatusOK, http.MethodGet, path, nil)
+		if etag := rec.Header().Get("ETag"); etag != `"1"` {
+			t.Fatalf("ETag = %q, want \"1\"", etag)
+		}
+
+		s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/products", map[string]interface{}{"price": "1.00"})
+		s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/products", map[string]interface{}{"name": "Cup", "price": "1.001"})
+
+		update := map[string]interface{}{"name": "Electric kettle"}
+		s.expect(http.StatusPreconditionFailed, http.MethodPut, path, update, "If-Match", `"7"`)
+		decode(t, s.expect(http.StatusOK, http.MethodPut, path, update, "If-Match", `"1"`), &product)
+		if product.Name != "Electric kettle" || product.Version != 2 {
+			t.Fatalf("updated product = %+v", product)
+		}
+
+		s.expect(http.StatusPreconditionFailed, http.MethodDelete, path, nil, "If-Match", `"1"`)
+		s.expect(http.StatusNoContent, http.MethodDelete, path, nil, "If-Match", `"2"`)
+		s.expect(http.StatusNotFound, http.MethodGet, path, nil)
+		s.expect(http.StatusNotFound, http.MethodDelete, path, nil)
+		s.expect(http.StatusNotFound, http.MethodPut, path, update)
+		s.expect(http.StatusNotFound, http.MethodGet, "/api/v1/products/999", nil)
+	})
+}
+
+func TestListPagination(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		var want []int64
+		for i := 1; i <= 5; i++ {
+			want = append([]int64{s.createProduct(fmt.Sprintf("Product %d", i), "1.00", 1).ID}, want...)
+		}
+
+		// По умолчанию новые продукты первыми; страницы не пересекаются
+		var got []int64
+		for page := 1; page <= 3; page++ {
+			var response model.ProductListResponse
+			decode(t, s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/products?page=%d&limit=2", page), nil), &response)
+			if response.Total == nil || *response.Total != 5 {
+				t.Fatalf("page %d: total = %v, want 5", page, response.Total)
+			}
+			got = append(got, productIDs(response.Products)...)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("pages = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestCursorPagination(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		for _, name := range []string{"Delta", "Alpha", "Echo", "Charlie", "Bravo"} {
+			s.createProduct(name, "1.00", 1)
+		}
+
+		// Вперед по next_cursor до конца
+		var (
+			names   []string
+			pages   []model.ProductListResponse
+			request = "/api/v1/products?sort=name&limit=2"
+		)
+		for {
+			var response model.ProductListResponse
+			decode(t, s.expect(http.StatusOK, http.MethodGet, request, nil), &response)
+			pages = append(pages, response)
+			for _, product := range response.Products {
+				names = append(names, product.Name)
+			}
+			if response.NextCursor == "" {
+				break
+			}
+			request = "/api/v1/products?sort=name&limit=2&cursor=" + response.NextCursor
+		}
+		if fmt.Sprint(names) != "[Alpha Bravo Charlie Delta Echo]" {
+			t.Fatalf("names = %v", names)
+		}
+		if len(pages) != 3 || pages[0].PrevCursor != "" {
+			t.Fatalf("got %d pages, first prev_cursor %q", len(pages), pages[0].PrevCursor)
+		}
+
+		// prev_cursor последней страницы возвращает предыдущую
+		var previous model.ProductListResponse
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products?sort=name&limit=2&cursor="+pages[2].PrevCursor, nil), &previous)
+		if fmt.Sprint(productIDs(previous.Products)) != fmt.Sprint(productIDs(pages[1].Products)) {
+			t.Fatalf("previous page = %v, want %v", productIDs(previous.Products), productIDs(pages[1].Products))
+		}
+
+		// Курсор привязан к сортировке
+		s.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/products?sort=-created_at&limit=2&cursor="+pages[0].NextCursor, nil)
+		s.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/products?cursor=garbage", nil)
+	})
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAmountsAsStrings(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		product := s.createProduct("Kettle", "19.99", 1)
+
+		var body map[string]interface{}
+		decode(t, s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/products/%d?amounts=string", product.ID), nil), &body)
+		if body["price"] != "19.99" {
+			t.Fatalf("price = %#v, want \"19.99\"", body["price"])
+		}
+		decode(t, s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/products/%d", product.ID), nil), &body)
+		if body["price"] != 19.99 {
+			t.Fatalf("price = %#v, want 19.99", body["price"])
+		}
+		s.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/products?amounts=float", nil)
+	})
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBatchModes(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		existing := s.createProduct("Existing", "1.00", 1)
+
+		operations := []map[string]interface{}{
+			{"op": "create", "name": "New", "price": "2.00", "stock": 4},
+			{"op": "update", "id": existing.ID, "version": existing.Version, "stock": 7},
+			{"op": "update", "id": 999, "name": "Missing"},
+		}
+
+		// atomic: отклоненная операция откатывает остальные
+		var response model.ProductBatchResponse
+		decode(t, s.expect(http.StatusNotFound, http.MethodPost, "/api/v1/products/batch", map[string]interface{}{"mode": "atomic", "operations": operations}), &response)
+		if response.Succeeded != 0 || response.Failed != 3 {
+			t.Fatalf("atomic: succeeded %d, failed %d", response.Succeeded, response.Failed)
+		}
+		for i, want := range []int{http.StatusFailedDependency, http.StatusFailedDependency, http.StatusNotFound} {
+			if response.Results[i].Status != want {
+				t.Fatalf("atomic: result %d status %d, want %d", i, response.Results[i].Status, want)
+			}
+		}
+		var list model.ProductListResponse
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products", nil), &list)
+		if *list.Total != 1 || s.getProduct(existing.ID).Stock != 1 {
+			t.Fatalf("atomic batch was partially applied: total %d", *list.Total)
+		}
+
+		// best_effort: применяется все, что удалось
+		decode(t, s.expect(http.StatusMultiStatus, http.MethodPost, "/api/v1/products/batch", map[string]interface{}{"mode": "best_effort", "operations": operations}), &response)
+		for i, want := range []int{http.StatusCreated, http.StatusOK, http.StatusNotFound} {
+			if response.Results[i].Status != want {
+				t.Fatalf("best_effort: result %d status %d, want %d", i, response.Results[i].Status, want)
+			}
+		}
+		if s.getProduct(existing.ID).Stock != 7 {
+			t.Fatal("best_effort: update was not applied")
+		}
+
+		// Устаревшая версия отклоняется так же, как в одиночном запросе
+		stale := []map[string]interface{}{{"op": "delete", "id": existing.ID, "version": existing.Version}}
+		decode(t, s.expect(http.StatusPreconditionFailed, http.MethodPost, "/api/v1/products/batch", map[string]interface{}{"operations": stale}), &response)
+
+		// Один продукт дважды и один SKU дважды
+		twice := []map[string]interface{}{
+			{"op": "update", "id": existing.ID, "sku": "MUG-1"},
+			{"op": "create", "name": "Other", "price": "1.00", "sku": "mug-1"},
+			{"op": "delete", "id": existing.ID},
+		}
+		decode(t, s.expect(http.StatusMultiStatus, http.MethodPost, "/api/v1/products/batch", map[string]interface{}{"mode": "best_effort", "operations": twice}), &response)
+		for i, want := range []int{http.StatusOK, http.StatusBadRequest, http.StatusBadRequest} {
+			if response.Results[i].Status != want {
+				t.Fatalf("duplicates: result %d status %d, want %d", i, response.Results[i].Status, want)
+			}
+		}
+	})
+}
+
+func TestAtomicBatchChecksBeforeWrite(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		product := s.createProduct("Reserved", "1.00", 3)
+		s.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/api/v1/products/%d/reservations", product.ID), map[string]int{"quantity": 2})
+
+		// Изменение отклоняется после проверки создания, но создание не
+		// должно остаться в хранилище
+		operations := []map[string]interface{}{
+			{"op": "create", "name": "Ghost", "price": "1.00"},
+			{"op": "update", "id": product.ID, "stock": 1},
+		}
+		var response model.ProductBatchResponse
+		decode(t, s.expect(http.StatusConflict, http.MethodPost, "/api/v1/products/batch", map[string]interface{}{"mode": "atomic", "operations": operations}), &response)
+		for i, want := range []int{http.StatusFailedDependency, http.StatusConflict} {
+			if response.Results[i].Status != want {
+				t.Fatalf("result %d status %d, want %d", i, response.Results[i].Status, want)
+			}
+		}
+
+		var list model.ProductListResponse
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products", nil), &list)
+		if *list.Total != 1 {
+			t.Fatalf("total = %d, want 1: %v", *list.Total, productIDs(list.Products))
+		}
+		if product = s.getProduct(product.ID); product.Stock != 3 || product.Version != 1 {
+			t.Fatalf("product = %+v", product)
+		}
+	})
+}
+
+// interferingProducts меняет продукты сразу после первого GetByIDs, как
+// параллельный запрос между чтением пакета и его записью.
+type interferingProducts struct {
+	repository.ProductStore
+	armed atomic.Bool
+}
+
+func (p *interferingProducts) GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.Product, error) {
+	products, err := p.ProductStore.GetByIDs(ctx, ids)
+	if err == nil && p.armed.CompareAndSwap(true, false) {
+		for _, id := range ids {
+			if _, err := p.ProductStore.Update(ctx, id, map[string]interface{}{"name": "Changed"}, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return products, err
+}
+
+func TestBatchRetriesVersionlessConflicts(t *testing.T) {
+	for _, backend := range []string{"memory", "database"} {
+		t.Run(backend, func(t *testing.T) {
+			products := &interferingProducts{}
+			s := newTestServer(t, backend, func(stores *repository.Stores) {
+				products.ProductStore = stores.Products
+				stores.Products = products
+			})
+			s.login("alice")
+			product := s.createProduct("Contended", "1.00", 1)
+
+			for _, mode := range []string{"atomic", "best_effort"} {
+				// Операция без версии перечитывает продукт и повторяется
+				products.armed.Store(true)
+				operations := []map[string]interface{}{{"op": "update", "id": product.ID, "description": mode}}
+				var response model.ProductBatchResponse
+				decode(t, s.expect(http.StatusOK, http.MethodPost, "/api/v1/products/batch", map[string]interface{}{"mode": mode, "operations": operations}), &response)
+				if got := response.Results[0].Product; got.Description != mode {
+					t.Fatalf("%s: product = %+v", mode, got)
+				}
+
+				// С версией клиента конфликт остается конфликтом
+				product = s.getProduct(product.ID)
+				products.armed.Store(true)
+				operations[0]["version"] = product.Version
+				rec := s.do(http.MethodPost, "/api/v1/products/batch", map[string]interface{}{"mode": mode, "operations": operations})
+				decode(t, rec, &response)
+				if response.Results[0].Status != http.StatusPreconditionFailed {
+					t.Fatalf("%s with version: status %d, result %+v", mode, rec.Code, response.Results[0])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"demo-service/internal/model"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestListFilters(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		before := time.Now().UTC().Add(-time.Second)
+		cheap := s.createProduct("Cheap", "10.00", 0)
+		dear := s.createProduct("Dear", "25.00", 5)
+		euro := s.createProductIn("Euro", "20.00", "EUR")
+		yen := s.createProductIn("Yen", "1500", "JPY")
+
+		list := func(query string) []int64 {
+			t.Helper()
+			var response model.ProductListResponse
+			decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products?"+query, nil), &response)
+			return productIDs(response.Products)
+		}
+		tests := []struct {
+			query string
+			want  []int64
+		}{
+			{"price_currency=USD", []int64{dear.ID, cheap.ID}},
+			{"price_currency=usd&min_price=10.01", []int64{dear.ID}},
+			{"price_currency=USD&max_price=10", []int64{cheap.ID}},
+			{"price_currency=USD&min_price=10&max_price=25", []int64{dear.ID, cheap.ID}},
+			// Границы в единицах price_currency: 20 евро, а не 20 центов или иен
+			{"price_currency=EUR&min_price=20", []int64{euro.ID}},
+			{"price_currency=JPY&min_price=1000", []int64{yen.ID}},
+			{"price_currency=USD&sort=-price", []int64{dear.ID, cheap.ID}},
+			{"price_currency=USD&sort=price", []int64{cheap.ID, dear.ID}},
+			{"in_stock=false", []int64{yen.ID, euro.ID, cheap.ID}},
+			{"in_stock=true", []int64{dear.ID}},
+			{"min_stock=1&max_stock=5", []int64{dear.ID}},
+			{"max_stock=0&price_currency=USD", []int64{cheap.ID}},
+			{"created_after=" + url.QueryEscape(before.Format(time.RFC3339)), []int64{yen.ID, euro.ID, dear.ID, cheap.ID}},
+			{"created_before=" + url.QueryEscape(before.Format(time.RFC3339)), []int64{}},
+			{"updated_since=2000-01-01", []int64{yen.ID, euro.ID, dear.ID, cheap.ID}},
+			{"sort=name", []int64{cheap.ID, dear.ID, euro.ID, yen.ID}},
+		}
+		for _, tt := range tests {
+			if got := list(tt.query); !slices.Equal(got, tt.want) {
+				t.Errorf("%s: %v, want %v", tt.query, got, tt.want)
+			}
+		}
+
+		for _, query := range []string{
+			"min_price=10",
+			"sort=price",
+			"price_currency=XXX",
+			"price_currency=USD&min_price=-1",
+			"price_currency=USD&min_price=abc",
+			"price_currency=JPY&min_price=10.5",
+			"price_currency=USD&min_price=20&max_price=10",
+			"min_stock=5&max_stock=1",
+			"min_stock=-1",
+			"in_stock=maybe",
+			"created_after=yesterday",
+			"created_after=2024-02-01&created_before=2024-01-01",
+			"sort=weight",
+			"category=0",
+			"include_subcategories=true",
+		} {
+			s.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/products?"+query, nil)
+		}
+	})
+}
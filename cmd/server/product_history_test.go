@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHistoryAndAsOf(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		path := func(id int64) string { return fmt.Sprintf("/api/v1/products/%d", id) }
+
+		// Метки времени между изменениями: created_at записей истории
+		// строго раньше следующей метки
+		var marks []time.Time
+		mark := func() {
+			time.Sleep(5 * time.Millisecond)
+			marks = append(marks, time.Now())
+			time.Sleep(5 * time.Millisecond)
+		}
+		mark()
+		product := s.createProduct("Kettle", "10.00", 1)
+		mark()
+		s.expect(http.StatusOK, http.MethodPut, path(product.ID), map[string]interface{}{"name": "Steel kettle"})
+		mark()
+		s.expect(http.StatusOK, http.MethodPut, path(product.ID), map[string]interface{}{"price": "12.00"})
+		mark()
+		s.expect(http.StatusNoContent, http.MethodDelete, path(product.ID), nil)
+		mark()
+		s.expect(http.StatusOK, http.MethodPost, path(product.ID)+"/restore", nil)
+		mark()
+
+		var history model.ProductHistoryResponse
+		decode(t, s.expect(http.StatusOK, http.MethodGet, path(product.ID)+"/history", nil), &history)
+		var operations []string
+		for _, entry := range history.Entries {
+			operations = append(operations, entry.Operation)
+			if entry.ActorID == nil {
+				t.Errorf("%s entry has no actor", entry.Operation)
+			}
+		}
+		want := []string{model.ProductOpRestore, model.ProductOpDelete, model.ProductOpUpdate, model.ProductOpUpdate, model.ProductOpCreate}
+		if history.Total != 5 || !slices.Equal(operations, want) {
+			t.Fatalf("history: total %d, operations %v, want %v", history.Total, operations, want)
+		}
+		if change := history.Entries[2].Changes["price_minor"]; change.After == nil {
+			t.Errorf("price update entry has no price change: %+v", history.Entries[2].Changes)
+		}
+		decode(t, s.expect(http.StatusOK, http.MethodGet, path(product.ID)+"/history?page=2&limit=2", nil), &history)
+		if len(history.Entries) != 2 || history.Entries[0].Operation != model.ProductOpUpdate || history.Entries[1].Version != history.Entries[0].Version-1 {
+			t.Fatalf("history page 2: %+v", history.Entries)
+		}
+
+		asOf := func(at time.Time) *httptest.ResponseRecorder {
+			return s.do(http.MethodGet, path(product.ID)+"?as_of="+url.QueryEscape(at.Format(time.RFC3339Nano)), nil)
+		}
+		if rec := asOf(marks[0]); rec.Code != http.StatusNotFound {
+			t.Errorf("as of before create: status %d", rec.Code)
+		}
+		for i, want := range []struct {
+			name  string
+			price int64
+		}{{"Kettle", 1000}, {"Steel kettle", 1000}, {"Steel kettle", 1200}} {
+			rec := asOf(marks[i+1])
+			var past model.Product
+			decode(t, rec, &past)
+			if rec.Code != http.StatusOK || past.Name != want.name || past.PriceMinor != want.price || past.Currency != "USD" {
+				t.Errorf("as of mark %d: status %d, %q %d %s, want %q %d", i+1, rec.Code, past.Name, past.PriceMinor, past.Currency, want.name, want.price)
+			}
+		}
+		if rec := asOf(marks[4]); rec.Code != http.StatusNotFound {
+			t.Errorf("as of after delete: status %d", rec.Code)
+		}
+		if rec := asOf(marks[5]); rec.Code != http.StatusOK {
+			t.Errorf("as of after restore: status %d", rec.Code)
+		}
+		s.expect(http.StatusBadRequest, http.MethodGet, path(product.ID)+"?as_of=yesterday", nil)
+	})
+}
+
+func TestHistoryLegacyPrice(t *testing.T) {
+	for _, backend := range []string{"memory", "database"} {
+		t.Run(backend, func(t *testing.T) {
+			t.Setenv("PRODUCT_DEFAULT_CURRENCY", "JPY")
+			var history repository.ProductHistoryStore
+			s := newTestServer(t, backend, func(stores *repository.Stores) {
+				history = stores.ProductHistory
+			})
+			s.login("alice")
+
+			// Записи до перехода на минимальные единицы хранят цену
+			// десятичным числом в валюте по умолчанию
+			for id, price := range map[int64]float64{42: 1500.5, 43: 19.99} {
+				err := history.Add(context.Background(), &model.ProductHistoryEntry{
+					ProductID: id,
+					Version:   1,
+					Operation: model.ProductOpSnapshot,
+					Changes: map[string]model.FieldChange{
+						"name":  {After: "Legacy"},
+						"price": {After: price},
+					},
+				})
+				if err != nil {
+					t.Fatalf("add history: %v", err)
+				}
+			}
+
+			// Цена переводится так же, как миграция перевела цены продуктов
+			asOf := url.QueryEscape(time.Now().Add(time.Second).Format(time.RFC3339))
+			for id, want := range map[int64]int64{42: 1501, 43: 20} {
+				var product model.Product
+				decode(t, s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/products/%d?as_of=%s", id, asOf), nil), &product)
+				if product.PriceMinor != want || product.Currency != "JPY" {
+					t.Fatalf("product %d as of %s: %d %s, want %d JPY", id, asOf, product.PriceMinor, product.Currency, want)
+				}
+			}
+		})
+	}
+}
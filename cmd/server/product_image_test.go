@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// uploadImage отправляет data полем file multipart-формы.
+func (s *testServer) uploadImage(productID int64, filename string, data []byte) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+	return s.do(http.MethodPost, fmt.Sprintf("/api/v1/products/%d/images", productID), body.String(), "Content-Type", form.FormDataContentType())
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProductImages(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		kettle := s.createProduct("Kettle", "10.00", 1)
+		other := s.createProduct("Mug", "5.00", 1)
+		picture := pngImage(t, 600, 300)
+
+		rec := s.uploadImage(kettle.ID, "kettle.png", picture)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("upload: status %d, body %s", rec.Code, rec.Body.String())
+		}
+		var uploaded model.ProductImage
+		decode(t, rec, &uploaded)
+		if uploaded.ContentType != "image/png" || uploaded.Size != int64(len(picture)) || uploaded.Width == nil || *uploaded.Width != 600 || uploaded.ThumbnailURL == "" {
+			t.Fatalf("uploaded %+v", uploaded)
+		}
+
+		rec = s.expect(http.StatusOK, http.MethodGet, uploaded.URL, nil)
+		if !bytes.Equal(rec.Body.Bytes(), picture) || rec.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("download: %d bytes, type %s", rec.Body.Len(), rec.Header().Get("Content-Type"))
+		}
+		rec = s.expect(http.StatusOK, http.MethodGet, uploaded.ThumbnailURL, nil)
+		thumbnail, err := png.DecodeConfig(rec.Body)
+		if err != nil {
+			t.Fatalf("decode thumbnail: %v", err)
+		}
+		if thumbnail.Width != config.AppConfig.ThumbnailSize || thumbnail.Height != config.AppConfig.ThumbnailSize/2 {
+			t.Fatalf("thumbnail %dx%d", thumbnail.Width, thumbnail.Height)
+		}
+		if product := s.getProduct(kettle.ID); len(product.Images) != 1 || product.Images[0].ID != uploaded.ID {
+			t.Fatalf("product images = %+v", product.Images)
+		}
+
+		// Тип определяется по содержимому, а не по имени файла
+		var document model.ProductImage
+		rec = s.uploadImage(kettle.ID, "manual.png", []byte("%PDF-1.4\n%%EOF\n"))
+		decode(t, rec, &document)
+		if rec.Code != http.StatusCreated || document.ContentType != "application/pdf" || document.ThumbnailURL != "" {
+			t.Fatalf("upload pdf: status %d, %+v", rec.Code, document)
+		}
+		s.expect(http.StatusNotFound, http.MethodGet, document.URL+"/thumbnail", nil)
+		if rec := s.uploadImage(kettle.ID, "notes.txt", []byte("just text")); rec.Code != http.StatusUnsupportedMediaType {
+			t.Fatalf("upload text: status %d", rec.Code)
+		}
+		config.AppConfig.ImageMaxBytes = 1024
+		if rec := s.uploadImage(kettle.ID, "big.png", pngImage(t, 600, 300)); rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("upload too large: status %d", rec.Code)
+		}
+		if rec := s.uploadImage(999, "kettle.png", picture[:100]); rec.Code != http.StatusNotFound {
+			t.Fatalf("upload to a missing product: status %d", rec.Code)
+		}
+
+		// Файл доступен только через свой продукт
+		s.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/api/v1/products/%d/images/%d", other.ID, uploaded.ID), nil)
+		s.expect(http.StatusNotFound, http.MethodDelete, fmt.Sprintf("/api/v1/products/%d/images/%d", other.ID, uploaded.ID), nil)
+
+		s.expect(http.StatusNoContent, http.MethodDelete, uploaded.URL, nil)
+		s.expect(http.StatusNotFound, http.MethodGet, uploaded.URL, nil)
+		s.expect(http.StatusNotFound, http.MethodGet, uploaded.ThumbnailURL, nil)
+		if product := s.getProduct(kettle.ID); len(product.Images) != 1 || product.Images[0].ID != document.ID {
+			t.Fatalf("product images after delete = %+v", product.Images)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"demo-service/internal/model"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestReservations(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		product := s.createProduct("Last one", "5.00", 1)
+		path := fmt.Sprintf("/api/v1/products/%d/reservations", product.ID)
+
+		// Последнюю единицу получает ровно один из параллельных запросов
+		const attempts = 8
+		responses := make(chan *httptest.ResponseRecorder, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				responses <- s.do(http.MethodPost, path, map[string]int{"quantity": 1})
+			}()
+		}
+		wg.Wait()
+		close(responses)
+		var (
+			reserved model.ReservationResponse
+			counts   = make(map[int]int)
+		)
+		for rec := range responses {
+			counts[rec.Code]++
+			if rec.Code == http.StatusCreated {
+				decode(t, rec, &reserved)
+			}
+		}
+		if counts[http.StatusCreated] != 1 || counts[http.StatusConflict] != attempts-1 {
+			t.Fatalf("statuses = %v, want one 201 and %d 409", counts, attempts-1)
+		}
+
+		product = s.getProduct(product.ID)
+		if product.Reserved != 1 || product.Available != 0 {
+			t.Fatalf("after reserve: reserved %d, available %d", product.Reserved, product.Available)
+		}
+		reservation := fmt.Sprintf("%s/%d", path, reserved.Reservation.ID)
+		s.expect(http.StatusOK, http.MethodGet, reservation, nil)
+
+		var committed model.ReservationResponse
+		decode(t, s.expect(http.StatusOK, http.MethodPost, reservation+"/commit", nil), &committed)
+		if committed.Reservation.Status != model.ReservationStatusCommitted || committed.Product.Stock != 0 || committed.Product.Reserved != 0 {
+			t.Fatalf("committed = %+v, product %+v", committed.Reservation, committed.Product)
+		}
+		s.expect(http.StatusConflict, http.MethodPost, reservation+"/commit", nil)
+		s.expect(http.StatusConflict, http.MethodPost, reservation+"/release", nil)
+		s.expect(http.StatusConflict, http.MethodPost, path, map[string]int{"quantity": 1})
+
+		// Отмена возвращает единицы в доступный остаток
+		product = s.createProduct("Pair", "5.00", 2)
+		path = fmt.Sprintf("/api/v1/products/%d/reservations", product.ID)
+		decode(t, s.expect(http.StatusCreated, http.MethodPost, path, map[string]int{"quantity": 2}), &reserved)
+		s.expect(http.StatusConflict, http.MethodPost, path, map[string]int{"quantity": 1})
+		var released model.ReservationResponse
+		decode(t, s.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("%s/%d/release", path, reserved.Reservation.ID), nil), &released)
+		if released.Product.Available != 2 || released.Product.Stock != 2 {
+			t.Fatalf("after release: %+v", released.Product)
+		}
+	})
+}
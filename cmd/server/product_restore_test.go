@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRestoreAndPurge(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		path := func(id int64) string { return fmt.Sprintf("/api/v1/products/%d", id) }
+		kept := s.createProduct("Kept", "10.00", 1)
+		restored := s.createProduct("Restored", "10.00", 1)
+		purged := s.createProduct("Purged", "10.00", 1)
+		if rec := s.uploadImage(purged.ID, "purged.png", pngImage(t, 10, 10)); rec.Code != http.StatusCreated {
+			t.Fatalf("upload: status %d", rec.Code)
+		}
+		blobDir := filepath.Join(config.AppConfig.BlobLocalDir, "products", fmt.Sprint(purged.ID))
+		if _, err := os.Stat(blobDir); err != nil {
+			t.Fatalf("files of the product: %v", err)
+		}
+
+		for _, product := range []model.Product{restored, purged} {
+			s.expect(http.StatusNoContent, http.MethodDelete, path(product.ID), nil)
+			s.expect(http.StatusNotFound, http.MethodGet, path(product.ID), nil)
+		}
+		listIDs := func(query string) []int64 {
+			t.Helper()
+			var response model.ProductListResponse
+			decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products?sort=id&"+query, nil), &response)
+			return productIDs(response.Products)
+		}
+		if got := listIDs(""); !slices.Equal(got, []int64{kept.ID}) {
+			t.Fatalf("list after delete: %v, want [%d]", got, kept.ID)
+		}
+
+		// Восстановление возвращает продукт с новой версией
+		var product model.Product
+		decode(t, s.expect(http.StatusOK, http.MethodPost, path(restored.ID)+"/restore", nil), &product)
+		if product.DeletedAt != nil || product.Version <= restored.Version {
+			t.Fatalf("restored %+v", product)
+		}
+		s.expect(http.StatusNotFound, http.MethodPost, path(restored.ID)+"/restore", nil)
+		s.expect(http.StatusNotFound, http.MethodPost, path(kept.ID)+"/restore", nil)
+		s.expect(http.StatusNotFound, http.MethodPost, path(999)+"/restore", nil)
+
+		// SKU удаленного продукта мог занять другой
+		var old model.Product
+		decode(t, s.expect(http.StatusCreated, http.MethodPost, "/api/v1/products", map[string]interface{}{"sku": "DUP-1", "name": "Old", "price": "1.00"}), &old)
+		s.expect(http.StatusNoContent, http.MethodDelete, path(old.ID), nil)
+		var taken model.Product
+		decode(t, s.expect(http.StatusCreated, http.MethodPost, "/api/v1/products", map[string]interface{}{"sku": "DUP-1", "name": "New", "price": "1.00"}), &taken)
+		s.expect(http.StatusConflict, http.MethodPost, path(old.ID)+"/restore", nil)
+
+		s.loginAdmin("root")
+		if got, want := listIDs("include_deleted=true"), []int64{kept.ID, restored.ID, purged.ID, old.ID, taken.ID}; !slices.Equal(got, want) {
+			t.Fatalf("list with deleted: %v, want %v", got, want)
+		}
+
+		// Недавно удаленные хранятся PRODUCT_DELETE_RETENTION
+		ctx := context.Background()
+		if n, err := s.app.productService.PurgeDeleted(ctx, time.Hour); err != nil || n != 0 {
+			t.Fatalf("purge with retention: %d, %v", n, err)
+		}
+		n, err := s.app.productService.PurgeDeleted(ctx, 0)
+		if err != nil || n != 2 {
+			t.Fatalf("purge: %d, %v, want 2", n, err)
+		}
+		if got := listIDs("include_deleted=true"); slices.Contains(got, purged.ID) || slices.Contains(got, old.ID) || !slices.Contains(got, restored.ID) {
+			t.Fatalf("list after purge: %v", got)
+		}
+		s.expect(http.StatusNotFound, http.MethodPost, path(purged.ID)+"/restore", nil)
+		if _, err := os.Stat(blobDir); !os.IsNotExist(err) {
+			t.Fatalf("files of the purged product: %v", err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"demo-service/internal/model"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		create := func(name, description string, stock int) model.Product {
+			var product model.Product
+			body := map[string]interface{}{"name": name, "description": description, "price": "10.00", "stock": stock}
+			decode(t, s.expect(http.StatusCreated, http.MethodPost, "/api/v1/products", body), &product)
+			return product
+		}
+		red := create("Red kettle", "Steel body", 5)
+		descaler := create("Kettle descaler", "Cleans red kettles", 0)
+		teapot := create("Teapot", "Ceramic, fits a kettle of tea", 3)
+		toy := create("<b>Cat</b> toy", "", 1)
+		removed := create("Old kettle", "", 1)
+		s.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/api/v1/products/%d", removed.ID), nil)
+
+		search := func(query string) []model.Product {
+			t.Helper()
+			var response model.ProductListResponse
+			decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products?q="+url.QueryEscape(query), nil), &response)
+			return response.Products
+		}
+		sorted := func(products []model.Product) []int64 {
+			ids := productIDs(products)
+			slices.Sort(ids)
+			return ids
+		}
+
+		// Удаленные не находятся, название весит больше описания
+		found := search("kettle")
+		if got, want := sorted(found), []int64{red.ID, descaler.ID, teapot.ID}; !slices.Equal(got, want) {
+			t.Fatalf("kettle: found %v, want %v", got, want)
+		}
+		if found[len(found)-1].ID != teapot.ID {
+			t.Errorf("kettle: description match ranked above name matches: %v", productIDs(found))
+		}
+
+		if got := productIDs(search(`"red kettle"`)); !slices.Equal(got, []int64{red.ID}) {
+			t.Errorf(`"red kettle": found %v, want [%d]`, got, red.ID)
+		}
+		if got, want := sorted(search("red kettle*")), []int64{red.ID, descaler.ID}; !slices.Equal(got, want) {
+			t.Errorf("red kettle*: found %v, want %v", got, want)
+		}
+		if got := search("kett"); len(got) != 0 {
+			t.Errorf("kett: found %v, want whole words only", productIDs(got))
+		}
+
+		highlighted := search("teapot")
+		if len(highlighted) != 1 || highlighted[0].Highlight == nil || highlighted[0].Highlight.Name != "<mark>Teapot</mark>" {
+			t.Fatalf("teapot: highlight %+v", highlighted)
+		}
+		// HTML из названия экранируется, остается только <mark>
+		highlighted = search("cat")
+		if len(highlighted) != 1 || highlighted[0].ID != toy.ID || highlighted[0].Highlight.Name != "&lt;b&gt;<mark>Cat</mark>&lt;/b&gt; toy" {
+			t.Fatalf("cat: highlight %+v", highlighted)
+		}
+
+		var response model.ProductListResponse
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products?q=kettle&in_stock=false", nil), &response)
+		if got := productIDs(response.Products); !slices.Equal(got, []int64{descaler.ID}) {
+			t.Errorf("kettle without stock: found %v, want [%d]", got, descaler.ID)
+		}
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products?q=kettle&sort=name", nil), &response)
+		if got, want := productIDs(response.Products), []int64{descaler.ID, red.ID, teapot.ID}; !slices.Equal(got, want) {
+			t.Errorf("kettle by name: found %v, want %v", got, want)
+		}
+
+		s.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/products?q="+url.QueryEscape("!!!"), nil)
+	})
+}
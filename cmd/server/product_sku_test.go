@@ -0,0 +1,91 @@
+package main
+
+import (
+	"demo-service/internal/model"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestProductSKU(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		var kettle model.Product
+		body := map[string]interface{}{"sku": "ket-1", "name": "Kettle", "price": "10.00", "stock": 1}
+		decode(t, s.expect(http.StatusCreated, http.MethodPost, "/api/v1/products", body), &kettle)
+		if kettle.SKU == nil || *kettle.SKU != "KET-1" {
+			t.Fatalf("sku = %v, want KET-1", kettle.SKU)
+		}
+
+		// Поиск без учета регистра, SKU уникален
+		var found model.Product
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products/by-sku/Ket-1", nil), &found)
+		if found.ID != kettle.ID {
+			t.Fatalf("by-sku found %d, want %d", found.ID, kettle.ID)
+		}
+		s.expect(http.StatusNotFound, http.MethodGet, "/api/v1/products/by-sku/NONE-1", nil)
+		s.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/products/by-sku/bad%20sku", nil)
+		s.expect(http.StatusConflict, http.MethodPost, "/api/v1/products", map[string]interface{}{"sku": "KET-1", "name": "Copy", "price": "1.00"})
+
+		// PUT by-sku создает, затем заменяет продукт целиком
+		var mug model.Product
+		rec := s.expect(http.StatusCreated, http.MethodPut, "/api/v1/products/by-sku/mug-1", map[string]interface{}{"name": "Mug", "description": "White", "price": "5.00", "stock": 2})
+		decode(t, rec, &mug)
+		etag := rec.Header().Get("ETag")
+		if mug.SKU == nil || *mug.SKU != "MUG-1" || etag == "" {
+			t.Fatalf("upsert created %+v, etag %q", mug, etag)
+		}
+		var replaced model.Product
+		decode(t, s.expect(http.StatusOK, http.MethodPut, "/api/v1/products/by-sku/MUG-1", map[string]interface{}{"name": "Black mug", "price": "6.00"}, "If-Match", etag), &replaced)
+		if replaced.ID != mug.ID || replaced.Name != "Black mug" || replaced.Description != "" || replaced.Stock != 0 || replaced.PriceMinor != 600 {
+			t.Fatalf("upsert replaced %+v", replaced)
+		}
+		s.expect(http.StatusPreconditionFailed, http.MethodPut, "/api/v1/products/by-sku/MUG-1", map[string]interface{}{"name": "Stale", "price": "1.00"}, "If-Match", etag)
+		s.expect(http.StatusBadRequest, http.MethodPut, "/api/v1/products/by-sku/MUG-1", map[string]interface{}{"sku": "KET-1", "name": "Other", "price": "1.00"})
+
+		// SKU можно сменить или снять через PUT /:id; удаленный продукт SKU не держит
+		s.expect(http.StatusConflict, http.MethodPut, fmt.Sprintf("/api/v1/products/%d", mug.ID), map[string]interface{}{"sku": "ket-1"})
+		s.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/api/v1/products/%d", mug.ID), map[string]interface{}{"sku": ""})
+		s.expect(http.StatusNotFound, http.MethodGet, "/api/v1/products/by-sku/MUG-1", nil)
+		s.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/api/v1/products/%d", kettle.ID), nil)
+		s.expect(http.StatusNotFound, http.MethodGet, "/api/v1/products/by-sku/KET-1", nil)
+		s.expect(http.StatusCreated, http.MethodPost, "/api/v1/products", map[string]interface{}{"sku": "KET-1", "name": "New kettle", "price": "12.00"})
+	})
+}
+
+func TestImportBySKU(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		var kettle model.Product
+		body := map[string]interface{}{"sku": "KET-1", "name": "Kettle", "description": "Steel", "price": "10.00", "stock": 4}
+		decode(t, s.expect(http.StatusCreated, http.MethodPost, "/api/v1/products", body), &kettle)
+
+		csv := "sku,name,price\nket-1,Kettle v2,12.00\nNEW-1,New,5.00\n,Nameless,1.00\n"
+		var job model.ProductImportJob
+		decode(t, s.expect(http.StatusOK, http.MethodPost, "/api/v1/products/import?key=sku", csv, "Content-Type", "text/csv"), &job)
+		if job.Status != model.ImportStatusCompleted || job.UpdatedRows != 1 || job.CreatedRows != 1 || job.FailedRows != 1 {
+			t.Fatalf("import job = %+v", job)
+		}
+		if len(job.RowErrors) != 1 || job.RowErrors[0].Row != 4 {
+			t.Fatalf("row errors = %+v, want row 4 without sku", job.RowErrors)
+		}
+
+		// Столбцов description и stock нет в файле: они не меняются
+		updated := s.getProduct(kettle.ID)
+		if updated.Name != "Kettle v2" || updated.PriceMinor != 1200 || updated.Description != "Steel" || updated.Stock != 4 {
+			t.Fatalf("updated by sku: %+v", updated)
+		}
+		var created model.Product
+		decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products/by-sku/NEW-1", nil), &created)
+		if created.Name != "New" {
+			t.Fatalf("created by sku: %+v", created)
+		}
+
+		// С key=id строка с SKU другого продукта отклоняется
+		csv = fmt.Sprintf("id,sku,name,price\n%d,NEW-1,Clash,1.00\n", kettle.ID)
+		decode(t, s.expect(http.StatusOK, http.MethodPost, "/api/v1/products/import", csv, "Content-Type", "text/csv"), &job)
+		if job.FailedRows != 1 {
+			t.Fatalf("import with a taken sku = %+v", job)
+		}
+	})
+}
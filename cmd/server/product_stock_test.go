@@ -0,0 +1,71 @@
+package main
+
+import (
+	"demo-service/internal/model"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestAdjustStock(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		product := s.createProduct("Mug", "3.00", 3)
+		path := fmt.Sprintf("/api/v1/products/%d/stock/adjust", product.ID)
+
+		s.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/api/v1/products/%d/reservations", product.ID), map[string]int{"quantity": 2})
+
+		// PUT не перезаписывает остаток: изменения идут только через журнал
+		s.expect(http.StatusBadRequest, http.MethodPut, fmt.Sprintf("/api/v1/products/%d", product.ID), map[string]interface{}{"name": "Mug", "stock": 10})
+
+		// Остаток не может стать меньше резерва
+		s.expect(http.StatusConflict, http.MethodPost, path, map[string]interface{}{"delta": -2, "reason": "adjustment"})
+		s.expect(http.StatusBadRequest, http.MethodPost, path, map[string]interface{}{"delta": 0, "reason": "adjustment"})
+		s.expect(http.StatusBadRequest, http.MethodPost, path, map[string]interface{}{"delta": 1, "reason": "gift"})
+
+		var adjusted model.StockAdjustResponse
+		decode(t, s.expect(http.StatusOK, http.MethodPost, path, map[string]interface{}{"delta": -1, "reason": "sale", "reference": "order-1"}), &adjusted)
+		if adjusted.Product.Stock != 2 || adjusted.Movement.Delta != -1 || adjusted.Movement.StockAfter != 2 {
+			t.Fatalf("adjusted = %+v, product %+v", adjusted.Movement, adjusted.Product)
+		}
+
+		// Параллельные изменения не теряются
+		const restocks = 10
+		var wg sync.WaitGroup
+		errs := make(chan string, restocks)
+		for i := 0; i < restocks; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if rec := s.do(http.MethodPost, path, map[string]interface{}{"delta": 1, "reason": "restock"}); rec.Code != http.StatusOK {
+					errs <- fmt.Sprintf("status %d: %s", rec.Code, rec.Body.String())
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+
+		product = s.getProduct(product.ID)
+		if product.Stock != 2+restocks || product.Reserved != 2 {
+			t.Fatalf("stock %d, reserved %d; want %d, 2", product.Stock, product.Reserved, 2+restocks)
+		}
+
+		// Журнал: начальный остаток, продажа и пополнения
+		var movements model.StockMovementListResponse
+		decode(t, s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/products/%d/stock/movements?limit=100", product.ID), nil), &movements)
+		if movements.Total != 2+restocks {
+			t.Fatalf("movements total = %d, want %d", movements.Total, 2+restocks)
+		}
+		sum := 0
+		for _, movement := range movements.Movements {
+			sum += movement.Delta
+		}
+		if sum != product.Stock {
+			t.Fatalf("sum of movements = %d, stock = %d", sum, product.Stock)
+		}
+	})
+}
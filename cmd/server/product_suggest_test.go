@@ -0,0 +1,48 @@
+package main
+
+import (
+	"demo-service/internal/model"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		kettle := s.createProduct("Kettle", "10.00", 1)
+		electric := s.createProduct("Electric kettle", "30.00", 1)
+		s.createProduct("Teapot", "15.00", 1)
+		removed := s.createProduct("Kettlebell", "20.00", 1)
+		s.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/api/v1/products/%d", removed.ID), nil)
+
+		suggest := func(query string) []int64 {
+			t.Helper()
+			var response model.ProductSuggestResponse
+			decode(t, s.expect(http.StatusOK, http.MethodGet, "/api/v1/products/suggest?"+query, nil), &response)
+			ids := make([]int64, len(response.Suggestions))
+			for i, suggestion := range response.Suggestions {
+				ids[i] = suggestion.ID
+			}
+			return ids
+		}
+
+		// Удаленные не предлагаются, начало названия выше слова в середине
+		if got, want := suggest("prefix=kett"), []int64{kettle.ID, electric.ID}; !slices.Equal(got, want) {
+			t.Errorf("kett: %v, want %v", got, want)
+		}
+		if got, want := suggest("prefix=ketle"), []int64{kettle.ID, electric.ID}; !slices.Equal(got, want) {
+			t.Errorf("ketle: %v, want %v", got, want)
+		}
+		if got, want := suggest("prefix=kett&limit=1"), []int64{kettle.ID}; !slices.Equal(got, want) {
+			t.Errorf("kett, limit 1: %v, want %v", got, want)
+		}
+		if got := suggest("prefix=mug"); len(got) != 0 {
+			t.Errorf("mug: %v, want none", got)
+		}
+
+		s.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/products/suggest", nil)
+		s.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/products/suggest?prefix=%20", nil)
+	})
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createProducts создает count продуктов пакетами.
+func (s *testServer) createProducts(count int) {
+	s.t.Helper()
+	for created := 0; created < count; {
+		var operations []map[string]interface{}
+		for ; created < count && len(operations) < 1000; created++ {
+			operations = append(operations, map[string]interface{}{
+				"op": "create", "name": fmt.Sprintf("Product %04d", created), "price": "1.00", "stock": created % 3,
+			})
+		}
+		s.expect(http.StatusOK, http.MethodPost, "/api/v1/products/batch", map[string]interface{}{"operations": operations})
+	}
+}
+
+// exportIDs выгружает продукты в NDJSON и возвращает их id по порядку.
+func (s *testServer) exportIDs(query string) []int64 {
+	s.t.Helper()
+	rec := s.expect(http.StatusOK, http.MethodGet, "/api/v1/products/export?format=ndjson&"+query, nil)
+	var ids []int64
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var product model.Product
+		if err := json.Unmarshal(scanner.Bytes(), &product); err != nil {
+			s.t.Fatalf("decode export line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
+
+func TestExportPages(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.login("alice")
+		// Больше двух страниц выгрузки; у продуктов пакета одинаковое
+		// время создания, порядок задает id
+		const count = 1234
+		s.createProducts(count)
+
+		ids := s.exportIDs("")
+		if len(ids) != count {
+			t.Fatalf("exported %d products, want %d", len(ids), count)
+		}
+		if !slices.IsSortedFunc(ids, func(a, b int64) int { return int(b - a) }) {
+			t.Fatal("export is not ordered by id descending")
+		}
+
+		// Фильтр и сортировка применяются на всех страницах
+		ids = s.exportIDs("in_stock=false&sort=name")
+		if len(ids) != (count+2)/3 {
+			t.Fatalf("exported %d products without stock, want %d", len(ids), (count+2)/3)
+		}
+		if !slices.IsSorted(ids) {
+			t.Fatal("export is not ordered by name")
+		}
+	})
+}
+
+// importCSV загружает CSV из rows строк и возвращает ответ.
+func (s *testServer) importCSV(rows int, status int) model.ProductImportJob {
+	s.t.Helper()
+	var file strings.Builder
+	file.WriteString("name,price,stock\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&file, "Imported %04d,2.50,%d\n", i, i%5)
+	}
+	var job model.ProductImportJob
+	decode(s.t, s.expect(status, http.MethodPost, "/api/v1/products/import?format=csv", file.String(), "Content-Type", "text/csv"), &job)
+	return job
+}
+
+func TestImportStoppedOnShutdown(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		t.Setenv("PRODUCT_IMPORT_SYNC_ROWS", "1")
+		if err := config.Load(); err != nil {
+			t.Fatalf("load config: %v", err)
+		}
+		s.login("alice")
+		job := s.importCSV(5000, http.StatusAccepted)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.app.productService.StopImports(ctx); err != nil {
+			t.Fatalf("StopImports: %v", err)
+		}
+
+		// После остановки задача завершена или прервана, но не "running"
+		decode(t, s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/products/imports/%d", job.ID), nil), &job)
+		switch {
+		case job.Status == model.ImportStatusCompleted && job.ProcessedRows == 5000:
+		case job.Status == model.ImportStatusFailed && strings.Contains(job.Error, "shutdown"):
+		default:
+			t.Fatalf("job after shutdown = %+v", job)
+		}
+	})
+}
+
+// staleImportJobs показывает задачи так, будто их не обновляли час.
+type staleImportJobs struct {
+	repository.ProductImportJobStore
+}
+
+func (s staleImportJobs) GetByID(ctx context.Context, id int64) (*model.ProductImportJob, error) {
+	job, err := s.ProductImportJobStore.GetByID(ctx, id)
+	if err == nil {
+		job.UpdatedAt = job.UpdatedAt.Add(-time.Hour)
+	}
+	return job, err
+}
+
+func TestImportInterruptedByCrash(t *testing.T) {
+	for _, backend := range []string{"memory", "database"} {
+		t.Run(backend, func(t *testing.T) {
+			var jobs repository.ProductImportJobStore
+			s := newTestServer(t, backend, func(stores *repository.Stores) {
+				jobs = stores.ProductImports
+				stores.ProductImports = staleImportJobs{jobs}
+			})
+			s.login("alice")
+
+			// Задача осталась "running" после аварийной остановки сервера
+			actorID := int64(1)
+			job := &model.ProductImportJob{Status: model.ImportStatusRunning, Format: "csv", Key: "id", ActorID: &actorID, TotalRows: 10, RowErrors: []model.ImportRowError{}}
+			if err := jobs.Create(context.Background(), job); err != nil {
+				t.Fatalf("create job: %v", err)
+			}
+			job.ProcessedRows = 4
+			if err := jobs.Update(context.Background(), job); err != nil {
+				t.Fatalf("update job: %v", err)
+			}
+
+			decode(t, s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/products/imports/%d", job.ID), nil), job)
+			if job.Status != model.ImportStatusFailed || job.FinishedAt == nil || job.Error != "Import was interrupted after 4 rows" {
+				t.Fatalf("stale job = %+v", job)
+			}
+			if saved, err := jobs.GetByID(context.Background(), job.ID); err != nil || saved.Status != model.ImportStatusFailed {
+				t.Fatalf("saved job = %+v, err %v", saved, err)
+			}
+		})
+	}
+}
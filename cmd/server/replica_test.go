@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newReplicaTestServer создает сервер на SQLite с репликой - отдельным
+// файлом, куда ничего не реплицируется: чтение с нее видно по отсутствию
+// данных. Реплику читают только продукты.
+func newReplicaTestServer(t *testing.T) *testServer {
+	t.Setenv("READ_YOUR_WRITES_WINDOW", "1m")
+	return newTestServer(t, "database", func(stores *repository.Stores) {
+		replicaURL := "sqlite://" + filepath.Join(t.TempDir(), "replica.db")
+		if err := database.InitReplicas([]string{replicaURL}, dbPoolConfig(), time.Hour); err != nil {
+			t.Fatalf("init replicas: %v", err)
+		}
+		t.Cleanup(func() {
+			database.Replicas.Close()
+			database.Replicas = nil
+		})
+
+		migrator, err := database.NewMigrator(database.Replicas.DBs()["replica-1"], database.CurrentDialect)
+		if err != nil {
+			t.Fatalf("load migrations: %v", err)
+		}
+		if _, err := migrator.Up(context.Background(), 0); err != nil {
+			t.Fatalf("migrate replica: %v", err)
+		}
+		stores.Products = repository.NewProductRepository()
+	})
+}
+
+func TestReadYourWrites(t *testing.T) {
+	s := newReplicaTestServer(t)
+	s.login("alice")
+
+	rec := s.expect(http.StatusCreated, http.MethodPost, "/api/v1/products", map[string]interface{}{"name": "Kettle", "price": "10.00"})
+	pin := rec.Header().Get("X-DB-Primary-Until")
+	if pin == "" {
+		t.Fatal("write response has no X-DB-Primary-Until")
+	}
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "db_primary_until" {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != pin || !cookie.HttpOnly {
+		t.Fatalf("pin cookie = %+v", cookie)
+	}
+	var product model.Product
+	decode(t, rec, &product)
+	path := fmt.Sprintf("/api/v1/products/%d", product.ID)
+
+	// Метка ведет чтение в primary, без нее чтение идет с отставшей реплики
+	s.expect(http.StatusOK, http.MethodGet, path, nil, "X-DB-Primary-Until", pin)
+	s.expect(http.StatusOK, http.MethodGet, path, nil, "Cookie", "db_primary_until="+pin)
+	s.expect(http.StatusNotFound, http.MethodGet, path, nil)
+
+	// Метку нельзя подделать или продлить
+	until, signature, _ := strings.Cut(pin, ".")
+	for _, forged := range []string{
+		until,
+		until + ".00",
+		strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10) + "." + signature,
+	} {
+		s.expect(http.StatusNotFound, http.MethodGet, path, nil, "X-DB-Primary-Until", forged)
+	}
+}
+
+func TestReplicaFallback(t *testing.T) {
+	s := newReplicaTestServer(t)
+	s.login("alice")
+	product := s.createProduct("Kettle", "10.00", 1)
+	path := fmt.Sprintf("/api/v1/products/%d", product.ID)
+	s.expect(http.StatusNotFound, http.MethodGet, path, nil)
+
+	// Ошибка реплики уводит чтение в primary до следующей проверки здоровья
+	database.Replicas.DBs()["replica-1"].Close()
+	s.expect(http.StatusOK, http.MethodGet, path, nil)
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/products", nil)
+}
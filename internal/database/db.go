@@ -1,42 +1,134 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	connectBackoffMin = 200 * time.Millisecond
+	connectBackoffMax = 10 * time.Second
 )
 
 var DB *sql.DB
 
-func Init(databaseURL string) error {
-	var err error
-	DB, err = sql.Open("postgres", databaseURL)
-	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+// PoolConfig задает параметры пула соединений. Нулевые значения
+// означают отсутствие ограничения, как в database/sql.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration
+	ConnMaxLifetime time.Duration
+}
+
+// Init открывает пул соединений и ждет доступности БД, повторяя попытки
+// до дедлайна ctx (см. WaitForConnection).
+func Init(ctx context.Context, databaseURL string, pool PoolConfig) error {
+	if err := Open(databaseURL, pool); err != nil {
+		return err
 	}
+	return WaitForConnection(ctx)
+}
 
-	DB.SetMaxOpenConns(25)
-	DB.SetMaxIdleConns(5)
-	DB.SetConnMaxLifetime(0)
+// Open создает пул соединений без обращения к БД: подключение
+// устанавливается при первом запросе.
+func Open(databaseURL string, pool PoolConfig) error {
+	dialect, dsn, err := ParseDatabaseURL(databaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid database URL: %w", err)
+	}
 
-	if err := DB.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+	if dialect.Name() == "sqlite" {
+		dsn, err = prepareSQLite(dsn)
+		if err != nil {
+			return err
+		}
 	}
 
-	if err := RunMigrations(); err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+	DB, err = sql.Open(dialect.DriverName(), dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
 	}
+	CurrentDialect = dialect
+
+	configurePool(DB, dialect, pool)
 
 	return nil
 }
 
+// WaitForConnection проверяет подключение к БД, повторяя попытки
+// с экспоненциальной задержкой и джиттером, пока не истечет ctx.
+func WaitForConnection(ctx context.Context) error {
+	for attempt := 0; ; attempt++ {
+		err := DB.PingContext(ctx)
+		if err == nil {
+			if attempt > 0 {
+				logrus.Infof("Database is reachable after %d attempt(s)", attempt+1)
+			}
+			return nil
+		}
+
+		delay := connectBackoff(attempt)
+		logrus.Warnf("Database is not reachable (attempt %d), retrying in %s: %v", attempt+1, delay.Round(time.Millisecond), err)
+
+		if waitErr := sleepContext(ctx, delay); waitErr != nil {
+			return fmt.Errorf("failed to ping database: %w", err)
+		}
+	}
+}
+
 func Close() error {
+	Replicas.Close()
 	if DB != nil {
 		return DB.Close()
 	}
 	return nil
 }
 
+func configurePool(db *sql.DB, dialect Dialect, pool PoolConfig) {
+	if dialect.Name() == "sqlite" {
+		// SQLite допускает только одного писателя
+		db.SetMaxOpenConns(1)
+	} else {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+}
 
+// prepareSQLite создает каталог для файла БД и добавляет к DSN pragma-параметры.
+func prepareSQLite(path string) (string, error) {
+	file, query, _ := strings.Cut(path, "?")
 
+	if file != ":memory:" && !strings.HasPrefix(file, "file::memory:") {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return "", fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	if query == "" {
+		return file + "?" + sqlitePragmas, nil
+	}
+	return file + "?" + query + "&" + sqlitePragmas, nil
+}
+
+// connectBackoff удваивает задержку с каждой попыткой до connectBackoffMax
+// и добавляет случайный разброс, чтобы реплики сервиса не ломились в БД одновременно.
+func connectBackoff(attempt int) time.Duration {
+	delay := connectBackoffMax
+	if attempt < 16 {
+		delay = min(connectBackoffMin<<attempt, connectBackoffMax)
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestConnectBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, connectBackoffMin / 2, connectBackoffMin},
+		{1, connectBackoffMin, 2 * connectBackoffMin},
+		{3, 4 * connectBackoffMin, 8 * connectBackoffMin},
+		{10, connectBackoffMax / 2, connectBackoffMax},
+		{100, connectBackoffMax / 2, connectBackoffMax},
+	} {
+		for i := 0; i < 20; i++ {
+			if got := connectBackoff(tt.attempt); got < tt.min || got > tt.max {
+				t.Fatalf("connectBackoff(%d) = %s, want within [%s, %s]", tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestWaitForConnectionDeadline(t *testing.T) {
+	if err := Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), PoolConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB = nil })
+	if err := WaitForConnection(context.Background()); err != nil {
+		t.Fatalf("wait for a reachable database: %v", err)
+	}
+
+	// Закрытый пул не отвечает никогда: ожидание заканчивается с дедлайном
+	DB.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := WaitForConnection(ctx); err == nil {
+		t.Fatal("wait for a closed database succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("wait ignored the deadline: %s", elapsed)
+	}
+}
+
+func TestParseIsolationLevel(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want sql.IsolationLevel
+	}{
+		{"", sql.LevelDefault},
+		{"default", sql.LevelDefault},
+		{"read_committed", sql.LevelReadCommitted},
+		{" Repeatable Read ", sql.LevelRepeatableRead},
+		{"SERIALIZABLE", sql.LevelSerializable},
+	} {
+		if got, err := ParseIsolationLevel(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseIsolationLevel(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseIsolationLevel("snapshot"); err == nil {
+		t.Error("ParseIsolationLevel(snapshot) succeeded")
+	}
+}
+
+func TestWithinTxRetry(t *testing.T) {
+	if err := Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), PoolConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+	ctx := context.Background()
+	if _, err := DB.ExecContext(ctx, "CREATE TABLE counters (n INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ошибки сериализации различает диалект, поэтому берем PostgreSQL
+	conflict := &pq.Error{Code: "40001"}
+	manager := &TxManager{db: DB, dialect: postgresDialect{}, maxRetries: 2}
+	count := func() (n int) {
+		t.Helper()
+		if err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM counters").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// Каждая попытка откатывается, успешной остается только последняя
+	attempts := 0
+	err := manager.WithinTx(ctx, func(ctx context.Context) error {
+		attempts++
+		if _, err := QuerierFrom(ctx, DB).ExecContext(ctx, "INSERT INTO counters (n) VALUES (?)", attempts); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return conflict
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 || count() != 1 {
+		t.Fatalf("retried tx: %v after %d attempts, %d rows", err, attempts, count())
+	}
+
+	attempts = 0
+	err = manager.WithinTx(ctx, func(ctx context.Context) error {
+		attempts++
+		return conflict
+	})
+	if !errors.Is(err, conflict) || attempts != 3 {
+		t.Fatalf("exhausted retries: %v after %d attempts", err, attempts)
+	}
+
+	// Прочие ошибки и вложенные вызовы не повторяются
+	attempts = 0
+	other := errors.New("validation failed")
+	err = manager.WithinTx(ctx, func(ctx context.Context) error {
+		attempts++
+		return other
+	})
+	if !errors.Is(err, other) || attempts != 1 {
+		t.Fatalf("other error: %v after %d attempts", err, attempts)
+	}
+	attempts = 0
+	manager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := manager.WithinTx(ctx, func(ctx context.Context) error {
+			attempts++
+			return other
+		}); !errors.Is(err, other) {
+			t.Errorf("nested call: %v", err)
+		}
+		return nil
+	})
+	if attempts != 1 {
+		t.Fatalf("nested call ran %d times", attempts)
+	}
+}
@@ -0,0 +1,30 @@
+package database
+
+import "testing"
+
+func TestRebindDollar(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = $1 AND b = $2"},
+		{"SELECT '?' , ?", "SELECT '?' , $1"},
+		{"SELECT 'it''s ?', ?", "SELECT 'it''s ?', $1"},
+		{`SELECT "odd?name" FROM t WHERE id = ?`, `SELECT "odd?name" FROM t WHERE id = $1`},
+		{`SELECT "a""?" , ?`, `SELECT "a""?" , $1`},
+		{"SELECT ? -- why?\nFROM t WHERE id = ?", "SELECT $1 -- why?\nFROM t WHERE id = $2"},
+		{"SELECT ? -- trailing?", "SELECT $1 -- trailing?"},
+		{"SELECT /* a ? b */ ?", "SELECT /* a ? b */ $1"},
+		{"SELECT ? - ?", "SELECT $1 - $2"},
+		{"SELECT ? / ?", "SELECT $1 / $2"},
+		{"SELECT 'цена ?' || ?", "SELECT 'цена ?' || $1"},
+		// Незакрытые кавычки и комментарии переносятся как есть
+		{"SELECT ?, 'open ?", "SELECT $1, 'open ?"},
+		{"SELECT ? /* open ?", "SELECT $1 /* open ?"},
+	}
+	for _, tt := range tests {
+		if got := rebindDollar(tt.query); got != tt.want {
+			t.Errorf("rebindDollar(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -1,51 +1,541 @@
 package database
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Миграции ведутся отдельно для каждого диалекта с общей нумерацией версий:
+// migrations/<dialect>/NNNN_name.{up,down}.sql
+//
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+var (
+	ErrMigrationChecksumMismatch = errors.New("migration was modified after being applied")
+	ErrMigrationMissing          = errors.New("applied migration not found in source")
+	ErrNoDownMigration           = errors.New("migration has no down script")
 )
 
-func RunMigrations() error {
-	migrations := []string{
-		createUsersTable,
-		createProductsTable,
-		createIndexes,
+var migrationDialects = []string{"postgres", "sqlite"}
+
+var (
+	migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	migrationNameRe = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Modified  bool
+	Missing   bool
+}
+
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// MigrationHook - шаг миграции на Go. Выполняется в транзакции миграции
+// сразу после up-скрипта, например чтобы заполнить данные значениями из
+// конфигурации; при откате не выполняется.
+type MigrationHook func(ctx context.Context, tx *sql.Tx, dialect Dialect) error
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	hooks      map[int64]MigrationHook
+}
+
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, path.Join("migrations", dialect.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+		hooks:      make(map[int64]MigrationHook),
+	}, nil
+}
+
+// AfterUp регистрирует шаг на Go для миграции version.
+func (m *Migrator) AfterUp(version int64, hook MigrationHook) {
+	m.hooks[version] = hook
+}
+
+// RunMigrations применяет все непримененные миграции при старте сервиса;
+// hooks - шаги на Go по версиям миграций.
+func RunMigrations(hooks map[int64]MigrationHook) error {
+	migrator, err := NewMigrator(DB, CurrentDialect)
+	if err != nil {
+		return err
 	}
+	for version, hook := range hooks {
+		migrator.AfterUp(version, hook)
+	}
+
+	applied, err := migrator.Up(context.Background(), 0)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range applied {
+		logrus.Infof("Applied migration %04d_%s", m.Version, m.Name)
+	}
+
+	return nil
+}
+
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up применяет до n ожидающих миграций (все, если n <= 0).
+func (m *Migrator) Up(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if n > 0 && len(done) >= n {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down откатывает до n последних примененных миграций (все, если n <= 0).
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
 
-	for i, migration := range migrations {
-		if _, err := DB.Exec(migration); err != nil {
-			return fmt.Errorf("migration %d failed: %w", i+1, err)
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.loadApplied(ctx, conn)
+		if err != nil {
+			return err
 		}
+
+		for _, version := range appliedVersionsDesc(applied) {
+			if n > 0 && len(done) >= n {
+				break
+			}
+
+			migration, ok := m.find(version)
+			if !ok {
+				return fmt.Errorf("%w: version %d", ErrMigrationMissing, version)
+			}
+
+			if err := m.revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Goto приводит схему к указанной версии: применяет недостающие миграции
+// до target включительно и откатывает все примененные после нее.
+// Версия 0 означает откат всех миграций.
+func (m *Migrator) Goto(ctx context.Context, target int64) (applied, reverted []Migration, err error) {
+	if _, ok := m.find(target); !ok && target != 0 {
+		return nil, nil, fmt.Errorf("unknown migration version %d", target)
 	}
 
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err := m.verify(current); err != nil {
+			return err
+		}
+
+		for _, version := range appliedVersionsDesc(current) {
+			if version <= target {
+				break
+			}
+
+			migration, _ := m.find(version)
+			if err := m.revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > target {
+				break
+			}
+			if _, ok := current[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, reverted, err
+}
+
+// Redo откатывает и заново применяет последнюю примененную миграцию.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := appliedVersionsDesc(applied)
+		if len(versions) == 0 {
+			return errors.New("no applied migrations to redo")
+		}
+
+		migration, ok := m.find(versions[0])
+		if !ok {
+			return fmt.Errorf("%w: version %d", ErrMigrationMissing, versions[0])
+		}
+
+		if err := m.revert(ctx, conn, migration); err != nil {
+			return err
+		}
+		if err := m.apply(ctx, conn, migration); err != nil {
+			return err
+		}
+
+		redone = &migration
+		return nil
+	})
+
+	return redone, err
+}
+
+// Status возвращает состояние всех известных миграций,
+// включая примененные, но отсутствующие в исходниках.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	applied, err := m.loadApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if a, ok := applied[migration.Version]; ok {
+			appliedAt := a.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Modified = a.Checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, a := range applied {
+		if _, ok := m.find(version); ok {
+			continue
+		}
+		appliedAt := a.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   version,
+			Name:      a.Name,
+			Applied:   true,
+			AppliedAt: &appliedAt,
+			Missing:   true,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	// Блокировка (advisory lock в PostgreSQL) привязана к сессии,
+	// поэтому все миграции выполняются на одном выделенном соединении.
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if err := m.dialect.LockMigrations(ctx, conn); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer m.dialect.UnlockMigrations(context.Background(), conn)
+
+	return fn(conn)
+}
+
+func (m *Migrator) loadApplied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	if _, err := conn.ExecContext(ctx, createSchemaMigrationsTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[a.Version] = a
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) verify(applied map[int64]appliedMigration) error {
+	for version, a := range applied {
+		migration, ok := m.find(version)
+		if !ok {
+			return fmt.Errorf("%w: version %d (%s)", ErrMigrationMissing, version, a.Name)
+		}
+		if migration.Checksum != a.Checksum {
+			return fmt.Errorf("%w: version %d (%s)", ErrMigrationChecksumMismatch, version, migration.Name)
+		}
+	}
 	return nil
 }
 
-const createUsersTable = `
-CREATE TABLE IF NOT EXISTS users (
-    id SERIAL PRIMARY KEY,
-    username VARCHAR(255) NOT NULL UNIQUE,
-    password_hash TEXT NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-`
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-const createProductsTable = `
-CREATE TABLE IF NOT EXISTS products (
-    id SERIAL PRIMARY KEY,
-    name VARCHAR(255) NOT NULL,
-    description TEXT,
-    price DECIMAL(10,2) NOT NULL,
-    stock INTEGER NOT NULL DEFAULT 0,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-`
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+	}
+	if hook, ok := m.hooks[migration.Version]; ok {
+		if err := hook(ctx, tx, m.dialect); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+	}
 
-const createIndexes = `
-CREATE INDEX IF NOT EXISTS idx_products_name ON products(name);
-CREATE INDEX IF NOT EXISTS idx_products_created_at ON products(created_at);
-`
+	query := m.dialect.Rebind(`INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)`)
+	if _, err := tx.ExecContext(ctx, query, migration.Version, migration.Name, migration.Checksum); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("%w: version %d (%s)", ErrNoDownMigration, migration.Version, migration.Name)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+		return fmt.Errorf("rollback of migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+	}
+
+	query := m.dialect.Rebind(`DELETE FROM schema_migrations WHERE version = ?`)
+	if _, err := tx.ExecContext(ctx, query, migration.Version); err != nil {
+		return fmt.Errorf("failed to unrecord migration %d: %w", migration.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+func appliedVersionsDesc(applied map[int64]appliedMigration) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	return versions
+}
 
+// CreateMigration создает пустые пары up/down файлов со следующим по порядку
+// номером версии в подкаталоге каждого диалекта внутри dir.
+func CreateMigration(dir, name string) ([]string, error) {
+	name = strings.Trim(migrationNameRe.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("migration name must contain letters or digits")
+	}
 
+	var version int64 = 1
+	for _, dialect := range migrationDialects {
+		existing, err := loadMigrations(os.DirFS(dir), dialect)
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 && existing[len(existing)-1].Version >= version {
+			version = existing[len(existing)-1].Version + 1
+		}
+	}
 
+	base := fmt.Sprintf("%04d_%s", version, name)
 
+	var created []string
+	for _, dialect := range migrationDialects {
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(dir, dialect, base+"."+direction+".sql")
+			content := fmt.Sprintf("-- %s (%s, %s)\n", base, dialect, direction)
+			if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+				return created, fmt.Errorf("failed to write %s: %w", file, err)
+			}
+			created = append(created, file)
+		}
+	}
+
+	return created, nil
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up script", migration.Version, migration.Name)
+		}
+		sum := sha256.Sum256([]byte(migration.Up))
+		migration.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+const createSchemaMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_products_name ON products(name);
+CREATE INDEX IF NOT EXISTS idx_products_created_at ON products(created_at);
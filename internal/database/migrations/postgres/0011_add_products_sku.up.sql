@@ -0,0 +1,4 @@
+-- SKU хранится в верхнем регистре (нормализует сервис). Уникальность только
+-- среди неудаленных продуктов: SKU удаленного можно выдать новому продукту.
+ALTER TABLE products ADD COLUMN sku VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products(sku) WHERE deleted_at IS NULL;
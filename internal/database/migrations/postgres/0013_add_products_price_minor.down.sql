@@ -0,0 +1,6 @@
+-- Валюта теряется: цены снова считаются суммами с двумя знаками после запятой
+ALTER TABLE products ADD COLUMN price DECIMAL(10,2) NOT NULL DEFAULT 0;
+UPDATE products SET price = price_minor / 100.0;
+ALTER TABLE products ALTER COLUMN price DROP DEFAULT;
+ALTER TABLE products DROP COLUMN currency;
+ALTER TABLE products DROP COLUMN price_minor;
@@ -0,0 +1,11 @@
+-- Цена хранится целым числом минимальных единиц валюты вместо DECIMAL.
+-- Валюта старых цен известна только конфигурации: миграция переводит их
+-- в сотые доли без потерь и оставляет currency пустой, а после миграций
+-- мигратор назначает PRODUCT_DEFAULT_CURRENCY и пересчитывает цены под
+-- число знаков этой валюты (см. database.CurrencyBackfill).
+ALTER TABLE products ADD COLUMN price_minor BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT '';
+UPDATE products SET price_minor = ROUND(price * 100);
+ALTER TABLE products ALTER COLUMN price_minor DROP DEFAULT;
+ALTER TABLE products ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE products DROP COLUMN price;
@@ -0,0 +1,21 @@
+-- Курс хранится десятичной строкой, чтобы пересчет цен был точным.
+-- История курсов сохраняется: действует последний курс с rate_at <= момента пересчета
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id BIGSERIAL PRIMARY KEY,
+    base_currency VARCHAR(3) NOT NULL,
+    quote_currency VARCHAR(3) NOT NULL,
+    rate VARCHAR(64) NOT NULL,
+    rate_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_pair ON exchange_rates(base_currency, quote_currency, rate_at);
+
+-- Явные цены продукта в других валютах, имеют приоритет над пересчетом по курсу
+CREATE TABLE IF NOT EXISTS product_prices (
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    price_minor BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, currency)
+);
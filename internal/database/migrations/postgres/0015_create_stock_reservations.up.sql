@@ -0,0 +1,19 @@
+-- reserved - сумма активных резервов продукта. Доступный остаток
+-- (stock - reserved) уменьшается условным UPDATE, CHECK не дает
+-- зарезервировать больше остатка или опустить остаток ниже резерва.
+ALTER TABLE products ADD COLUMN reserved INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD CONSTRAINT products_reserved_check CHECK (reserved >= 0 AND reserved <= stock);
+
+-- Резерв активен до expires_at, затем его снимает фоновая очистка
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    status VARCHAR(16) NOT NULL,
+    actor_id BIGINT,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expiry ON stock_reservations(status, expires_at);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product ON stock_reservations(product_id);
@@ -0,0 +1,27 @@
+-- Журнал движений остатка: сумма delta продукта равна products.stock
+-- (проверяет cmd/reconcile-stock). Записи только добавляются и, как
+-- product_history, остаются после окончательного удаления продукта.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL,
+    delta INTEGER NOT NULL,
+    stock_after INTEGER NOT NULL,
+    reason VARCHAR(16) NOT NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    actor_id BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements(product_id, id);
+
+CREATE OR REPLACE FUNCTION stock_movements_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock movements are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_immutable BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE PROCEDURE stock_movements_immutable();
+
+-- Остаток существующих продуктов - начальное движение
+INSERT INTO stock_movements (product_id, delta, stock_after, reason)
+SELECT id, stock, stock, 'initial' FROM products WHERE stock <> 0;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS product_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    actor_id INTEGER,
+    operation VARCHAR(16) NOT NULL,
+    changes TEXT NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_product_history_product ON product_history(product_id, id);
+
+-- Текущее состояние уже существующих продуктов - отправная точка для as_of
+INSERT INTO product_history (product_id, version, operation, changes, created_at)
+SELECT id, version, 'snapshot',
+       json_object(
+           'name', json_object('before', NULL, 'after', name),
+           'description', json_object('before', NULL, 'after', description),
+           'price', json_object('before', NULL, 'after', price),
+           'stock', json_object('before', NULL, 'after', stock)
+       ),
+       updated_at
+FROM products
+WHERE deleted_at IS NULL;
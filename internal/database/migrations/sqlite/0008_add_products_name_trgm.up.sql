@@ -0,0 +1 @@
+-- pg_trgm есть только в Postgres, для SQLite подсказки ранжируются в памяти
@@ -0,0 +1,9 @@
+-- Цена хранится целым числом минимальных единиц валюты вместо REAL.
+-- Валюта старых цен известна только конфигурации: миграция переводит их
+-- в сотые доли с округлением и оставляет currency пустой, а после миграций
+-- мигратор назначает PRODUCT_DEFAULT_CURRENCY и пересчитывает цены под
+-- число знаков этой валюты (см. database.CurrencyBackfill).
+ALTER TABLE products ADD COLUMN price_minor INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT '';
+UPDATE products SET price_minor = CAST(ROUND(price * 100) AS INTEGER);
+ALTER TABLE products DROP COLUMN price;
@@ -0,0 +1,26 @@
+-- Журнал движений остатка: сумма delta продукта равна products.stock
+-- (проверяет cmd/reconcile-stock). Записи только добавляются и, как
+-- product_history, остаются после окончательного удаления продукта.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL,
+    delta INTEGER NOT NULL,
+    stock_after INTEGER NOT NULL,
+    reason VARCHAR(16) NOT NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    actor_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements(product_id, id);
+
+CREATE TRIGGER IF NOT EXISTS stock_movements_no_update BEFORE UPDATE ON stock_movements BEGIN
+    SELECT RAISE(ABORT, 'stock movements are immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS stock_movements_no_delete BEFORE DELETE ON stock_movements BEGIN
+    SELECT RAISE(ABORT, 'stock movements are immutable');
+END;
+
+-- Остаток существующих продуктов - начальное движение
+INSERT INTO stock_movements (product_id, delta, stock_after, reason)
+SELECT id, stock, stock, 'initial' FROM products WHERE stock <> 0;
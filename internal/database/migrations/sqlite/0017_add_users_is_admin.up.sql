@@ -0,0 +1,3 @@
+-- Права администратора хранятся в строке пользователя и выдаются вне API
+-- (cmd/admin), а не по имени: имя может занять любой, кто зарегистрируется.
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
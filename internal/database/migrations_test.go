@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// newTestMigrator открывает пустую базу SQLite во временном каталоге.
+func newTestMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	if err := Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1}); err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+
+	migrator, err := NewMigrator(DB, CurrentDialect)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	return migrator
+}
+
+func TestMigrationsUpDown(t *testing.T) {
+	ctx := context.Background()
+	migrator := newTestMigrator(t)
+	all := len(migrator.Migrations())
+
+	applied, err := migrator.Up(ctx, 0)
+	if err != nil || len(applied) != all {
+		t.Fatalf("Up: applied %d of %d, err %v", len(applied), all, err)
+	}
+	if applied, err := migrator.Up(ctx, 0); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up: applied %d, err %v", len(applied), err)
+	}
+
+	// Каждый down-скрипт откатывает свой up: после полного отката
+	// миграции применяются заново
+	if _, reverted, err := migrator.Goto(ctx, 0); err != nil || len(reverted) != all {
+		t.Fatalf("Goto 0: reverted %d of %d, err %v", len(reverted), all, err)
+	}
+	if applied, err := migrator.Up(ctx, 0); err != nil || len(applied) != all {
+		t.Fatalf("Up after rollback: applied %d of %d, err %v", len(applied), all, err)
+	}
+}
+
+func TestMigrationsChecksum(t *testing.T) {
+	ctx := context.Background()
+	migrator := newTestMigrator(t)
+	if _, err := migrator.Up(ctx, 3); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	if _, err := DB.Exec(`UPDATE schema_migrations SET checksum = 'edited' WHERE version = 2`); err != nil {
+		t.Fatalf("edit checksum: %v", err)
+	}
+	if _, err := migrator.Up(ctx, 0); !errors.Is(err, ErrMigrationChecksumMismatch) {
+		t.Fatalf("Up with edited migration: err %v, want %v", err, ErrMigrationChecksumMismatch)
+	}
+	if _, _, err := migrator.Goto(ctx, 1); !errors.Is(err, ErrMigrationChecksumMismatch) {
+		t.Fatalf("Goto with edited migration: err %v, want %v", err, ErrMigrationChecksumMismatch)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Modified != (status.Version == 2) {
+			t.Fatalf("migration %d: modified = %v", status.Version, status.Modified)
+		}
+	}
+
+	// Пропавшая из исходников миграция тоже останавливает Up
+	if _, err := DB.Exec(`UPDATE schema_migrations SET checksum = ? WHERE version = 2`, migrator.Migrations()[1].Checksum); err != nil {
+		t.Fatalf("restore checksum: %v", err)
+	}
+	if _, err := DB.Exec(`INSERT INTO schema_migrations (version, name, checksum) VALUES (9999, 'removed', 'x')`); err != nil {
+		t.Fatalf("record missing migration: %v", err)
+	}
+	if _, err := migrator.Up(ctx, 0); !errors.Is(err, ErrMigrationMissing) {
+		t.Fatalf("Up with missing migration: err %v, want %v", err, ErrMigrationMissing)
+	}
+}
+
+func TestMigrationHooks(t *testing.T) {
+	ctx := context.Background()
+	migrator := newTestMigrator(t)
+
+	// Ошибка шага откатывает его миграцию
+	calls := 0
+	failed := errors.New("hook failed")
+	migrator.AfterUp(2, func(ctx context.Context, tx *sql.Tx, dialect Dialect) error {
+		calls++
+		if calls == 1 {
+			return failed
+		}
+		return nil
+	})
+	if applied, err := migrator.Up(ctx, 0); !errors.Is(err, failed) || len(applied) != 1 {
+		t.Fatalf("Up with failing hook: applied %d, err %v", len(applied), err)
+	}
+
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	// Шаг выполняется только при применении своей миграции
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("second Up: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("hook called %d times, want 2", calls)
+	}
+}
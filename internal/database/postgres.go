@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Ключ advisory-блокировки, под которой выполняются миграции.
+// Позволяет нескольким репликам стартовать одновременно.
+const migrationLockKey int64 = 72_364_011_180
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) DriverName() string {
+	return "postgres"
+}
+
+func (postgresDialect) Rebind(query string) string {
+	return rebindDollar(query)
+}
+
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// IsCheckViolation: 23514 check_violation.
+func (postgresDialect) IsCheckViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23514"
+}
+
+// IsSerializationFailure: 40001 serialization_failure, 40P01 deadlock_detected.
+func (postgresDialect) IsSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == "40001" || pqErr.Code == "40P01")
+}
+
+// IsConnectionError: класс 08 (connection exception), остановка или старт
+// сервера (57P01-57P03) и сетевые ошибки.
+func (postgresDialect) IsConnectionError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Class() == "08" ||
+			pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"
+	}
+	return isNetworkError(err)
+}
+
+func (postgresDialect) TimeArg(t time.Time) interface{} {
+	return t.UTC()
+}
+
+func (postgresDialect) LockMigrations(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey)
+	return err
+}
+
+func (postgresDialect) UnlockMigrations(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+	return err
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type primaryKey struct{}
+
+// ReplicaSet распределяет читающие запросы по репликам по кругу.
+// Недоступные реплики исключаются фоновой проверкой здоровья,
+// а при отсутствии здоровых реплик чтение идет в primary.
+type ReplicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+type replica struct {
+	db      *sql.DB
+	name    string
+	healthy atomic.Bool
+}
+
+// Replicas - пул реплик из DATABASE_READ_URLS, nil если реплики не заданы.
+var Replicas *ReplicaSet
+
+// InitReplicas подключает реплики чтения. Диалект реплик должен совпадать с primary.
+func InitReplicas(urls []string, pool PoolConfig, healthInterval time.Duration) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	rs := &ReplicaSet{stop: make(chan struct{})}
+	for i, url := range urls {
+		dialect, dsn, err := ParseDatabaseURL(url)
+		if err != nil {
+			rs.Close()
+			return fmt.Errorf("invalid read replica URL #%d: %w", i+1, err)
+		}
+		if dialect.Name() != CurrentDialect.Name() {
+			rs.Close()
+			return fmt.Errorf("read replica #%d uses %s, primary uses %s", i+1, dialect.Name(), CurrentDialect.Name())
+		}
+
+		if dialect.Name() == "sqlite" {
+			if dsn, err = prepareSQLite(dsn); err != nil {
+				rs.Close()
+				return err
+			}
+		}
+
+		db, err := sql.Open(dialect.DriverName(), dsn)
+		if err != nil {
+			rs.Close()
+			return fmt.Errorf("failed to open read replica #%d: %w", i+1, err)
+		}
+		configurePool(db, dialect, pool)
+
+		r := &replica{db: db, name: fmt.Sprintf("replica-%d", i+1)}
+		// Недоступная при старте реплика не мешает запуску: чтение уйдет в primary
+		r.healthy.Store(db.Ping() == nil)
+		rs.replicas = append(rs.replicas, r)
+	}
+
+	rs.wg.Add(1)
+	go rs.healthLoop(healthInterval)
+
+	Replicas = rs
+	return nil
+}
+
+// Reader возвращает пул для чтения: реплику по кругу или primary,
+// если здоровых реплик нет. Безопасен для nil.
+func (rs *ReplicaSet) Reader(primary *sql.DB) *sql.DB {
+	if rs == nil || len(rs.replicas) == 0 {
+		return primary
+	}
+
+	start := rs.next.Add(1)
+	for i := 0; i < len(rs.replicas); i++ {
+		r := rs.replicas[(start+uint64(i))%uint64(len(rs.replicas))]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+
+	return primary
+}
+
+// MarkFailed исключает реплику до следующей успешной проверки здоровья.
+func (rs *ReplicaSet) MarkFailed(db *sql.DB) {
+	if rs == nil {
+		return
+	}
+	for _, r := range rs.replicas {
+		if r.db == db && r.healthy.Swap(false) {
+			logrus.Warnf("Read %s marked unhealthy", r.name)
+		}
+	}
+}
+
+// DBs возвращает пулы реплик по именам (replica-1, replica-2, ...).
+func (rs *ReplicaSet) DBs() map[string]*sql.DB {
+	dbs := make(map[string]*sql.DB)
+	if rs == nil {
+		return dbs
+	}
+	for _, r := range rs.replicas {
+		dbs[r.name] = r.db
+	}
+	return dbs
+}
+
+func (rs *ReplicaSet) Close() {
+	if rs == nil {
+		return
+	}
+	if rs.stop != nil {
+		close(rs.stop)
+		rs.wg.Wait()
+	}
+	for _, r := range rs.replicas {
+		r.db.Close()
+	}
+}
+
+func (rs *ReplicaSet) healthLoop(interval time.Duration) {
+	defer rs.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+			for _, r := range rs.replicas {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				healthy := r.db.PingContext(ctx) == nil
+				cancel()
+
+				if r.healthy.Swap(healthy) != healthy {
+					if healthy {
+						logrus.Infof("Read %s is healthy again", r.name)
+					} else {
+						logrus.Warnf("Read %s failed health check", r.name)
+					}
+				}
+			}
+		}
+	}
+}
+
+// WithPrimary помечает контекст так, что чтения идут только в primary
+// (read-your-writes сразу после записи).
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// ReadQuerierFrom выбирает исполнителя для чтения: транзакцию из контекста,
+// primary для закрепленных запросов или реплику.
+func ReadQuerierFrom(ctx context.Context, primary *sql.DB, replicas *ReplicaSet) (Querier, *sql.DB) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx, primary
+	}
+	if pinned, _ := ctx.Value(primaryKey{}).(bool); pinned {
+		return primary, primary
+	}
+
+	db := replicas.Reader(primary)
+	return db, db
+}
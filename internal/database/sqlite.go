@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Параметры соединения, добавляемые к пути файла БД.
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) DriverName() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+func (sqliteDialect) IsCheckViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_CHECK
+}
+
+// IsSerializationFailure: SQLITE_BUSY и SQLITE_LOCKED - БД занята другой транзакцией.
+func (sqliteDialect) IsSerializationFailure(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
+
+// TimeArg форматирует время так же, как CURRENT_TIMESTAMP ("2006-01-02 15:04:05"):
+// SQLite сравнивает даты как строки.
+func (sqliteDialect) TimeArg(t time.Time) interface{} {
+	return t.UTC().Format("2006-01-02 15:04:05.999999999")
+}
+
+// SQLite - встраиваемая БД одного процесса: миграции и так выполняются
+// в транзакциях на единственном соединении, отдельная блокировка не нужна.
+func (sqliteDialect) LockMigrations(ctx context.Context, conn *sql.Conn) error {
+	return nil
+}
+
+func (sqliteDialect) UnlockMigrations(ctx context.Context, conn *sql.Conn) error {
+	return nil
+}
+
+// IsConnectionError: у встроенной БД сети нет, остаются только сбросы
+// соединения в database/sql.
+func (sqliteDialect) IsConnectionError(err error) bool {
+	return isNetworkError(err)
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+type txKey struct{}
+
+// TxManager выполняет функции в транзакции. Транзакция передается через
+// контекст, и репозитории подхватывают ее автоматически (см. QuerierFrom).
+type TxManager struct {
+	db         *sql.DB
+	dialect    Dialect
+	isolation  sql.IsolationLevel
+	maxRetries int
+}
+
+func NewTxManager(isolation sql.IsolationLevel, maxRetries int) *TxManager {
+	return &TxManager{
+		db:         DB,
+		dialect:    CurrentDialect,
+		isolation:  isolation,
+		maxRetries: maxRetries,
+	}
+}
+
+// WithinTx выполняет fn в транзакции: коммитит при успехе и откатывает при ошибке.
+// Вложенные вызовы присоединяются к внешней транзакции. При конфликте
+// сериализации или дедлоке вся функция повторяется до maxRetries раз,
+// поэтому fn не должна иметь побочных эффектов вне БД.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = m.runTx(ctx, fn)
+		if err == nil || attempt >= m.maxRetries || !m.dialect.IsSerializationFailure(err) {
+			return err
+		}
+
+		if waitErr := sleepContext(ctx, retryBackoff(attempt)); waitErr != nil {
+			return err
+		}
+	}
+}
+
+func (m *TxManager) runTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: m.isolation})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// QuerierFrom возвращает транзакцию из контекста, если она есть, иначе db.
+func QuerierFrom(ctx context.Context, db *sql.DB) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// InTx сообщает, выполняется ли код внутри транзакции WithinTx.
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return ok
+}
+
+// ParseIsolationLevel разбирает значение вида "read_committed" или "serializable".
+func ParseIsolationLevel(s string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), " ", "_")) {
+	case "", "default":
+		return sql.LevelDefault, nil
+	case "read_uncommitted":
+		return sql.LevelReadUncommitted, nil
+	case "read_committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable_read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("unknown isolation level %q", s)
+	}
+}
+
+func retryBackoff(attempt int) time.Duration {
+	base := 10 * time.Millisecond << attempt
+	return base/2 + time.Duration(rand.Int63n(int64(base)))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"demo-service/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryHandler struct {
+	categoryService *service.CategoryService
+}
+
+func NewCategoryHandler(categoryService *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: categoryService,
+	}
+}
+
+// CreateCategory godoc
+// @Summary Create a category
+// @Description Create a root category or, with parent_id, a subcategory. Names are unique among siblings.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.CreateCategoryRequest true "Category request"
+// @Success 201 {object} model.Category
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/categories [post]
+func (h *CategoryHandler) Create(c *gin.Context) {
+	var req model.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.categoryService.Create(c.Request.Context(), &req)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to create category")
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// ListCategories godoc
+// @Summary Get the category tree
+// @Description All categories as a tree: root categories with nested children, siblings sorted by name
+// @Tags categories
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.CategoryTreeResponse
+// @Router /api/v1/categories [get]
+func (h *CategoryHandler) List(c *gin.Context) {
+	response, err := h.categoryService.Tree(c.Request.Context())
+	if err != nil {
+		respondCategoryError(c, err, "Failed to list categories")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCategory godoc
+// @Summary Get category by ID
+// @Description Get a category with its path from the root of the tree
+// @Tags categories
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 200 {object} model.Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/categories/{id} [get]
+func (h *CategoryHandler) GetByID(c *gin.Context) {
+	id, ok := categoryID(c)
+	if !ok {
+		return
+	}
+
+	category, err := h.categoryService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to get category")
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// UpdateCategory godoc
+// @Summary Rename a category
+// @Description Rename a category; use the move endpoint to change its parent
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Param request body model.UpdateCategoryRequest true "Category request"
+// @Success 200 {object} model.Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/categories/{id} [put]
+func (h *CategoryHandler) Update(c *gin.Context) {
+	id, ok := categoryID(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.categoryService.Rename(c.Request.Context(), id, &req)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to update category")
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// MoveCategory godoc
+// @Summary Move a category subtree
+// @Description Move a category with all its subcategories under another parent, or to the root with parent_id null. A category cannot be moved into its own subtree.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Param request body model.MoveCategoryRequest true "New parent"
+// @Success 200 {object} model.Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/categories/{id}/move [post]
+func (h *CategoryHandler) Move(c *gin.Context) {
+	id, ok := categoryID(c)
+	if !ok {
+		return
+	}
+
+	var req model.MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.categoryService.Move(c.Request.Context(), id, &req)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to move category")
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory godoc
+// @Summary Delete a category
+// @Description Delete a category without subcategories; its products stay, only unlinked from it
+// @Tags categories
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/categories/{id} [delete]
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	id, ok := categoryID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.categoryService.Delete(c.Request.Context(), id); err != nil {
+		respondCategoryError(c, err, "Failed to delete category")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func categoryID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondCategoryError отвечает на ошибку операции с категориями;
+// fallback - сообщение для непредвиденных ошибок (500).
+func respondCategoryError(c *gin.Context, err error, fallback string) {
+	if respondContextError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrUnknownCategory):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+	case errors.Is(err, repository.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+	case errors.Is(err, repository.ErrCategoryExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Category with this name already exists in the parent"})
+	case errors.Is(err, repository.ErrCategoryHasChildren):
+		c.JSON(http.StatusConflict, gin.H{"error": "Category has subcategories, move or delete them first"})
+	case errors.Is(err, repository.ErrCategoryCycle):
+		c.JSON(http.StatusConflict, gin.H{"error": "Category cannot be moved into its own subtree"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"demo-service/internal/metrics"
+	"demo-service/internal/middleware"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest - нестандартный код (nginx) для запросов,
+// которые клиент прервал до получения ответа.
+const StatusClientClosedRequest = 499
+
+// respondContextError отвечает 499/503, если операция прервана отменой
+// запроса или дедлайном, и возвращает false для остальных ошибок.
+func respondContextError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled):
+		metrics.HTTPRequestsCanceled.WithLabelValues(c.Request.Method, c.FullPath(), "canceled").Inc()
+		c.JSON(StatusClientClosedRequest, gin.H{"error": "Request canceled"})
+	case errors.Is(err, context.DeadlineExceeded):
+		metrics.HTTPRequestsCanceled.WithLabelValues(c.Request.Method, c.FullPath(), "deadline_exceeded").Inc()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Request timed out"})
+	default:
+		return false
+	}
+	return true
+}
+
+// isAdmin проверяет по хранилищу, что пользователь - администратор
+// (middleware.IsAdmin). Если проверить не удалось, отвечает ошибкой и
+// возвращает ok = false.
+func isAdmin(c *gin.Context) (admin, ok bool) {
+	admin, err := middleware.IsAdmin(c)
+	if err != nil {
+		if !respondContextError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check administrator rights"})
+		}
+		return false, false
+	}
+	return admin, true
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidIfMatch = errors.New("invalid If-Match header")
+
+// productETag - сильный ETag продукта на основе его версии.
+func productETag(product *model.Product) string {
+	return `"` + strconv.FormatInt(product.Version, 10) + `"`
+}
+
+func setProductETag(c *gin.Context, product *model.Product) {
+	c.Header("ETag", productETag(product))
+}
+
+// parseIfMatch возвращает версию из If-Match. Ноль означает, что проверять
+// версию не нужно: заголовка нет или передан "*".
+func parseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	// If-Match использует только сильное сравнение, слабые ETag не совпадают
+	if len(header) < 3 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, errInvalidIfMatch
+	}
+
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// ifMatchVersion разбирает If-Match изменяющего запроса и при ошибке сам
+// отвечает клиенту: 428 без заголовка в строгом режиме (REQUIRE_IF_MATCH)
+// и 412 для ETag, который не может совпасть с версией продукта.
+func ifMatchVersion(c *gin.Context) (int64, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" && config.AppConfig.RequireIfMatch {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return 0, false
+	}
+
+	version, err := parseIfMatch(header)
+	if err != nil {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified, reload it and retry"})
+		return 0, false
+	}
+	return version, true
+}
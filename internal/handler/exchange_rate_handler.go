@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"demo-service/internal/service"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Размер загрузки курсов: тысяча строк CSV занимает десятки килобайт
+const maxExchangeRateUploadBytes = 1 << 20
+
+type ExchangeRateHandler struct {
+	rateService *service.ExchangeRateService
+}
+
+func NewExchangeRateHandler(rateService *service.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		rateService: rateService,
+	}
+}
+
+// ListExchangeRates godoc
+// @Summary List exchange rates
+// @Description Latest rate of every currency pair in effect at the given time (now by default). One unit of base costs rate units of quote.
+// @Tags exchange-rates
+// @Produce json
+// @Security BearerAuth
+// @Param at query string false "Rates in effect at this time (RFC 3339)"
+// @Success 200 {object} model.ExchangeRateListResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/exchange-rates [get]
+func (h *ExchangeRateHandler) List(c *gin.Context) {
+	at := time.Now()
+	if param := c.Query("at"); param != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, param); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at, expected RFC 3339 timestamp"})
+			return
+		}
+	}
+
+	response, err := h.rateService.Current(c.Request.Context(), at)
+	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UploadExchangeRates godoc
+// @Summary Upload exchange rates
+// @Description Add exchange rates (admins only) as JSON or as a CSV file (text/csv body or multipart field file) with the header base,quote,rate and an optional rate_at column. Without rate_at a rate takes effect immediately; a rate of the same pair with the same rate_at is replaced. Earlier rates are kept for as_of queries. The upload is rejected as a whole if any rate is invalid.
+// @Tags exchange-rates
+// @Accept json
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.SetExchangeRatesRequest false "Rates (JSON upload)"
+// @Param file formData file false "CSV file (multipart upload)"
+// @Success 200 {object} model.ExchangeRateListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /api/v1/exchange-rates [post]
+func (h *ExchangeRateHandler) Upload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxExchangeRateUploadBytes)
+
+	var (
+		rates []model.ExchangeRate
+		err   error
+	)
+	switch mediaType, _, _ := mime.ParseMediaType(c.ContentType()); mediaType {
+	case "text/csv":
+		rates, err = h.rateService.ImportCSV(c.Request.Context(), c.Request.Body)
+	case "multipart/form-data":
+		file, _, formErr := c.Request.FormFile("file")
+		if formErr != nil {
+			if respondUploadTooLarge(c, formErr) {
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Multipart form must contain a file field"})
+			return
+		}
+		defer file.Close()
+		rates, err = h.rateService.ImportCSV(c.Request.Context(), file)
+	default:
+		var req model.SetExchangeRatesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			if respondUploadTooLarge(c, err) {
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rates, err = h.rateService.Save(c.Request.Context(), &req)
+	}
+	if err != nil {
+		if respondContextError(c, err) || respondUploadTooLarge(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidExchangeRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ExchangeRateListResponse{Rates: rates})
+}
+
+// DeleteExchangeRate godoc
+// @Summary Delete an exchange rate
+// @Description Delete a rate uploaded by mistake (admins only); the previous rate of the pair takes effect again
+// @Tags exchange-rates
+// @Security BearerAuth
+// @Param id path int true "Exchange rate ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/exchange-rates/{id} [delete]
+func (h *ExchangeRateHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exchange rate ID"})
+		return
+	}
+
+	if err := h.rateService.Delete(c.Request.Context(), id); err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrExchangeRateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exchange rate not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete exchange rate"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -3,16 +3,25 @@ package handler
 import (
 	"demo-service/internal/database"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	ready atomic.Bool
+}
 
 func NewHealthHandler() *HealthHandler {
 	return &HealthHandler{}
 }
 
+// SetReady отмечает, что подключение к хранилищу установлено и миграции
+// применены: с этого момента ReadinessCheck может отвечать "ready".
+func (h *HealthHandler) SetReady() {
+	h.ready.Store(true)
+}
+
 // HealthCheck godoc
 // @Summary Health check
 // @Description Health check endpoint
@@ -21,6 +30,14 @@ func NewHealthHandler() *HealthHandler {
 // @Success 200 {object} map[string]string
 // @Router /health [get]
 func (h *HealthHandler) HealthCheck(c *gin.Context) {
+	// При STORAGE_BACKEND=memory подключения к БД нет
+	if database.DB == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "healthy",
+		})
+		return
+	}
+
 	if err := database.DB.Ping(); err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"status": "unhealthy",
@@ -34,5 +51,34 @@ func (h *HealthHandler) HealthCheck(c *gin.Context) {
 	})
 }
 
+// ReadinessCheck godoc
+// @Summary Readiness check
+// @Description Reports whether the service can serve traffic: storage is connected and migrated
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /ready [get]
+func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
+	if !h.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "not ready",
+			"error":  "database is not initialized yet",
+		})
+		return
+	}
 
+	if database.DB != nil {
+		if err := database.DB.PingContext(c.Request.Context()); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status": "not ready",
+				"error":  "database connection failed",
+			})
+			return
+		}
+	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ready",
+	})
+}
@@ -1,10 +1,17 @@
 package handler
 
 import (
+	"context"
+	"demo-service/internal/config"
 	"demo-service/internal/model"
+	"demo-service/internal/repository"
 	"demo-service/internal/service"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,14 +28,16 @@ func NewProductHandler(productService *service.ProductService) *ProductHandler {
 
 // CreateProduct godoc
 // @Summary Create a new product
-// @Description Create a new product
+// @Description Create a new product. Set the price as price_minor (integer minor units, e.g. cents) or as a decimal string in price; a JSON number in price is still accepted but deprecated. A price with more decimal places than the currency allows is rejected, not rounded. Without currency the price is in PRODUCT_DEFAULT_CURRENCY.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body model.CreateProductRequest true "Product request"
 // @Success 201 {object} model.Product
+// @Header 201 {string} ETag "Product version"
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "SKU is already used by another product"
 // @Router /api/v1/products [post]
 func (h *ProductHandler) Create(c *gin.Context) {
 	var req model.CreateProductRequest
@@ -37,12 +46,16 @@ func (h *ProductHandler) Create(c *gin.Context) {
 		return
 	}
 
-	product, err := h.productService.Create(&req)
+	product, err := h.productService.Create(actorContext(c), &req)
 	if err != nil {
+		if respondContextError(c, err) || respondSKUConflict(c, err) || respondInvalidPrice(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		return
 	}
 
+	setProductETag(c, product)
 	c.JSON(http.StatusCreated, product)
 }
 
@@ -54,8 +67,13 @@ func (h *ProductHandler) Create(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Product ID"
+// @Param as_of query string false "Return the product as it was at this time (RFC 3339)"
+// @Param currency query string false "Also return the price in this currency (converted_price): the per-currency override if set, otherwise converted with the exchange rate in effect (at as_of, if given)"
 // @Success 200 {object} model.Product
+// @Header 200 {string} ETag "Product version"
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string "No exchange rate for the requested currency"
 // @Router /api/v1/products/{id} [get]
 func (h *ProductHandler) GetByID(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -63,52 +81,226 @@ func (h *ProductHandler) GetByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
 		return
 	}
+	currency, ok := displayCurrency(c)
+	if !ok {
+		return
+	}
 
-	product, err := h.productService.GetByID(id)
+	if asOfParam := c.Query("as_of"); asOfParam != "" {
+		asOf, err := time.Parse(time.RFC3339, asOfParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as_of, expected RFC 3339 timestamp"})
+			return
+		}
+		h.getAsOf(c, id, asOf, currency)
+		return
+	}
+
+	product, err := h.productService.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		if respondContextError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product"})
+		return
+	}
+	if !h.convertPrices(c, currency, time.Now(), product) {
 		return
 	}
 
+	setProductETag(c, product)
 	c.JSON(http.StatusOK, product)
 }
 
 // ListProducts godoc
 // @Summary List products
-// @Description List products with pagination
+// @Description List products with pagination, filters and sorting. Page through large lists with next_cursor/prev_cursor (also sent in the Link header) instead of page.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "next_cursor or prev_cursor from the previous response; other parameters except limit must stay the same"
+// @Param include_total query bool false "Count matching products (total); disable to speed up large lists" default(true)
+// @Param include_deleted query bool false "Include soft-deleted products (admins only)" default(false)
+// @Param q query string false "Full-text search: words, \"exact phrase\", prefix*; results are ranked and highlighted"
+// @Param price_currency query string false "Only products priced in this currency; required with min_price, max_price and sort by price"
+// @Param min_price query number false "Minimum price, inclusive, in price_currency"
+// @Param max_price query number false "Maximum price, inclusive, in price_currency"
+// @Param min_stock query int false "Minimum stock, inclusive"
+// @Param max_stock query int false "Maximum stock, inclusive"
+// @Param in_stock query bool false "Only products with (true) or without (false) stock"
+// @Param created_after query string false "Created after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Created before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_since query string false "Updated at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param category query int false "Only products in this category"
+// @Param include_subcategories query bool false "With category: also products of all its subcategories" default(false)
+// @Param sort query string false "Comma-separated fields, '-' for descending: id, name, price (requires price_currency), stock, created_at, updated_at" example(price,-created_at)
+// @Param currency query string false "Also return prices in this currency (converted_price): per-currency overrides if set, otherwise converted with the current exchange rates"
 // @Success 200 {object} model.ProductListResponse
+// @Header 200 {string} Link "Links to the next and previous pages (RFC 8288)"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string "No exchange rate for the requested currency"
 // @Router /api/v1/products [get]
 func (h *ProductHandler) List(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	opts, err := parseProductListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if opts.IncludeDeleted {
+		admin, ok := isAdmin(c)
+		if !ok {
+			return
+		}
+		if !admin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can list deleted products"})
+			return
+		}
+	}
+	currency, ok := displayCurrency(c)
+	if !ok {
+		return
+	}
 
-	response, err := h.productService.List(page, limit)
+	response, err := h.productService.List(c.Request.Context(), opts, c.Query("cursor"))
 	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrInvalidSearchQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Search query must contain at least one word"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list products"})
 		return
 	}
+	products := make([]*model.Product, len(response.Products))
+	for i := range response.Products {
+		products[i] = &response.Products[i]
+	}
+	if !h.convertPrices(c, currency, time.Now(), products...) {
+		return
+	}
 
+	setPaginationLinks(c, response)
 	c.JSON(http.StatusOK, response)
 }
 
+// BatchProducts godoc
+// @Summary Create, update and delete products in bulk
+// @Description Apply up to PRODUCT_BATCH_MAX_OPERATIONS operations. In atomic mode (default) either all operations are applied or none, and the status of the first rejected operation is returned. In best_effort mode each operation succeeds or fails on its own (207 if any failed).
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.ProductBatchRequest true "Batch of operations"
+// @Success 200 {object} model.ProductBatchResponse
+// @Success 207 {object} model.ProductBatchResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} model.ProductBatchResponse
+// @Failure 409 {object} model.ProductBatchResponse
+// @Router /api/v1/products/batch [post]
+func (h *ProductHandler) Batch(c *gin.Context) {
+	var req model.ProductBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if limit := config.AppConfig.BatchMaxOperations; len(req.Operations) > limit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Batch must contain at most %d operations", limit)})
+		return
+	}
+
+	response, err := h.productService.Batch(actorContext(c), &req)
+	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply product batch"})
+		return
+	}
+
+	c.JSON(batchStatus(response), response)
+}
+
+// batchStatus - код ответа пакета: при откате атомарного пакета - код
+// отклоненной операции, при частичном успехе best_effort - 207 Multi-Status.
+func batchStatus(response *model.ProductBatchResponse) int {
+	if response.Failed == 0 {
+		return http.StatusOK
+	}
+	if response.Mode == model.BatchModeBestEffort {
+		return http.StatusMultiStatus
+	}
+	for _, result := range response.Results {
+		if result.Status >= http.StatusBadRequest && result.Status != http.StatusFailedDependency {
+			return result.Status
+		}
+	}
+	return http.StatusConflict
+}
+
+// SuggestProducts godoc
+// @Summary Suggest product names
+// @Description Autocomplete product names by the typed prefix, tolerating one or two typos
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param prefix query string true "Beginning of the product name"
+// @Param limit query int false "Maximum number of suggestions (up to 20)" default(10)
+// @Success 200 {object} model.ProductSuggestResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/products/suggest [get]
+func (h *ProductHandler) Suggest(c *gin.Context) {
+	prefix := strings.TrimSpace(c.Query("prefix"))
+	if prefix == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	suggestions, err := h.productService.Suggest(c.Request.Context(), prefix, limit)
+	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suggest products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ProductSuggestResponse{Suggestions: suggestions})
+}
+
 // UpdateProduct godoc
 // @Summary Update product
-// @Description Update product by ID
+// @Description Update product by ID. A price without currency is in the current currency of the product; currency can only be changed together with the price. Stock is not accepted (400): change it with POST /api/v1/products/{id}/stock/adjust, which records the reason.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag of the product version being updated"
 // @Param request body model.UpdateProductRequest true "Product update request"
 // @Success 200 {object} model.Product
+// @Header 200 {string} ETag "New product version"
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "SKU is already used by another product"
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /api/v1/products/{id} [put]
 func (h *ProductHandler) Update(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -123,29 +315,52 @@ func (h *ProductHandler) Update(c *gin.Context) {
 		return
 	}
 
-	product, err := h.productService.Update(id, &req)
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.productService.Update(actorContext(c), id, &req, version)
 	if err != nil {
-		if err.Error() == "product not found" {
+		if respondContextError(c, err) || respondSKUConflict(c, err) || respondInvalidPrice(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrStockOverwrite) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":  "Stock cannot be set by PUT, use POST /api/v1/products/:id/stock/adjust",
+				"fields": gin.H{"stock": "use POST /api/v1/products/:id/stock/adjust"},
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrProductNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 			return
 		}
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified, reload it and retry"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
 		return
 	}
 
+	setProductETag(c, product)
 	c.JSON(http.StatusOK, product)
 }
 
 // DeleteProduct godoc
 // @Summary Delete product
-// @Description Delete product by ID
+// @Description Soft-delete product by ID. It can be restored until purged after the retention period
 // @Tags products
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag of the product version being deleted"
 // @Success 204
 // @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /api/v1/products/{id} [delete]
 func (h *ProductHandler) Delete(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -154,12 +369,24 @@ func (h *ProductHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.productService.Delete(id)
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err = h.productService.Delete(actorContext(c), id, version)
 	if err != nil {
-		if err.Error() == "product not found" {
+		if respondContextError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrProductNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 			return
 		}
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified, reload it and retry"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		return
 	}
@@ -167,6 +394,163 @@ func (h *ProductHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// RestoreProduct godoc
+// @Summary Restore product
+// @Description Restore a soft-deleted product by ID
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.Product
+// @Header 200 {string} ETag "New product version"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "SKU was given to another product while this one was deleted"
+// @Router /api/v1/products/{id}/restore [post]
+func (h *ProductHandler) Restore(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	product, err := h.productService.Restore(actorContext(c), id)
+	if err != nil {
+		if respondContextError(c, err) || respondSKUConflict(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deleted product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore product"})
+		return
+	}
+
+	setProductETag(c, product)
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *ProductHandler) getAsOf(c *gin.Context, id int64, asOf time.Time, currency string) {
+	product, err := h.productService.GetAsOf(c.Request.Context(), id, asOf)
+	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product did not exist at the given time"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product"})
+		return
+	}
+	if !h.convertPrices(c, currency, asOf, product) {
+		return
+	}
 
+	c.JSON(http.StatusOK, product)
+}
 
+// ProductHistory godoc
+// @Summary Product change history
+// @Description List changes of a product, newest first, with the acting user and per-field before/after values
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} model.ProductHistoryResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/products/{id}/history [get]
+func (h *ProductHandler) History(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
 
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	response, err := h.productService.History(c.Request.Context(), id, page, limit)
+	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// actorContext возвращает контекст запроса с пользователем из JWT
+// (user_id, выставленный AuthMiddleware) для записи в историю.
+func actorContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if userID, ok := c.Get("user_id"); ok {
+		if id, ok := userID.(int64); ok {
+			ctx = service.WithActor(ctx, id)
+		}
+	}
+	return ctx
+}
+
+// SetProductCategories godoc
+// @Summary Set product categories
+// @Description Replace the categories of a product; an empty list removes it from all categories
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body model.SetProductCategoriesRequest true "Category IDs"
+// @Success 200 {object} model.Product
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/{id}/categories [put]
+func (h *ProductHandler) SetCategories(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req model.SetProductCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.productService.SetCategories(c.Request.Context(), id, req.CategoryIDs)
+	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if errors.Is(err, service.ErrUnknownCategory) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set product categories"})
+		return
+	}
+
+	setProductETag(c, product)
+	c.JSON(http.StatusOK, product)
+}
+
+// respondInvalidPrice отвечает 400, если цену нельзя точно перевести в
+// минимальные единицы валюты, и возвращает false для остальных ошибок.
+func respondInvalidPrice(c *gin.Context, err error) bool {
+	if !errors.Is(err, service.ErrInvalidPrice) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	return true
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"demo-service/internal/config"
+	"demo-service/internal/repository"
+	"demo-service/internal/service"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Запас на заголовки multipart сверх PRODUCT_IMAGE_MAX_BYTES
+const multipartOverheadBytes = 1 << 20
+
+// UploadProductImage godoc
+// @Summary Upload a product image or document
+// @Description Upload a file as the "file" field of a multipart form. The type is detected from the content: JPEG, PNG, GIF and WebP images and PDF documents are accepted. JPEG, PNG and GIF images also get a thumbnail. The product lists the file in images.
+// @Tags products
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param file formData file true "Image or document"
+// @Success 201 {object} model.ProductImage
+// @Header 201 {string} Location "File URL"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /api/v1/products/{id}/images [post]
+func (h *ProductHandler) UploadImage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if err := http.NewResponseController(c.Writer).SetReadDeadline(time.Time{}); err != nil {
+		logrus.Warnf("Failed to extend upload read deadline: %v", err)
+	}
+	maxBytes := int64(config.AppConfig.ImageMaxBytes)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverheadBytes)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondImageTooLarge(c)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Multipart form must contain a file field"})
+		return
+	}
+	defer file.Close()
+	if header.Size > maxBytes {
+		respondImageTooLarge(c)
+		return
+	}
+
+	image, err := h.productService.AddImage(c.Request.Context(), id, header.Filename, file)
+	if err != nil {
+		respondImageError(c, err, "Failed to upload file")
+		return
+	}
+
+	c.Header("Location", image.URL)
+	c.JSON(http.StatusCreated, image)
+}
+
+// GetProductImage godoc
+// @Summary Download a product image or document
+// @Tags products
+// @Produce image/jpeg
+// @Produce image/png
+// @Produce image/gif
+// @Produce image/webp
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param imageId path int true "File ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/{id}/images/{imageId} [get]
+func (h *ProductHandler) GetImage(c *gin.Context) {
+	h.serveImage(c, false)
+}
+
+// GetProductImageThumbnail godoc
+// @Summary Download a product image thumbnail
+// @Description Thumbnail that fits into PRODUCT_THUMBNAIL_SIZE pixels: JPEG for JPEG images, PNG otherwise. Documents and WebP images have none (404).
+// @Tags products
+// @Produce image/jpeg
+// @Produce image/png
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param imageId path int true "File ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/{id}/images/{imageId}/thumbnail [get]
+func (h *ProductHandler) GetImageThumbnail(c *gin.Context) {
+	h.serveImage(c, true)
+}
+
+func (h *ProductHandler) serveImage(c *gin.Context, thumbnail bool) {
+	productID, imageID, ok := imageParams(c)
+	if !ok {
+		return
+	}
+
+	content, err := h.productService.OpenImage(c.Request.Context(), productID, imageID, thumbnail)
+	if err != nil {
+		respondImageError(c, err, "Failed to get file")
+		return
+	}
+	defer content.Close()
+
+	// Содержимое файла с данным id не меняется
+	c.Header("Cache-Control", "private, max-age=86400, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": content.Filename}))
+	c.Header("Content-Type", content.ContentType)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, content); err != nil {
+		logrus.Warnf("Failed to send product image %d: %v", imageID, err)
+	}
+}
+
+// DeleteProductImage godoc
+// @Summary Delete a product image or document
+// @Tags products
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param imageId path int true "File ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/{id}/images/{imageId} [delete]
+func (h *ProductHandler) DeleteImage(c *gin.Context) {
+	productID, imageID, ok := imageParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.productService.DeleteImage(c.Request.Context(), productID, imageID); err != nil {
+		respondImageError(c, err, "Failed to delete file")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func imageParams(c *gin.Context) (int64, int64, bool) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return 0, 0, false
+	}
+	imageID, err := strconv.ParseInt(c.Param("imageId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return 0, 0, false
+	}
+	return productID, imageID, true
+}
+
+// respondImageError отвечает на ошибку операции с файлами продукта;
+// fallback - сообщение для непредвиденных ошибок (500).
+func respondImageError(c *gin.Context, err error, fallback string) {
+	if respondContextError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errors.Is(err, repository.ErrImageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+	case errors.Is(err, service.ErrNoThumbnail):
+		c.JSON(http.StatusNotFound, gin.H{"error": "File has no thumbnail"})
+	case errors.Is(err, service.ErrFileTooLarge):
+		respondImageTooLarge(c)
+	case errors.Is(err, service.ErrUnsupportedFileType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Only JPEG, PNG, GIF and WebP images and PDF documents are accepted"})
+	case errors.Is(err, service.ErrInvalidImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func respondImageTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File must be at most " + strconv.Itoa(config.AppConfig.ImageMaxBytes) + " bytes"})
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"demo-service/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListProductPrices godoc
+// @Summary List per-currency price overrides
+// @Description Explicit prices of a product in other currencies. With ?currency= an override is shown instead of the price converted with the exchange rate.
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.ProductPriceListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/{id}/prices [get]
+func (h *ProductHandler) ListPrices(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	response, err := h.productService.ListPrices(c.Request.Context(), id)
+	if err != nil {
+		respondProductPriceError(c, err, "Failed to get product prices")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetProductPrice godoc
+// @Summary Set a per-currency price override
+// @Description Set the price of a product in another currency, as price_minor or as a decimal string in price. It wins over conversion with the exchange rate. The price in the product's own currency is changed with PUT /api/v1/products/{id}.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param currency path string true "ISO 4217 currency code"
+// @Param request body model.SetProductPriceRequest true "Price"
+// @Success 200 {object} model.ProductPrice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/{id}/prices/{currency} [put]
+func (h *ProductHandler) SetPrice(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req model.SetProductPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, err := h.productService.SetPrice(c.Request.Context(), id, c.Param("currency"), &req)
+	if err != nil {
+		respondProductPriceError(c, err, "Failed to set product price")
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
+
+// DeleteProductPrice godoc
+// @Summary Delete a per-currency price override
+// @Description Delete the override; the price in this currency is converted with the exchange rate again
+// @Tags products
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param currency path string true "ISO 4217 currency code"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/{id}/prices/{currency} [delete]
+func (h *ProductHandler) DeletePrice(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if err := h.productService.DeletePrice(c.Request.Context(), id, c.Param("currency")); err != nil {
+		respondProductPriceError(c, err, "Failed to delete product price")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondProductPriceError(c *gin.Context, err error, message string) {
+	switch {
+	case respondContextError(c, err) || respondInvalidPrice(c, err):
+	case errors.Is(err, repository.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errors.Is(err, repository.ErrProductPriceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product has no price in this currency"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// displayCurrency читает параметр currency: валюту, в которой показать цены.
+// Пустая строка - параметр не задан; при ошибке отвечает 400.
+func displayCurrency(c *gin.Context) (string, bool) {
+	currency := strings.ToUpper(strings.TrimSpace(c.Query("currency")))
+	if currency != "" && !model.ValidCurrency(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be an ISO 4217 code"})
+		return "", false
+	}
+	return currency, true
+}
+
+// convertPrices заполняет converted_price продуктов, если задан currency.
+// Если цену не в чем пересчитать, отвечает 422.
+func (h *ProductHandler) convertPrices(c *gin.Context, currency string, at time.Time, products ...*model.Product) bool {
+	if currency == "" {
+		return true
+	}
+
+	err := h.productService.ConvertPrices(c.Request.Context(), currency, at, products...)
+	switch {
+	case err == nil:
+		return true
+	case respondContextError(c, err):
+	case errors.Is(err, service.ErrNoExchangeRate):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert prices"})
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Сколько полей можно указать в sort
+const maxSortFields = 4
+
+// parseProductListOptions читает параметры списка продуктов. Ошибка
+// содержит понятное клиенту описание неверного параметра.
+func parseProductListOptions(c *gin.Context) (repository.ProductListOptions, error) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	opts := repository.ProductListOptions{
+		Page:  page,
+		Limit: limit,
+		Query: c.Query("q"),
+	}
+
+	var err error
+	if opts.IncludeDeleted, err = parseBoolParam(c, "include_deleted"); err != nil {
+		return opts, err
+	}
+	if value := c.Query("include_total"); value != "" {
+		includeTotal, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("include_total must be true or false")
+		}
+		opts.SkipTotal = !includeTotal
+	}
+
+	f := &opts.Filter
+	if value := c.Query("price_currency"); value != "" {
+		currency := strings.ToUpper(strings.TrimSpace(value))
+		if !model.ValidCurrency(currency) {
+			return opts, fmt.Errorf("price_currency must be an ISO 4217 code")
+		}
+		f.Currency = &currency
+	}
+	if f.MinPrice, err = parsePriceParam(c, "min_price", f.Currency); err != nil {
+		return opts, err
+	}
+	if f.MaxPrice, err = parsePriceParam(c, "max_price", f.Currency); err != nil {
+		return opts, err
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return opts, fmt.Errorf("min_price must not be greater than max_price")
+	}
+
+	if f.MinStock, err = parseStockParam(c, "min_stock"); err != nil {
+		return opts, err
+	}
+	if f.MaxStock, err = parseStockParam(c, "max_stock"); err != nil {
+		return opts, err
+	}
+	if f.MinStock != nil && f.MaxStock != nil && *f.MinStock > *f.MaxStock {
+		return opts, fmt.Errorf("min_stock must not be greater than max_stock")
+	}
+
+	if value := c.Query("in_stock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("in_stock must be true or false")
+		}
+		f.InStock = &inStock
+	}
+
+	if f.CreatedAfter, err = parseTimeParam(c, "created_after"); err != nil {
+		return opts, err
+	}
+	if f.CreatedBefore, err = parseTimeParam(c, "created_before"); err != nil {
+		return opts, err
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return opts, fmt.Errorf("created_after must be earlier than created_before")
+	}
+	if f.UpdatedSince, err = parseTimeParam(c, "updated_since"); err != nil {
+		return opts, err
+	}
+
+	if value := c.Query("category"); value != "" {
+		categoryID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || categoryID < 1 {
+			return opts, fmt.Errorf("category must be a positive integer")
+		}
+		f.CategoryID = &categoryID
+	}
+	if f.IncludeSubcategories, err = parseBoolParam(c, "include_subcategories"); err != nil {
+		return opts, err
+	}
+	if f.IncludeSubcategories && f.CategoryID == nil {
+		return opts, fmt.Errorf("include_subcategories requires category")
+	}
+
+	if opts.Sort, err = parseSortParam(c.Query("sort")); err != nil {
+		return opts, err
+	}
+	// Цены в разных валютах несравнимы: сортировка по price_minor смешала бы
+	// центы и иены
+	if f.Currency == nil && slices.ContainsFunc(opts.Sort, func(s repository.SortField) bool { return s.Field == "price" }) {
+		return opts, fmt.Errorf("sort by price requires price_currency")
+	}
+
+	return opts, nil
+}
+
+// setPaginationLinks выставляет заголовок Link (RFC 8288) со ссылками на
+// соседние страницы: текущий URL с подставленным cursor и без page.
+func setPaginationLinks(c *gin.Context, response *model.ProductListResponse) {
+	var links []string
+	for _, link := range []struct{ rel, cursor string }{
+		{"next", response.NextCursor},
+		{"prev", response.PrevCursor},
+	} {
+		if link.cursor == "" {
+			continue
+		}
+		u := *c.Request.URL
+		query := u.Query()
+		query.Del("page")
+		query.Set("cursor", link.cursor)
+		u.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), link.rel))
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+func parseBoolParam(c *gin.Context, name string) (bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return b, nil
+}
+
+// parsePriceParam переводит границу цены в минимальные единицы currency
+// (price_currency): границы без валюты сравнивали бы, например, доллары
+// с евро.
+func parsePriceParam(c *gin.Context, name string, currency *string) (*int64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	if currency == nil {
+		return nil, fmt.Errorf("%s requires price_currency", name)
+	}
+	amount, err := model.ParseAmount(value)
+	if err != nil || strings.HasPrefix(string(amount), "-") {
+		return nil, fmt.Errorf("%s must be a non-negative number", name)
+	}
+	price, err := amount.Minor(*currency)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return &price, nil
+}
+
+func parseStockParam(c *gin.Context, name string) (*int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	stock, err := strconv.Atoi(value)
+	if err != nil || stock < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return &stock, nil
+}
+
+// parseTimeParam принимает RFC 3339 (2024-01-02T15:04:05Z) или дату (2024-01-02, полночь UTC).
+func parseTimeParam(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", name)
+}
+
+// parseSortParam разбирает "price,-created_at": поля через запятую,
+// минус означает сортировку по убыванию.
+func parseSortParam(value string) ([]repository.SortField, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) > maxSortFields {
+		return nil, fmt.Errorf("sort accepts at most %d fields", maxSortFields)
+	}
+
+	fields := make([]repository.SortField, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		field := repository.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		if !slices.Contains(repository.ProductSortFields, field.Field) {
+			return nil, fmt.Errorf("cannot sort by %q, allowed fields: %s", field.Field, strings.Join(repository.ProductSortFields, ", "))
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("sort field %q is repeated", field.Field)
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"demo-service/internal/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReserveStock godoc
+// @Summary Reserve product stock
+// @Description Reserve quantity units of the available stock (stock - reserved) until the reservation expires after ttl_seconds (RESERVATION_TTL by default, at most RESERVATION_MAX_TTL). The check and the decrement are one conditional update, so two checkouts cannot both reserve the last unit. An expired reservation is released by a background sweeper. Reservations do not change the product version.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body model.CreateReservationRequest true "Quantity and TTL"
+// @Success 201 {object} model.ReservationResponse
+// @Header 201 {string} Location "Reservation URL"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Not enough available stock"
+// @Router /api/v1/products/{id}/reservations [post]
+func (h *ProductHandler) Reserve(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req model.CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.productService.Reserve(actorContext(c), id, &req)
+	if err != nil {
+		respondReservationError(c, err, "Failed to reserve stock")
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/products/%d/reservations/%d", id, response.Reservation.ID))
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetStockReservation godoc
+// @Summary Get a stock reservation
+// @Description Reservations are visible to the user who made them and to administrators
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param reservationId path int true "Reservation ID"
+// @Success 200 {object} model.StockReservation
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/{id}/reservations/{reservationId} [get]
+func (h *ProductHandler) GetReservation(c *gin.Context) {
+	reservation, ok := h.ownReservation(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// CommitStockReservation godoc
+// @Summary Commit a stock reservation
+// @Description Deduct the reserved units from the product stock, e.g. when the order is paid. The stock change is recorded in the product history.
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param reservationId path int true "Reservation ID"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Reservation is already committed or released"
+// @Failure 410 {object} map[string]string "Reservation expired"
+// @Router /api/v1/products/{id}/reservations/{reservationId}/commit [post]
+func (h *ProductHandler) CommitReservation(c *gin.Context) {
+	reservation, ok := h.ownReservation(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.productService.CommitReservation(actorContext(c), reservation.ProductID, reservation.ID)
+	if err != nil {
+		respondReservationError(c, err, "Failed to commit stock reservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReleaseStockReservation godoc
+// @Summary Release a stock reservation
+// @Description Return the reserved units to the available stock, e.g. when the checkout is abandoned
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param reservationId path int true "Reservation ID"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Reservation is already committed, released or expired"
+// @Router /api/v1/products/{id}/reservations/{reservationId}/release [post]
+func (h *ProductHandler) ReleaseReservation(c *gin.Context) {
+	reservation, ok := h.ownReservation(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.productService.ReleaseReservation(actorContext(c), reservation.ProductID, reservation.ID)
+	if err != nil {
+		respondReservationError(c, err, "Failed to release stock reservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ownReservation читает резерв из пути запроса. Чужие резервы, кроме как
+// администраторам, не раскрываем: для них ответ 404.
+func (h *ProductHandler) ownReservation(c *gin.Context) (*model.StockReservation, bool) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return nil, false
+	}
+	id, err := strconv.ParseInt(c.Param("reservationId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return nil, false
+	}
+
+	reservation, err := h.productService.GetReservation(c.Request.Context(), productID, id)
+	if err != nil {
+		respondReservationError(c, err, "Failed to get stock reservation")
+		return nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	if reservation.ActorID == nil || userID != *reservation.ActorID {
+		admin, ok := isAdmin(c)
+		if !ok {
+			return nil, false
+		}
+		if !admin {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Stock reservation not found"})
+			return nil, false
+		}
+	}
+	return reservation, true
+}
+
+func respondReservationError(c *gin.Context, err error, message string) {
+	switch {
+	case respondContextError(c, err):
+	case errors.Is(err, repository.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errors.Is(err, repository.ErrReservationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock reservation not found"})
+	case errors.Is(err, repository.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, gin.H{"error": "Not enough available stock"})
+	case errors.Is(err, repository.ErrReservationNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": "Stock reservation is no longer active"})
+	case errors.Is(err, service.ErrReservationExpired):
+		c.JSON(http.StatusGone, gin.H{"error": "Stock reservation expired"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// respondStockBelowReserved отвечает 409 с ошибкой поля stock, если остаток
+// меньше зарезервированного, и возвращает false для остальных ошибок.
+func respondStockBelowReserved(c *gin.Context, err error) bool {
+	if !errors.Is(err, repository.ErrStockBelowReserved) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":  "Stock cannot be lower than the reserved quantity",
+		"fields": gin.H{"stock": "lower than the reserved quantity"},
+	})
+	return true
+}
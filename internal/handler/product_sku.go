@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"demo-service/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProductBySKU godoc
+// @Summary Get product by SKU
+// @Description Get product by SKU (case-insensitive)
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param sku path string true "Product SKU"
+// @Success 200 {object} model.Product
+// @Header 200 {string} ETag "Product version"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/by-sku/{sku} [get]
+func (h *ProductHandler) GetBySKU(c *gin.Context) {
+	sku, ok := skuParam(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.productService.GetBySKU(c.Request.Context(), sku)
+	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product"})
+		return
+	}
+
+	setProductETag(c, product)
+	c.JSON(http.StatusOK, product)
+}
+
+// UpsertProductBySKU godoc
+// @Summary Create or replace product by SKU
+// @Description Replace all fields of the product with this SKU, or create it if there is none. The sku field of the body may be omitted; if present, it must match the path.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param sku path string true "Product SKU"
+// @Param If-Match header string false "ETag of the product version being replaced"
+// @Param request body model.CreateProductRequest true "Product"
+// @Success 200 {object} model.Product "Product replaced"
+// @Success 201 {object} model.Product "Product created"
+// @Header 200 {string} ETag "New product version"
+// @Header 201 {string} ETag "Product version"
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Stock is lower than the reserved quantity"
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Router /api/v1/products/by-sku/{sku} [put]
+func (h *ProductHandler) UpsertBySKU(c *gin.Context) {
+	sku, ok := skuParam(c)
+	if !ok {
+		return
+	}
+
+	var req model.CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	product, created, err := h.productService.UpsertBySKU(actorContext(c), sku, &req, version)
+	if err != nil {
+		if respondContextError(c, err) || respondSKUConflict(c, err) || respondInvalidPrice(c, err) || respondStockBelowReserved(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrSKUMismatch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sku in the body must match the path", "fields": gin.H{"sku": "must match the path"}})
+			return
+		}
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified, reload it and retry"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save product"})
+		return
+	}
+
+	setProductETag(c, product)
+	if created {
+		c.JSON(http.StatusCreated, product)
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+func skuParam(c *gin.Context) (string, bool) {
+	sku := c.Param("sku")
+	if len(sku) > 64 || !model.ValidSKU(sku) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SKU"})
+		return "", false
+	}
+	return sku, true
+}
+
+// respondSKUConflict отвечает 409 с ошибкой поля sku, если SKU уже занят
+// другим продуктом, и возвращает false для остальных ошибок.
+func respondSKUConflict(c *gin.Context, err error) bool {
+	if !errors.Is(err, repository.ErrSKUExists) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":  "Product with this SKU already exists",
+		"fields": gin.H{"sku": "already used by another product"},
+	})
+	return true
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"demo-service/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdjustProductStock godoc
+// @Summary Adjust product stock
+// @Description Change the stock by a relative delta and record the change in the stock movement ledger. The sign of delta must match the reason: sale decreases the stock, restock and return increase it, adjustment may go either way. The stock cannot drop below the reserved quantity; the check and the change are one conditional update, so concurrent adjustments are safe. The product version is incremented.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body model.StockAdjustRequest true "Delta, reason and reference"
+// @Success 200 {object} model.StockAdjustResponse
+// @Header 200 {string} ETag "Product version"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Stock would drop below the reserved quantity"
+// @Router /api/v1/products/{id}/stock/adjust [post]
+func (h *ProductHandler) AdjustStock(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req model.StockAdjustRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.productService.AdjustStock(actorContext(c), id, &req)
+	if err != nil {
+		switch {
+		case respondContextError(c, err):
+		case errors.Is(err, service.ErrInvalidStockAdjustment):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case errors.Is(err, repository.ErrInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": "Stock cannot be lower than the reserved quantity"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust stock"})
+		}
+		return
+	}
+
+	setProductETag(c, response.Product)
+	c.JSON(http.StatusOK, response)
+}
+
+// ProductStockMovements godoc
+// @Summary Product stock movements
+// @Description List the stock movement ledger of a product, newest first. Every stock change is recorded: adjustments, committed reservations, and stock set through create, update, upsert, batch and import.
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} model.StockMovementListResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/products/{id}/stock/movements [get]
+func (h *ProductHandler) StockMovements(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	response, err := h.productService.StockMovements(c.Request.Context(), id, page, limit)
+	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stock movements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
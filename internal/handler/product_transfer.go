@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"demo-service/internal/service"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var productFormatContentTypes = map[string]string{
+	model.ProductFormatCSV:    "text/csv; charset=utf-8",
+	model.ProductFormatNDJSON: "application/x-ndjson",
+}
+
+// ExportProducts godoc
+// @Summary Export products
+// @Description Stream all products, or those matching the list filters, as CSV or NDJSON. The CSV header is id,sku,name,description,price,currency,stock,version,created_at,updated_at and can be imported back; prices are exact decimals.
+// @Tags products
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "File format" Enums(csv, ndjson) default(csv)
+// @Param include_deleted query bool false "Include soft-deleted products (admins only)" default(false)
+// @Param price_currency query string false "Only products priced in this currency; required with min_price, max_price and sort by price"
+// @Param min_price query number false "Minimum price, inclusive, in price_currency"
+// @Param max_price query number false "Maximum price, inclusive, in price_currency"
+// @Param min_stock query int false "Minimum stock, inclusive"
+// @Param max_stock query int false "Maximum stock, inclusive"
+// @Param in_stock query bool false "Only products with (true) or without (false) stock"
+// @Param created_after query string false "Created after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Created before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_since query string false "Updated at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param category query int false "Only products in this category"
+// @Param include_subcategories query bool false "With category: also products of all its subcategories" default(false)
+// @Param sort query string false "Comma-separated fields, '-' for descending" example(name)
+// @Success 200 {string} string "Products file"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/v1/products/export [get]
+func (h *ProductHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", model.ProductFormatCSV)
+	contentType, ok := productFormatContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or ndjson"})
+		return
+	}
+
+	opts, err := parseProductListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.Query != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is not supported for export"})
+		return
+	}
+	if opts.IncludeDeleted {
+		admin, ok := isAdmin(c)
+		if !ok {
+			return
+		}
+		if !admin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can export deleted products"})
+			return
+		}
+	}
+
+	// Выгрузка может длиться дольше WriteTimeout сервера, но не бесконечно:
+	// медленный клиент не держит обработчик дольше PRODUCT_EXPORT_TIMEOUT
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(config.AppConfig.ExportTimeout)); err != nil {
+		logrus.Warnf("Failed to extend export write deadline: %v", err)
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="products.`+format+`"`)
+	c.Status(http.StatusOK)
+
+	err = h.productService.Export(c.Request.Context(), opts, format, c.Writer)
+	if err == nil {
+		return
+	}
+	if c.Writer.Written() {
+		// Статус уже отправлен: клиент увидит оборванный файл
+		logrus.Errorf("Product export interrupted: %v", err)
+		c.Abort()
+		return
+	}
+
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	if respondContextError(c, err) {
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export products"})
+}
+
+// ImportProducts godoc
+// @Summary Import products
+// @Description Upload a CSV (header with at least name and price, optionally description, currency, stock and sku) or NDJSON file as the request body or as the "file" field of a multipart form. Rows are validated like product creation; a row whose key matches an existing product updates it, other rows create products. Files with more than PRODUCT_IMPORT_SYNC_ROWS rows are imported in the background: the response is 202 with a Location of the job to poll.
+// @Tags products
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "File format; by default taken from Content-Type or the file extension" Enums(csv, ndjson)
+// @Param key query string false "Column that identifies existing products" Enums(id, name, sku) default(id)
+// @Param file formData file false "File to import (multipart form)"
+// @Success 200 {object} model.ProductImportJob
+// @Success 202 {object} model.ProductImportJob
+// @Header 202 {string} Location "Import job URL"
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /api/v1/products/import [post]
+func (h *ProductHandler) Import(c *gin.Context) {
+	// Загрузка большого файла может длиться дольше ReadTimeout сервера
+	if err := http.NewResponseController(c.Writer).SetReadDeadline(time.Time{}); err != nil {
+		logrus.Warnf("Failed to extend import read deadline: %v", err)
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(config.AppConfig.ImportMaxBytes))
+
+	format := c.Query("format")
+	var upload io.Reader = c.Request.Body
+	if mediaType, _, _ := mime.ParseMediaType(c.ContentType()); mediaType == "multipart/form-data" {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			if respondUploadTooLarge(c, err) {
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Multipart form must contain a file field"})
+			return
+		}
+		defer file.Close()
+		upload = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+	} else if format == "" {
+		format = formatFromContentType(mediaType)
+	}
+	if _, ok := productFormatContentTypes[format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or ndjson"})
+		return
+	}
+
+	job, async, err := h.productService.Import(actorContext(c), format, c.DefaultQuery("key", model.ImportKeyID), upload)
+	if err != nil {
+		if respondContextError(c, err) || respondUploadTooLarge(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidImport) || errors.Is(err, service.ErrUnsupportedFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import products"})
+		return
+	}
+
+	if async {
+		c.Header("Location", "/api/v1/products/imports/"+strconv.FormatInt(job.ID, 10))
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// GetImportJob godoc
+// @Summary Get product import status
+// @Description Progress and row-level errors of an import started by the current user (admins see all imports). A background import interrupted by server shutdown, or left running by a crashed server and idle for 10 minutes, is reported as failed.
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Import job ID"
+// @Success 200 {object} model.ProductImportJob
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/imports/{id} [get]
+func (h *ProductHandler) GetImportJob(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import job ID"})
+		return
+	}
+
+	job, err := h.productService.GetImportJob(c.Request.Context(), id)
+	if err != nil {
+		if respondContextError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrImportJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get import job"})
+		return
+	}
+
+	// Чужие задачи не раскрываем
+	userID, _ := c.Get("user_id")
+	if job.ActorID == nil || userID != *job.ActorID {
+		admin, ok := isAdmin(c)
+		if !ok {
+			return
+		}
+		if !admin {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func formatFromContentType(mediaType string) string {
+	switch mediaType {
+	case "text/csv":
+		return model.ProductFormatCSV
+	case "application/x-ndjson", "application/jsonl":
+		return model.ProductFormatNDJSON
+	}
+	return ""
+}
+
+func respondUploadTooLarge(c *gin.Context, err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		return false
+	}
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File must be at most " + strconv.FormatInt(maxBytesErr.Limit, 10) + " bytes"})
+	return true
+}
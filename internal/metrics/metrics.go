@@ -1,7 +1,10 @@
 package metrics
 
 import (
+	"database/sql"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
@@ -23,6 +26,23 @@ var (
 		[]string{"method", "endpoint", "status"},
 	)
 
+	HTTPRequestsCanceled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_canceled_total",
+			Help: "Total number of HTTP requests aborted by client disconnect or deadline",
+		},
+		[]string{"method", "endpoint", "reason"},
+	)
+
+	DBQueryDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of repository operations in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"operation"},
+	)
+
 	ActiveConnections = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "http_active_connections",
@@ -31,6 +51,8 @@ var (
 	)
 )
 
-
-
-
+// RegisterDBStats экспортирует статистику пула соединений db (go_sql_*)
+// с меткой db_name.
+func RegisterDBStats(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Поле с десятичной суммой: Product, ProductPrice и ConvertedPrice отдают
+// в нем цену числом JSON
+const amountField = "price"
+
+// AmountsMiddleware отдает десятичные цены строками ("19.99"), если клиент
+// запросил ?amounts=string: клиенты, читающие числа JSON как float64
+// (JavaScript), иначе теряют точность больших сумм. amounts=number
+// (по умолчанию) оставляет числа. Переписываются только JSON-ответы,
+// выгрузка и файлы отдаются как есть.
+func AmountsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Query("amounts") {
+		case "", "number":
+			c.Next()
+			return
+		case "string":
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "amounts must be number or string"})
+			return
+		}
+
+		w := &amountsWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		w.flush()
+	}
+}
+
+// amountsWriter копит JSON-ответ, чтобы переписать его целиком; ответы
+// других типов пишутся сразу.
+type amountsWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	buffering bool
+	decided   bool
+}
+
+func (w *amountsWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.buffering = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	if w.buffering {
+		return w.buf.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *amountsWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *amountsWriter) flush() {
+	if !w.buffering {
+		return
+	}
+	data, err := stringifyAmounts(w.buf.Bytes())
+	if err != nil {
+		logrus.Warnf("Failed to rewrite amounts as strings: %v", err)
+		data = w.buf.Bytes()
+	}
+	if _, err := w.ResponseWriter.Write(data); err != nil {
+		logrus.Debugf("Failed to write response: %v", err)
+	}
+}
+
+// stringifyAmounts переписывает JSON по токенам, сохраняя порядок полей,
+// и заключает в кавычки числовые значения полей amountField.
+func stringifyAmounts(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	type container struct {
+		object bool
+		tokens int
+	}
+	var (
+		out   bytes.Buffer
+		stack []*container
+		key   string
+	)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			out.WriteByte(byte(delim))
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		// В объекте ключи и значения чередуются: перед ключом запятая,
+		// перед значением двоеточие
+		var parent *container
+		isKey := false
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+			isKey = parent.object && parent.tokens%2 == 0
+			if parent.tokens > 0 {
+				if parent.object && !isKey {
+					out.WriteByte(':')
+				} else {
+					out.WriteByte(',')
+				}
+			}
+			parent.tokens++
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			out.WriteByte(byte(v))
+			stack = append(stack, &container{object: v == '{'})
+			continue
+		case json.Number:
+			if parent != nil && parent.object && key == amountField {
+				tok = string(v)
+			}
+		case string:
+			if isKey {
+				key = v
+			}
+		}
+
+		encoded, err := json.Marshal(tok)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(encoded)
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"demo-service/internal/config"
 	"demo-service/pkg/jwt"
 	"net/http"
@@ -9,7 +10,17 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// AdminChecker возвращает текущие права администратора пользователя из
+// хранилища. Признак is_admin в токене не используется: отзыв прав должен
+// действовать сразу, а не после истечения токена.
+type AdminChecker func(ctx context.Context, userID int64) (bool, error)
+
+const (
+	adminCheckerKey = "admin_checker"
+	isAdminKey      = "is_admin"
+)
+
+func AuthMiddleware(admins AdminChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -41,11 +52,48 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Сохраняем информацию о пользователе в контексте
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set(adminCheckerKey, admins)
 
 		c.Next()
 	}
 }
 
+// IsAdmin проверяет по хранилищу, что текущий пользователь - администратор
+// (users.is_admin). Результат запоминается до конца запроса, поэтому
+// хранилище читается не больше одного раза. Работает после AuthMiddleware.
+func IsAdmin(c *gin.Context) (bool, error) {
+	if isAdmin, ok := c.Get(isAdminKey); ok {
+		return isAdmin.(bool), nil
+	}
 
+	value, _ := c.Get(adminCheckerKey)
+	admins, ok := value.(AdminChecker)
+	if !ok || admins == nil {
+		return false, nil
+	}
+	isAdmin, err := admins(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		return false, err
+	}
+	c.Set(isAdminKey, isAdmin)
+	return isAdmin, nil
+}
 
-
+// AdminMiddleware пропускает только администраторов (users.is_admin,
+// выдается командой cmd/admin). Ставится после AuthMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		isAdmin, err := IsAdmin(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check administrator rights"})
+			c.Abort()
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Administrator rights required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
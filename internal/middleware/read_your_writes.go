@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"demo-service/internal/config"
+	"demo-service/internal/database"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	primaryPinCookie = "db_primary_until"
+	primaryPinHeader = "X-DB-Primary-Until"
+)
+
+// ReadYourWritesMiddleware закрепляет клиента за primary на window после
+// изменяющего запроса, чтобы он сразу видел свои записи, даже если реплики
+// отстают. Метка (unix-время в миллисекундах и ее HMAC-подпись) передается
+// в cookie и заголовке ответа; клиенты без cookie могут вернуть ее тем же
+// заголовком. Подпись не дает клиенту самому выписать себе метку на
+// будущее и навсегда увести свои чтения с реплик.
+func ReadYourWritesMiddleware(window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+
+		// Метка дальше now + window могла остаться от прежней, более длинной
+		// настройки окна - ограничиваем ее текущим окном
+		if until, ok := primaryPinUntil(c); ok && now.Before(until) && !until.After(now.Add(window)) {
+			c.Request = c.Request.WithContext(database.WithPrimary(c.Request.Context()))
+		}
+
+		// Заголовки нужно выставить до записи тела ответа обработчиком
+		if isWriteMethod(c.Request.Method) {
+			until := now.Add(window)
+			ms := strconv.FormatInt(until.UnixMilli(), 10)
+			value := ms + "." + hex.EncodeToString(signPrimaryPin(ms))
+
+			c.SetSameSite(http.SameSiteLaxMode)
+			c.SetCookie(primaryPinCookie, value, int(window.Seconds())+1, "/", "", false, true)
+			c.Header(primaryPinHeader, value)
+		}
+
+		c.Next()
+	}
+}
+
+func primaryPinUntil(c *gin.Context) (time.Time, bool) {
+	value := c.GetHeader(primaryPinHeader)
+	if value == "" {
+		value, _ = c.Cookie(primaryPinCookie)
+	}
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return time.Time{}, false
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, signPrimaryPin(encoded)) {
+		return time.Time{}, false
+	}
+
+	ms, err := strconv.ParseInt(encoded, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+func signPrimaryPin(encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+	mac.Write([]byte("primary-pin:" + encoded))
+	return mac.Sum(nil)
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
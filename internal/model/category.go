@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// Category - узел дерева категорий; ParentID == nil у корневых категорий.
+type Category struct {
+	ID        int64     `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	ParentID  *int64    `json:"parent_id" db:"parent_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// Путь от корня до категории включительно, заполняется GET /categories/:id
+	Path []CategoryRef `json:"path,omitempty" db:"-"`
+}
+
+// CategoryRef - краткая ссылка на категорию в путях.
+type CategoryRef struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// ProductCategory - категория продукта с путем от корня дерева.
+type ProductCategory struct {
+	ID   int64         `json:"id"`
+	Name string        `json:"name"`
+	Path []CategoryRef `json:"path"`
+}
+
+// CategoryNode - категория с вложенными подкатегориями.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
+type CategoryTreeResponse struct {
+	Categories []CategoryNode `json:"categories"`
+}
+
+type CreateCategoryRequest struct {
+	Name     string `json:"name" binding:"required,min=1,max=255"`
+	ParentID *int64 `json:"parent_id" binding:"omitempty,gt=0"`
+}
+
+type UpdateCategoryRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=255"`
+}
+
+// MoveCategoryRequest переносит категорию вместе с поддеревом;
+// parent_id = null делает ее корневой.
+type MoveCategoryRequest struct {
+	ParentID *int64 `json:"parent_id" binding:"omitempty,gt=0"`
+}
+
+type SetProductCategoriesRequest struct {
+	CategoryIDs []int64 `json:"category_ids" binding:"required,max=50,dive,gt=0"`
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ExchangeRate - курс валюты: одна единица Base стоит Rate единиц Quote.
+// Курс действует с RateAt до следующего курса той же пары.
+type ExchangeRate struct {
+	ID        int64     `json:"id" db:"id"`
+	Base      string    `json:"base" db:"base_currency" example:"USD"`
+	Quote     string    `json:"quote" db:"quote_currency" example:"EUR"`
+	Rate      Amount    `json:"rate" db:"rate" example:"0.9215"`
+	RateAt    time.Time `json:"rate_at" db:"rate_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ExchangeRateInput - курс в запросе на загрузку. Без rate_at курс действует
+// с момента загрузки; курс пары с тем же rate_at заменяется.
+type ExchangeRateInput struct {
+	Base   string     `json:"base" binding:"required,currency" example:"USD"`
+	Quote  string     `json:"quote" binding:"required,currency" example:"EUR"`
+	Rate   Amount     `json:"rate" binding:"required" example:"0.9215"`
+	RateAt *time.Time `json:"rate_at"`
+}
+
+type SetExchangeRatesRequest struct {
+	Rates []ExchangeRateInput `json:"rates" binding:"required,min=1,dive"`
+}
+
+type ExchangeRateListResponse struct {
+	Rates []ExchangeRate `json:"rates"`
+}
+
+// ProductPrice - явная цена продукта в другой валюте. Она показывается
+// вместо пересчета по курсу.
+type ProductPrice struct {
+	ProductID  int64     `json:"product_id" db:"product_id"`
+	Currency   string    `json:"currency" db:"currency" example:"EUR"`
+	PriceMinor int64     `json:"price_minor" db:"price_minor" example:"1850"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MarshalJSON добавляет десятичную price, как у Product.
+func (p ProductPrice) MarshalJSON() ([]byte, error) {
+	type productPrice ProductPrice
+	return json.Marshal(struct {
+		productPrice
+		Price json.Number `json:"price"`
+	}{productPrice(p), json.Number(FormatMinor(p.PriceMinor, p.Currency))})
+}
+
+// SetProductPriceRequest: цена задается так же, как при создании продукта.
+type SetProductPriceRequest struct {
+	Price      *Amount `json:"price" binding:"required_without=PriceMinor" example:"18.50"`
+	PriceMinor *int64  `json:"price_minor" binding:"omitempty,gt=0" example:"1850"`
+}
+
+type ProductPriceListResponse struct {
+	Prices []ProductPrice `json:"prices"`
+}
+
+// Откуда взята цена в запрошенной валюте
+const (
+	PriceSourceNative       = "native"
+	PriceSourceOverride     = "override"
+	PriceSourceExchangeRate = "exchange_rate"
+)
+
+// ConvertedPrice - цена продукта в валюте из параметра currency. Rate и
+// RateAt есть только у пересчитанной по курсу цены: Rate - примененный
+// курс, для показа округленный до 12 знаков после запятой, RateAt - время
+// самого старого из использованных курсов.
+type ConvertedPrice struct {
+	PriceMinor int64      `json:"price_minor" example:"1842"`
+	Currency   string     `json:"currency" example:"EUR"`
+	Source     string     `json:"source" enums:"native,override,exchange_rate" example:"exchange_rate"`
+	Rate       Amount     `json:"rate,omitempty" example:"0.9215"`
+	RateAt     *time.Time `json:"rate_at,omitempty"`
+}
+
+// MarshalJSON добавляет десятичную price, как у Product.
+func (p ConvertedPrice) MarshalJSON() ([]byte, error) {
+	type convertedPrice ConvertedPrice
+	return json.Marshal(struct {
+		convertedPrice
+		Price json.Number `json:"price"`
+	}{convertedPrice(p), json.Number(FormatMinor(p.PriceMinor, p.Currency))})
+}
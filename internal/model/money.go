@@ -0,0 +1,141 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// currenciesByExponent - действующие валюты ISO 4217 по числу знаков после
+// запятой (exponent): 1 JPY неделим, 1 USD = 100 центов, 1 KWD = 1000 филсов.
+var currenciesByExponent = map[int]string{
+	0: "BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF",
+	2: "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD BTN BWP BYN BZD " +
+		"CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS " +
+		"GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL " +
+		"MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK " +
+		"PHP PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS " +
+		"TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VED VES WST XCD XCG YER ZAR ZMW ZWG",
+	3: "BHD IQD JOD KWD LYD OMR TND",
+	4: "CLF UYW",
+}
+
+var currencyExponents = func() map[string]int {
+	exponents := make(map[string]int)
+	for exponent, codes := range currenciesByExponent {
+		for _, code := range strings.Fields(codes) {
+			exponents[code] = exponent
+		}
+	}
+	return exponents
+}()
+
+// CurrencyExponent возвращает число знаков после запятой валюты; false -
+// код не из ISO 4217.
+func CurrencyExponent(currency string) (int, bool) {
+	exponent, ok := currencyExponents[currency]
+	return exponent, ok
+}
+
+// ValidCurrency проверяет код валюты ISO 4217 (в верхнем регистре).
+func ValidCurrency(currency string) bool {
+	_, ok := currencyExponents[currency]
+	return ok
+}
+
+// FormatMinor записывает сумму в минимальных единицах валюты десятичной
+// дробью с exponent знаков: 1999 USD -> "19.99", 500 JPY -> "500".
+func FormatMinor(minor int64, currency string) string {
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		exponent = 2
+	}
+
+	sign := ""
+	abs := uint64(minor)
+	if minor < 0 {
+		sign, abs = "-", -abs
+	}
+	digits := strconv.FormatUint(abs, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// amountPattern - десятичная запись суммы: как число JSON, но с
+// ограниченным порядком, чтобы 1e999999999 не стоил гигабайт памяти.
+var amountPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]{1,3})?$`)
+
+var ErrInvalidAmount = errors.New("amount must be a decimal number")
+
+// Amount - денежная сумма в десятичной записи ("19.99"). В JSON принимается
+// строкой или числом; число разбирается из исходного текста, а не через
+// float64, поэтому 0.1 остается ровно 0.1.
+type Amount string
+
+// ParseAmount проверяет десятичную запись суммы.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 64 || !amountPattern.MatchString(s) {
+		return "", ErrInvalidAmount
+	}
+	return Amount(s), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	amount, err := ParseAmount(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Minor переводит сумму в минимальные единицы валюты. Сумма с большим
+// числом знаков, чем у валюты, не округляется, а отклоняется.
+func (a Amount) Minor(currency string) (int64, error) {
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return 0, fmt.Errorf("unknown currency %q", currency)
+	}
+	value, err := a.Rat()
+	if err != nil {
+		return 0, err
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+	value.Mul(value, new(big.Rat).SetInt(scale))
+	if !value.IsInt() {
+		return 0, fmt.Errorf("%s amounts have at most %d decimal places", currency, exponent)
+	}
+	if !value.Num().IsInt64() {
+		return 0, fmt.Errorf("amount %s is too large", a)
+	}
+	return value.Num().Int64(), nil
+}
+
+// Rat возвращает точное значение суммы.
+func (a Amount) Rat() (*big.Rat, error) {
+	value, ok := new(big.Rat).SetString(string(a))
+	if !ok || !amountPattern.MatchString(string(a)) {
+		return nil, ErrInvalidAmount
+	}
+	return value, nil
+}
@@ -1,38 +1,158 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Product struct {
-	ID          int64     `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	Price       float64   `json:"price" db:"price"`
-	Stock       int       `json:"stock" db:"stock"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int64   `json:"id" db:"id"`
+	SKU         *string `json:"sku,omitempty" db:"sku"`
+	Name        string  `json:"name" db:"name"`
+	Description string  `json:"description" db:"description"`
+	// Цена в минимальных единицах валюты (центах для USD), см. CurrencyExponent.
+	// В JSON также отдается price - та же цена десятичным числом (устарело).
+	PriceMinor int64  `json:"price_minor" db:"price_minor" example:"1999"`
+	Currency   string `json:"currency" db:"currency" example:"USD"`
+	Stock      int    `json:"stock" db:"stock"`
+	// Reserved - сумма активных резервов, Available - остаток, который еще
+	// можно зарезервировать (stock - reserved)
+	Reserved  int        `json:"reserved" db:"reserved"`
+	Available int        `json:"available" db:"-"`
+	Version   int64      `json:"version" db:"version"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	// Категории продукта с путями от корня дерева; нет у продуктов без категорий
+	Categories []ProductCategory `json:"categories,omitempty" db:"-"`
+	// Фотографии и документы продукта в порядке загрузки
+	Images []ProductImage `json:"images,omitempty" db:"-"`
+
+	// Заполняется только при поиске (параметр q)
+	Highlight *ProductHighlight `json:"highlight,omitempty" db:"-"`
+	// Цена в валюте из параметра currency, только если он задан
+	ConvertedPrice *ConvertedPrice `json:"converted_price,omitempty" db:"-"`
+}
+
+// MarshalJSON добавляет поле price: цену, записанную из PriceMinor точной
+// десятичной дробью, а не через float64, и вычисляет available.
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+	p.Available = p.Stock - p.Reserved
+	return json.Marshal(struct {
+		product
+		Price json.Number `json:"price"`
+	}{product(p), json.Number(FormatMinor(p.PriceMinor, p.Currency))})
 }
 
+// ProductHighlight - название и фрагменты описания, где совпадения
+// с поисковым запросом обернуты в <mark>. Остальной текст экранирован как
+// HTML, поэтому его можно вставлять в страницу как разметку.
+type ProductHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// CreateProductRequest: цена задается price_minor или десятичной price
+// (строкой; число тоже принимается, но устарело). Если заданы обе, они
+// должны совпадать. Без currency цена в PRODUCT_DEFAULT_CURRENCY.
 type CreateProductRequest struct {
+	SKU         string  `json:"sku" binding:"omitempty,max=64,sku" example:"KET-1000"`
 	Name        string  `json:"name" binding:"required,min=1,max=255"`
 	Description string  `json:"description" binding:"max=1000"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
+	Price       *Amount `json:"price" binding:"required_without=PriceMinor" example:"19.99"`
+	PriceMinor  *int64  `json:"price_minor" binding:"omitempty,gt=0" example:"1999"`
+	Currency    string  `json:"currency" binding:"omitempty,currency" example:"USD"`
 	Stock       int     `json:"stock" binding:"gte=0"`
 }
 
+// UpdateProductRequest: nil-поля не меняются, пустой sku удаляет SKU продукта.
+// Без currency цена задается в текущей валюте продукта; сменить валюту
+// можно только вместе с ценой.
 type UpdateProductRequest struct {
-	Name        *string  `json:"name" binding:"omitempty,min=1,max=255"`
-	Description *string  `json:"description" binding:"omitempty,max=1000"`
-	Price       *float64 `json:"price" binding:"omitempty,gt=0"`
-	Stock       *int     `json:"stock" binding:"omitempty,gte=0"`
+	SKU         *string `json:"sku" binding:"omitempty,max=64,sku"`
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	Price       *Amount `json:"price" example:"19.99"`
+	PriceMinor  *int64  `json:"price_minor" binding:"omitempty,gt=0" example:"1999"`
+	Currency    *string `json:"currency" binding:"omitempty,currency" example:"USD"`
+	// Остаток не меняется через PUT (400): изменения остатка с причиной
+	// принимает POST /products/{id}/stock/adjust
+	Stock *int `json:"stock" swaggerignore:"true"`
+}
+
+// ProductSuggestion - подсказка для автодополнения; Score от 0 до 1.
+type ProductSuggestion struct {
+	ID    int64   `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
 }
 
+type ProductSuggestResponse struct {
+	Suggestions []ProductSuggestion `json:"suggestions"`
+}
+
+// ProductListResponse - страница списка. Total отсутствует при
+// include_total=false, Page - при курсорной пагинации.
 type ProductListResponse struct {
-	Products []Product `json:"products"`
-	Total    int       `json:"total"`
-	Page     int       `json:"page"`
-	Limit    int       `json:"limit"`
+	Products   []Product `json:"products"`
+	Total      *int      `json:"total,omitempty"`
+	Page       int       `json:"page,omitempty"`
+	Limit      int       `json:"limit"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	PrevCursor string    `json:"prev_cursor,omitempty"`
 }
 
+// Режимы пакетной операции над продуктами
+const (
+	// BatchModeAtomic применяет все операции или ни одной
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort применяет то, что удалось, со статусом каждой операции
+	BatchModeBestEffort = "best_effort"
+)
+
+// Операции в ProductBatchOperation.Op
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+type ProductBatchRequest struct {
+	Mode       string                  `json:"mode" binding:"omitempty,oneof=atomic best_effort" example:"atomic"`
+	Operations []ProductBatchOperation `json:"operations" binding:"required,min=1"`
+}
 
+// ProductBatchOperation - одна операция пакета. Поля проверяются сервисом,
+// чтобы в режиме best_effort ошибка одной операции не отклоняла весь пакет.
+type ProductBatchOperation struct {
+	Op string `json:"op" example:"update"`
+	// ID и Version (ожидаемая версия, 0 - без проверки) - для update и delete
+	ID          int64   `json:"id,omitempty"`
+	Version     int64   `json:"version,omitempty"`
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Price       *Amount `json:"price,omitempty"`
+	PriceMinor  *int64  `json:"price_minor,omitempty"`
+	Currency    *string `json:"currency,omitempty"`
+	Stock       *int    `json:"stock,omitempty"`
+	// SKU задается при create и меняется при update; пустая строка удаляет SKU
+	SKU *string `json:"sku,omitempty" example:"KET-1000"`
+}
 
+// ProductBatchResult - результат операции с индексом Index в запросе.
+// Status - HTTP-код, который вернул бы одиночный запрос.
+type ProductBatchResult struct {
+	Index   int      `json:"index"`
+	Status  int      `json:"status"`
+	Product *Product `json:"product,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
 
+type ProductBatchResponse struct {
+	Mode      string               `json:"mode"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []ProductBatchResult `json:"results"`
+}
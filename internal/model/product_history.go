@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Операции, записываемые в историю продукта
+const (
+	ProductOpCreate   = "create"
+	ProductOpUpdate   = "update"
+	ProductOpDelete   = "delete"
+	ProductOpRestore  = "restore"
+	ProductOpSnapshot = "snapshot"
+)
+
+// FieldChange - значение поля до и после изменения.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ProductHistoryEntry - одно изменение продукта. Changes содержит только
+// изменившиеся поля UpdateProductRequest; Version - версия продукта после изменения.
+type ProductHistoryEntry struct {
+	ID        int64                  `json:"id" db:"id"`
+	ProductID int64                  `json:"product_id" db:"product_id"`
+	Version   int64                  `json:"version" db:"version"`
+	ActorID   *int64                 `json:"actor_id" db:"actor_id"`
+	Operation string                 `json:"operation" db:"operation"`
+	Changes   map[string]FieldChange `json:"changes" db:"changes"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+}
+
+type ProductHistoryResponse struct {
+	Entries []ProductHistoryEntry `json:"entries"`
+	Total   int                   `json:"total"`
+	Page    int                   `json:"page"`
+	Limit   int                   `json:"limit"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// ProductImage - файл продукта: фотография или документ (например, PDF со
+// спецификацией). URL ведут на эндпоинты скачивания. Миниатюра, ширина и
+// высота есть только у изображений, которые сервис умеет декодировать
+// (JPEG, PNG, GIF).
+type ProductImage struct {
+	ID           int64     `json:"id" db:"id"`
+	ProductID    int64     `json:"product_id" db:"product_id"`
+	Filename     string    `json:"filename" db:"filename" example:"kettle.jpg"`
+	ContentType  string    `json:"content_type" db:"content_type" example:"image/jpeg"`
+	Size         int64     `json:"size" db:"size_bytes"`
+	Width        *int      `json:"width,omitempty" db:"width"`
+	Height       *int      `json:"height,omitempty" db:"height"`
+	URL          string    `json:"url" db:"-" example:"/api/v1/products/1/images/3"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty" db:"-" example:"/api/v1/products/1/images/3/thumbnail"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+
+	// Ключи содержимого в хранилище файлов
+	BlobKey      string  `json:"-" db:"blob_key"`
+	ThumbnailKey *string `json:"-" db:"thumbnail_key"`
+}
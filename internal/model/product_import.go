@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// Форматы выгрузки и загрузки каталога
+const (
+	ProductFormatCSV    = "csv"
+	ProductFormatNDJSON = "ndjson"
+)
+
+// Ключи, по которым импорт находит существующий продукт
+const (
+	ImportKeyID   = "id"
+	ImportKeyName = "name"
+	ImportKeySKU  = "sku"
+)
+
+// Состояния задачи импорта
+const (
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// ImportRowError - ошибка строки файла импорта. Row - номер строки файла,
+// считая заголовок CSV.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ProductImportJob - задача импорта. Error заполняется, если импорт прерван
+// целиком; ошибки отдельных строк - в RowErrors (не больше первой тысячи).
+type ProductImportJob struct {
+	ID            int64            `json:"id" db:"id"`
+	Status        string           `json:"status" db:"status"`
+	Format        string           `json:"format" db:"format"`
+	Key           string           `json:"key" db:"upsert_key"`
+	ActorID       *int64           `json:"actor_id" db:"actor_id"`
+	TotalRows     int              `json:"total_rows" db:"total_rows"`
+	ProcessedRows int              `json:"processed_rows" db:"processed_rows"`
+	CreatedRows   int              `json:"created_rows" db:"created_rows"`
+	UpdatedRows   int              `json:"updated_rows" db:"updated_rows"`
+	FailedRows    int              `json:"failed_rows" db:"failed_rows"`
+	RowErrors     []ImportRowError `json:"row_errors" db:"row_errors"`
+	Error         string           `json:"error,omitempty" db:"error"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at" db:"updated_at"`
+	FinishedAt    *time.Time       `json:"finished_at,omitempty" db:"finished_at"`
+}
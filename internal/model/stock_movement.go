@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// Причины движения остатка. Initial - остаток нового продукта (и продуктов,
+// существовавших до журнала), остальные задаются при корректировке;
+// sale также записывается при подтверждении резерва, adjustment - при
+// перезаписи stock через PUT, пакет или импорт.
+const (
+	StockReasonInitial    = "initial"
+	StockReasonSale       = "sale"
+	StockReasonRestock    = "restock"
+	StockReasonAdjustment = "adjustment"
+	StockReasonReturn     = "return"
+)
+
+// StockMovement - неизменяемая запись журнала остатка: изменение Delta и
+// остаток StockAfter после него. Reference - внешний идентификатор
+// (номер заказа, накладной) или резерв "reservation:<id>".
+type StockMovement struct {
+	ID         int64     `json:"id" db:"id"`
+	ProductID  int64     `json:"product_id" db:"product_id"`
+	Delta      int       `json:"delta" db:"delta" example:"-2"`
+	StockAfter int       `json:"stock_after" db:"stock_after" example:"8"`
+	Reason     string    `json:"reason" db:"reason" enums:"initial,sale,restock,adjustment,return"`
+	Reference  string    `json:"reference,omitempty" db:"reference" example:"order-1042"`
+	ActorID    *int64    `json:"actor_id" db:"actor_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// StockAdjustRequest - относительное изменение остатка. Знак delta должен
+// соответствовать причине: sale уменьшает остаток, restock и return
+// увеличивают, adjustment - в любую сторону.
+type StockAdjustRequest struct {
+	Delta     int    `json:"delta" binding:"required" example:"-2"`
+	Reason    string `json:"reason" binding:"required,oneof=sale restock adjustment return" example:"sale"`
+	Reference string `json:"reference" binding:"max=255" example:"order-1042"`
+}
+
+// StockAdjustResponse - записанное движение и продукт после него.
+type StockAdjustResponse struct {
+	Movement StockMovement `json:"movement"`
+	Product  *Product      `json:"product"`
+}
+
+type StockMovementListResponse struct {
+	Movements []StockMovement `json:"movements"`
+	Total     int             `json:"total"`
+	Page      int             `json:"page"`
+	Limit     int             `json:"limit"`
+}
+
+// StockDrift - расхождение остатка продукта с суммой его журнала.
+type StockDrift struct {
+	ProductID   int64 `json:"product_id"`
+	Stock       int   `json:"stock"`
+	LedgerStock int   `json:"ledger_stock"`
+}
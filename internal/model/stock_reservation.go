@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Состояния резерва: активный резерв уменьшает доступный остаток продукта,
+// подтвержденный списан со склада, снятый и истекший вернули остаток.
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusCommitted = "committed"
+	ReservationStatusReleased  = "released"
+	ReservationStatusExpired   = "expired"
+)
+
+// StockReservation - резерв Quantity единиц продукта до ExpiresAt. Пока резерв
+// активен, эти единицы входят в Product.Reserved и недоступны другим.
+type StockReservation struct {
+	ID        int64     `json:"id" db:"id"`
+	ProductID int64     `json:"product_id" db:"product_id"`
+	Quantity  int       `json:"quantity" db:"quantity"`
+	Status    string    `json:"status" db:"status" enums:"active,committed,released,expired"`
+	ActorID   *int64    `json:"actor_id" db:"actor_id"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateReservationRequest: без ttl_seconds резерв действует RESERVATION_TTL,
+// больший RESERVATION_MAX_TTL срок сокращается до него.
+type CreateReservationRequest struct {
+	Quantity   int `json:"quantity" binding:"required,gt=0" example:"1"`
+	TTLSeconds int `json:"ttl_seconds" binding:"omitempty,gt=0" example:"900"`
+}
+
+// ReservationResponse - резерв и продукт с остатками после операции.
+type ReservationResponse struct {
+	Reservation StockReservation `json:"reservation"`
+	Product     *Product         `json:"product,omitempty"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// skuPattern - допустимый SKU: латинские буквы, цифры и разделители . _ -,
+// начинается с буквы или цифры. Регистр не важен, хранится верхний.
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidSKU проверяет формат SKU (длину ограничивает тег max).
+func ValidSKU(sku string) bool {
+	return skuPattern.MatchString(sku)
+}
+
+// RegisterValidations добавляет в v проверки, на которые ссылаются теги
+// binding моделей: "sku" (пустая строка допустима - это "без SKU") и
+// "currency" (код ISO 4217 в любом регистре).
+func RegisterValidations(v *validator.Validate) error {
+	err := v.RegisterValidation("sku", func(fl validator.FieldLevel) bool {
+		sku := fl.Field().String()
+		return sku == "" || ValidSKU(sku)
+	})
+	if err != nil {
+		return err
+	}
+	return v.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return ValidCurrency(strings.ToUpper(fl.Field().String()))
+	})
+}
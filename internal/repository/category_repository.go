@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const categoryColumns = `id, name, parent_id, created_at, updated_at`
+
+// categorySubtreeSQL выбирает категорию (параметр) и всех ее потомков.
+// UNION, а не UNION ALL: рекурсия остановится даже на дереве с циклом.
+const categorySubtreeSQL = `WITH RECURSIVE subtree(id) AS (
+	SELECT CAST(? AS BIGINT)
+	UNION
+	SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+) SELECT id FROM subtree`
+
+// categoryAncestorsSQL поднимается от категорий из условия %s к корню.
+// depth ограничен, чтобы рекурсия остановилась и на дереве с циклом.
+const categoryAncestorsSQL = `WITH RECURSIVE ancestors(category_id, id, name, parent_id, depth) AS (
+	SELECT c.id, c.id, c.name, c.parent_id, 0 FROM categories c WHERE %s
+	UNION ALL
+	SELECT a.category_id, p.id, p.name, p.parent_id, a.depth + 1
+	FROM categories p JOIN ancestors a ON p.id = a.parent_id
+	WHERE a.depth < 100
+)`
+
+type CategoryRepository struct {
+	db       *sql.DB
+	replicas *database.ReplicaSet
+	dialect  database.Dialect
+}
+
+func NewCategoryRepository() *CategoryRepository {
+	return &CategoryRepository{
+		db:       database.DB,
+		replicas: database.Replicas,
+		dialect:  database.CurrentDialect,
+	}
+}
+
+func scanCategory(row rowScanner, category *model.Category) error {
+	return row.Scan(&category.ID, &category.Name, &category.ParentID, &category.CreatedAt, &category.UpdatedAt)
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *model.Category) error {
+	defer observeQuery("category.create")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO categories (name, parent_id) VALUES (?, ?) RETURNING id, created_at, updated_at`
+	err := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), category.Name, category.ParentID).
+		Scan(&category.ID, &category.CreatedAt, &category.UpdatedAt)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrCategoryExists
+		}
+		return fmt.Errorf("failed to create category: %w", queryError(ctx, err))
+	}
+	return nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id int64) (*model.Category, error) {
+	defer observeQuery("category.get_by_id")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE id = ?`
+	pathQuery := fmt.Sprintf(categoryAncestorsSQL, "c.id = ?") + ` SELECT id, name FROM ancestors ORDER BY depth DESC`
+
+	category := &model.Category{}
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		if err := scanCategory(q.QueryRowContext(ctx, r.dialect.Rebind(query), id), category); err != nil {
+			return err
+		}
+
+		rows, err := q.QueryContext(ctx, r.dialect.Rebind(pathQuery), id)
+		if err != nil {
+			return fmt.Errorf("failed to get category path: %w", err)
+		}
+		defer rows.Close()
+
+		category.Path = nil
+		for rows.Next() {
+			var ref model.CategoryRef
+			if err := rows.Scan(&ref.ID, &ref.Name); err != nil {
+				return fmt.Errorf("failed to scan category path: %w", err)
+			}
+			category.Path = append(category.Path, ref)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get category: %w", queryError(ctx, err))
+	}
+	return category, nil
+}
+
+func (r *CategoryRepository) List(ctx context.Context) ([]model.Category, error) {
+	defer observeQuery("category.list")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + categoryColumns + ` FROM categories ORDER BY name, id`
+
+	var categories []model.Category
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		categories = nil
+
+		rows, err := q.QueryContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to list categories: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var category model.Category
+			if err := scanCategory(rows, &category); err != nil {
+				return fmt.Errorf("failed to scan category: %w", err)
+			}
+			categories = append(categories, category)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return categories, nil
+}
+
+func (r *CategoryRepository) Rename(ctx context.Context, id int64, name string) (*model.Category, error) {
+	defer observeQuery("category.rename")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE categories SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING ` + categoryColumns
+	return r.updateCategory(ctx, "rename", query, name, id)
+}
+
+// Move проверяет цикл и переносит категорию одним запросом: условие NOT IN
+// вычисляется по дереву на момент изменения.
+func (r *CategoryRepository) Move(ctx context.Context, id int64, parentID *int64) (*model.Category, error) {
+	defer observeQuery("category.move")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if parentID == nil {
+		query := `UPDATE categories SET parent_id = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING ` + categoryColumns
+		return r.updateCategory(ctx, "move", query, id)
+	}
+
+	query := `UPDATE categories SET parent_id = ?, updated_at = CURRENT_TIMESTAMP
+	          WHERE id = ? AND ? NOT IN (` + categorySubtreeSQL + `) RETURNING ` + categoryColumns
+	category, err := r.updateCategory(ctx, "move", query, *parentID, id, *parentID, id)
+	if !errors.Is(err, ErrCategoryNotFound) {
+		return category, err
+	}
+
+	// Строка не изменилась: категории нет или parentID в ее поддереве
+	if _, err := r.GetByID(database.WithPrimary(ctx), id); err != nil {
+		return nil, err
+	}
+	return nil, ErrCategoryCycle
+}
+
+func (r *CategoryRepository) updateCategory(ctx context.Context, operation, query string, args ...interface{}) (*model.Category, error) {
+	category := &model.Category{}
+	row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), args...)
+	if err := scanCategory(row, category); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCategoryNotFound
+		}
+		if r.dialect.IsUniqueViolation(err) {
+			return nil, ErrCategoryExists
+		}
+		return nil, fmt.Errorf("failed to %s category: %w", operation, queryError(ctx, err))
+	}
+	return category, nil
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id int64) error {
+	defer observeQuery("category.delete")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	q := database.QuerierFrom(ctx, r.db)
+
+	var hasChildren bool
+	query := `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = ?)`
+	if err := q.QueryRowContext(ctx, r.dialect.Rebind(query), id).Scan(&hasChildren); err != nil {
+		return fmt.Errorf("failed to check subcategories: %w", queryError(ctx, err))
+	}
+	if hasChildren {
+		return ErrCategoryHasChildren
+	}
+
+	// Связи с продуктами удаляет ON DELETE CASCADE
+	result, err := q.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM categories WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", queryError(ctx, err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", queryError(ctx, err))
+	}
+	if rowsAffected == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// SetProductCategories должен выполняться в транзакции: удаление старых
+// связей и вставка новых - отдельные запросы.
+func (r *CategoryRepository) SetProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error {
+	defer observeQuery("category.set_product_categories")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	q := database.QuerierFrom(ctx, r.db)
+	categoryIDs = slices.Clone(categoryIDs)
+	slices.Sort(categoryIDs)
+	categoryIDs = slices.Compact(categoryIDs)
+
+	if len(categoryIDs) > 0 {
+		args := make([]interface{}, len(categoryIDs))
+		for i, id := range categoryIDs {
+			args[i] = id
+		}
+
+		var found int
+		query := `SELECT COUNT(*) FROM categories WHERE id IN (` + valuesSQL("?", len(categoryIDs)) + `)`
+		if err := q.QueryRowContext(ctx, r.dialect.Rebind(query), args...).Scan(&found); err != nil {
+			return fmt.Errorf("failed to check categories: %w", queryError(ctx, err))
+		}
+		if found != len(categoryIDs) {
+			return ErrCategoryNotFound
+		}
+	}
+
+	query := `DELETE FROM product_categories WHERE product_id = ?`
+	if _, err := q.ExecContext(ctx, r.dialect.Rebind(query), productID); err != nil {
+		return fmt.Errorf("failed to clear product categories: %w", queryError(ctx, err))
+	}
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(categoryIDs)*2)
+	for _, id := range categoryIDs {
+		args = append(args, productID, id)
+	}
+	query = `INSERT INTO product_categories (product_id, category_id) VALUES ` + valuesSQL("(?, ?)", len(categoryIDs))
+	if _, err := q.ExecContext(ctx, r.dialect.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to set product categories: %w", queryError(ctx, err))
+	}
+	return nil
+}
+
+// ProductCategories строит пути всех категорий продуктов одним рекурсивным
+// запросом на порцию продуктов.
+func (r *CategoryRepository) ProductCategories(ctx context.Context, productIDs []int64) (map[int64][]model.ProductCategory, error) {
+	defer observeQuery("category.product_categories")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result := make(map[int64][]model.ProductCategory, len(productIDs))
+	for _, chunk := range chunks(productIDs, batchChunkRows) {
+		in := valuesSQL("?", len(chunk))
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+		// Список продуктов нужен дважды: в CTE и в основном запросе
+		args = append(args, args...)
+
+		query := fmt.Sprintf(categoryAncestorsSQL, `c.id IN (SELECT category_id FROM product_categories WHERE product_id IN (`+in+`))`) + `
+			SELECT pc.product_id, a.category_id, a.id, a.name
+			FROM product_categories pc JOIN ancestors a ON a.category_id = pc.category_id
+			WHERE pc.product_id IN (` + in + `)
+			ORDER BY pc.product_id, pc.category_id, a.depth DESC`
+		err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+			rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+			if err != nil {
+				return fmt.Errorf("failed to get product categories: %w", err)
+			}
+			defer rows.Close()
+
+			paths := make(map[int64][]model.ProductCategory, len(chunk))
+			for rows.Next() {
+				var (
+					productID, categoryID int64
+					ref                   model.CategoryRef
+				)
+				if err := rows.Scan(&productID, &categoryID, &ref.ID, &ref.Name); err != nil {
+					return fmt.Errorf("failed to scan product category: %w", err)
+				}
+				paths[productID] = appendCategoryPath(paths[productID], categoryID, ref)
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for id, categories := range paths {
+				result[id] = categories
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, queryError(ctx, err)
+		}
+	}
+	return result, nil
+}
+
+// appendCategoryPath добавляет очередной узел пути (от корня вниз) к
+// категории categoryID; строки одной категории идут подряд.
+func appendCategoryPath(categories []model.ProductCategory, categoryID int64, ref model.CategoryRef) []model.ProductCategory {
+	if n := len(categories); n == 0 || categories[n-1].ID != categoryID {
+		categories = append(categories, model.ProductCategory{ID: categoryID})
+	}
+	last := &categories[len(categories)-1]
+	last.Path = append(last.Path, ref)
+	if ref.ID == categoryID {
+		last.Name = ref.Name
+	}
+	return categories
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"fmt"
+	"time"
+)
+
+const exchangeRateColumns = `id, base_currency, quote_currency, rate, rate_at, created_at`
+
+type ExchangeRateRepository struct {
+	db       *sql.DB
+	replicas *database.ReplicaSet
+	dialect  database.Dialect
+}
+
+func NewExchangeRateRepository() *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db:       database.DB,
+		replicas: database.Replicas,
+		dialect:  database.CurrentDialect,
+	}
+}
+
+func scanExchangeRate(row rowScanner, rate *model.ExchangeRate) error {
+	return row.Scan(&rate.ID, &rate.Base, &rate.Quote, &rate.Rate, &rate.RateAt, &rate.CreatedAt)
+}
+
+func (r *ExchangeRateRepository) Save(ctx context.Context, rate *model.ExchangeRate) error {
+	defer observeQuery("exchange_rate.save")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO exchange_rates (base_currency, quote_currency, rate, rate_at) VALUES (?, ?, ?, ?)
+	          ON CONFLICT (base_currency, quote_currency, rate_at)
+	          DO UPDATE SET rate = excluded.rate, created_at = CURRENT_TIMESTAMP
+	          RETURNING id, created_at`
+	err := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), rate.Base, rate.Quote, rate.Rate, r.dialect.TimeArg(rate.RateAt)).
+		Scan(&rate.ID, &rate.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save exchange rate: %w", queryError(ctx, err))
+	}
+	return nil
+}
+
+func (r *ExchangeRateRepository) Current(ctx context.Context, at time.Time) ([]model.ExchangeRate, error) {
+	defer observeQuery("exchange_rate.current")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates e
+	          WHERE e.rate_at = (SELECT MAX(l.rate_at) FROM exchange_rates l
+	                             WHERE l.base_currency = e.base_currency AND l.quote_currency = e.quote_currency
+	                               AND l.rate_at <= ?)
+	          ORDER BY e.base_currency, e.quote_currency`
+
+	var rates []model.ExchangeRate
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		rates = nil
+		rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), r.dialect.TimeArg(at))
+		if err != nil {
+			return fmt.Errorf("failed to list exchange rates: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var rate model.ExchangeRate
+			if err := scanExchangeRate(rows, &rate); err != nil {
+				return fmt.Errorf("failed to scan exchange rate: %w", err)
+			}
+			rates = append(rates, rate)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return rates, nil
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, id int64) error {
+	defer observeQuery("exchange_rate.delete")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := database.QuerierFrom(ctx, r.db).
+		ExecContext(ctx, r.dialect.Rebind(`DELETE FROM exchange_rates WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete exchange rate: %w", queryError(ctx, err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete exchange rate: %w", err)
+	}
+	if affected == 0 {
+		return ErrExchangeRateNotFound
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"demo-service/internal/model"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryCategoryRepository хранит дерево категорий и связи с продуктами
+// в памяти процесса. MemoryProductRepository читает связи для фильтра по
+// категории.
+type MemoryCategoryRepository struct {
+	mu         sync.RWMutex
+	categories map[int64]model.Category
+	// Категории каждого продукта, по возрастанию id
+	links  map[int64][]int64
+	nextID int64
+}
+
+func NewMemoryCategoryRepository() *MemoryCategoryRepository {
+	return &MemoryCategoryRepository{
+		categories: make(map[int64]model.Category),
+		links:      make(map[int64][]int64),
+		nextID:     1,
+	}
+}
+
+func (r *MemoryCategoryRepository) Create(ctx context.Context, category *model.Category) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if category.ParentID != nil {
+		if _, ok := r.categories[*category.ParentID]; !ok {
+			return ErrCategoryNotFound
+		}
+	}
+	if r.hasSibling(category.ParentID, category.Name, 0) {
+		return ErrCategoryExists
+	}
+
+	now := time.Now().UTC()
+	category.ID = r.nextID
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	r.nextID++
+
+	stored := *category
+	stored.Path = nil
+	r.categories[category.ID] = stored
+	return nil
+}
+
+func (r *MemoryCategoryRepository) GetByID(ctx context.Context, id int64) (*model.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	category.Path = r.path(id)
+	return &category, nil
+}
+
+func (r *MemoryCategoryRepository) List(ctx context.Context) ([]model.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	categories := make([]model.Category, 0, len(r.categories))
+	for _, category := range r.categories {
+		categories = append(categories, category)
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(categories, func(a, b model.Category) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return categories, nil
+}
+
+func (r *MemoryCategoryRepository) Rename(ctx context.Context, id int64, name string) (*model.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	if r.hasSibling(category.ParentID, name, id) {
+		return nil, ErrCategoryExists
+	}
+
+	category.Name = name
+	category.UpdatedAt = time.Now().UTC()
+	r.categories[id] = category
+	return &category, nil
+}
+
+func (r *MemoryCategoryRepository) Move(ctx context.Context, id int64, parentID *int64) (*model.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	if parentID != nil {
+		if _, ok := r.categories[*parentID]; !ok {
+			return nil, ErrCategoryNotFound
+		}
+		if r.subtree(id)[*parentID] {
+			return nil, ErrCategoryCycle
+		}
+	}
+	if r.hasSibling(parentID, category.Name, id) {
+		return nil, ErrCategoryExists
+	}
+
+	category.ParentID = parentID
+	category.UpdatedAt = time.Now().UTC()
+	r.categories[id] = category
+	return &category, nil
+}
+
+func (r *MemoryCategoryRepository) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.categories[id]; !ok {
+		return ErrCategoryNotFound
+	}
+	for _, category := range r.categories {
+		if category.ParentID != nil && *category.ParentID == id {
+			return ErrCategoryHasChildren
+		}
+	}
+
+	delete(r.categories, id)
+	for productID, categoryIDs := range r.links {
+		r.setLinks(productID, slices.DeleteFunc(categoryIDs, func(categoryID int64) bool {
+			return categoryID == id
+		}))
+	}
+	return nil
+}
+
+func (r *MemoryCategoryRepository) SetProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range categoryIDs {
+		if _, ok := r.categories[id]; !ok {
+			return ErrCategoryNotFound
+		}
+	}
+
+	categoryIDs = slices.Clone(categoryIDs)
+	slices.Sort(categoryIDs)
+	r.setLinks(productID, slices.Compact(categoryIDs))
+	return nil
+}
+
+func (r *MemoryCategoryRepository) ProductCategories(ctx context.Context, productIDs []int64) (map[int64][]model.ProductCategory, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[int64][]model.ProductCategory, len(productIDs))
+	for _, productID := range productIDs {
+		for _, id := range r.links[productID] {
+			result[productID] = append(result[productID], model.ProductCategory{
+				ID:   id,
+				Name: r.categories[id].Name,
+				Path: r.path(id),
+			})
+		}
+	}
+	return result, nil
+}
+
+// productsIn возвращает продукты категории, а с subcategories - и продукты
+// ее подкатегорий.
+func (r *MemoryCategoryRepository) productsIn(categoryID int64, subcategories bool) map[int64]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scope := map[int64]bool{categoryID: true}
+	if subcategories {
+		scope = r.subtree(categoryID)
+	}
+
+	products := make(map[int64]bool)
+	for productID, categoryIDs := range r.links {
+		if slices.ContainsFunc(categoryIDs, func(id int64) bool { return scope[id] }) {
+			products[productID] = true
+		}
+	}
+	return products
+}
+
+// unlinkProducts удаляет связи окончательно удаленных продуктов.
+func (r *MemoryCategoryRepository) unlinkProducts(productIDs []int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range productIDs {
+		delete(r.links, id)
+	}
+}
+
+func (r *MemoryCategoryRepository) setLinks(productID int64, categoryIDs []int64) {
+	if len(categoryIDs) == 0 {
+		delete(r.links, productID)
+		return
+	}
+	r.links[productID] = categoryIDs
+}
+
+// subtree возвращает категорию и всех ее потомков.
+func (r *MemoryCategoryRepository) subtree(id int64) map[int64]bool {
+	subtree := map[int64]bool{id: true}
+	for grown := true; grown; {
+		grown = false
+		for _, category := range r.categories {
+			if category.ParentID != nil && subtree[*category.ParentID] && !subtree[category.ID] {
+				subtree[category.ID] = true
+				grown = true
+			}
+		}
+	}
+	return subtree
+}
+
+// path возвращает путь от корня до категории включительно.
+func (r *MemoryCategoryRepository) path(id int64) []model.CategoryRef {
+	var path []model.CategoryRef
+	for current, ok := r.categories[id]; ok; {
+		path = append(path, model.CategoryRef{ID: current.ID, Name: current.Name})
+		if current.ParentID == nil {
+			break
+		}
+		current, ok = r.categories[*current.ParentID]
+	}
+	slices.Reverse(path)
+	return path
+}
+
+func (r *MemoryCategoryRepository) hasSibling(parentID *int64, name string, exceptID int64) bool {
+	for _, category := range r.categories {
+		if category.ID != exceptID && category.Name == name && equalParent(category.ParentID, parentID) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalParent(a, b *int64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryExchangeRateRepository хранит историю курсов валют в памяти процесса.
+type MemoryExchangeRateRepository struct {
+	mu     sync.RWMutex
+	rates  []model.ExchangeRate
+	nextID int64
+}
+
+func NewMemoryExchangeRateRepository() *MemoryExchangeRateRepository {
+	return &MemoryExchangeRateRepository{nextID: 1}
+}
+
+func (r *MemoryExchangeRateRepository) Save(ctx context.Context, rate *model.ExchangeRate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rate.CreatedAt = time.Now().UTC()
+	i := slices.IndexFunc(r.rates, func(existing model.ExchangeRate) bool {
+		return existing.Base == rate.Base && existing.Quote == rate.Quote && existing.RateAt.Equal(rate.RateAt)
+	})
+	if i >= 0 {
+		rate.ID = r.rates[i].ID
+		r.rates[i] = *rate
+		return nil
+	}
+
+	rate.ID = r.nextID
+	r.nextID++
+	r.rates = append(r.rates, *rate)
+	return nil
+}
+
+func (r *MemoryExchangeRateRepository) Current(ctx context.Context, at time.Time) ([]model.ExchangeRate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	latest := make(map[[2]string]model.ExchangeRate)
+	for _, rate := range r.rates {
+		if rate.RateAt.After(at) {
+			continue
+		}
+		pair := [2]string{rate.Base, rate.Quote}
+		if current, ok := latest[pair]; !ok || rate.RateAt.After(current.RateAt) {
+			latest[pair] = rate
+		}
+	}
+
+	rates := make([]model.ExchangeRate, 0, len(latest))
+	for _, rate := range latest {
+		rates = append(rates, rate)
+	}
+	slices.SortFunc(rates, func(a, b model.ExchangeRate) int {
+		if c := strings.Compare(a.Base, b.Base); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Quote, b.Quote)
+	})
+	return rates, nil
+}
+
+func (r *MemoryExchangeRateRepository) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.rates, func(rate model.ExchangeRate) bool { return rate.ID == id })
+	if i < 0 {
+		return ErrExchangeRateNotFound
+	}
+	r.rates = slices.Delete(r.rates, i, i+1)
+	return nil
+}
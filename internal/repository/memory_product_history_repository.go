@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"sync"
+	"time"
+)
+
+// MemoryProductHistoryRepository хранит историю продуктов в памяти процесса.
+type MemoryProductHistoryRepository struct {
+	mu      sync.RWMutex
+	entries []model.ProductHistoryEntry
+	nextID  int64
+}
+
+func NewMemoryProductHistoryRepository() *MemoryProductHistoryRepository {
+	return &MemoryProductHistoryRepository{nextID: 1}
+}
+
+func (r *MemoryProductHistoryRepository) Add(ctx context.Context, entry *model.ProductHistoryEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.ID = r.nextID
+	entry.CreatedAt = time.Now().UTC()
+	r.nextID++
+
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *MemoryProductHistoryRepository) AddMany(ctx context.Context, entries []*model.ProductHistoryEntry) error {
+	for _, entry := range entries {
+		if err := r.Add(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryProductHistoryRepository) List(ctx context.Context, productID int64, page, limit int) ([]model.ProductHistoryEntry, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Свежие изменения первыми, как ORDER BY id DESC
+	var entries []model.ProductHistoryEntry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if r.entries[i].ProductID == productID {
+			entries = append(entries, r.entries[i])
+		}
+	}
+
+	total := len(entries)
+	offset := (page - 1) * limit
+	if offset >= total {
+		return nil, total, nil
+	}
+
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return entries[offset:end], total, nil
+}
+
+func (r *MemoryProductHistoryRepository) ListUntil(ctx context.Context, productID int64, until time.Time) ([]model.ProductHistoryEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []model.ProductHistoryEntry
+	for _, entry := range r.entries {
+		if entry.ProductID == productID && !entry.CreatedAt.After(until) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryProductImageRepository хранит описания файлов продуктов в памяти
+// процесса. MemoryProductRepository стирает файлы продуктов при Purge.
+type MemoryProductImageRepository struct {
+	mu sync.RWMutex
+	// Файлы каждого продукта, по возрастанию id
+	images map[int64][]model.ProductImage
+	nextID int64
+}
+
+func NewMemoryProductImageRepository() *MemoryProductImageRepository {
+	return &MemoryProductImageRepository{
+		images: make(map[int64][]model.ProductImage),
+		nextID: 1,
+	}
+}
+
+func (r *MemoryProductImageRepository) Create(ctx context.Context, image *model.ProductImage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image.ID = r.nextID
+	image.CreatedAt = time.Now().UTC()
+	r.nextID++
+
+	r.images[image.ProductID] = append(r.images[image.ProductID], *image)
+	return nil
+}
+
+func (r *MemoryProductImageRepository) GetByID(ctx context.Context, productID, id int64) (*model.ProductImage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.index(productID, id)
+	if i < 0 {
+		return nil, ErrImageNotFound
+	}
+	image := r.images[productID][i]
+	return &image, nil
+}
+
+func (r *MemoryProductImageRepository) Delete(ctx context.Context, productID, id int64) (*model.ProductImage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(productID, id)
+	if i < 0 {
+		return nil, ErrImageNotFound
+	}
+	image := r.images[productID][i]
+	r.images[productID] = slices.Delete(r.images[productID], i, i+1)
+	if len(r.images[productID]) == 0 {
+		delete(r.images, productID)
+	}
+	return &image, nil
+}
+
+func (r *MemoryProductImageRepository) ListByProducts(ctx context.Context, productIDs []int64) (map[int64][]model.ProductImage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[int64][]model.ProductImage, len(productIDs))
+	for _, id := range productIDs {
+		if images := r.images[id]; len(images) > 0 {
+			result[id] = slices.Clone(images)
+		}
+	}
+	return result, nil
+}
+
+// deleteProducts удаляет файлы окончательно удаленных продуктов.
+func (r *MemoryProductImageRepository) deleteProducts(productIDs []int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range productIDs {
+		delete(r.images, id)
+	}
+}
+
+func (r *MemoryProductImageRepository) index(productID, id int64) int {
+	return slices.IndexFunc(r.images[productID], func(image model.ProductImage) bool {
+		return image.ID == id
+	})
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryProductImportJobRepository хранит задачи импорта в памяти процесса.
+type MemoryProductImportJobRepository struct {
+	mu     sync.RWMutex
+	jobs   map[int64]model.ProductImportJob
+	nextID int64
+}
+
+func NewMemoryProductImportJobRepository() *MemoryProductImportJobRepository {
+	return &MemoryProductImportJobRepository{
+		jobs:   make(map[int64]model.ProductImportJob),
+		nextID: 1,
+	}
+}
+
+func (r *MemoryProductImportJobRepository) Create(ctx context.Context, job *model.ProductImportJob) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	job.ID = r.nextID
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	r.nextID++
+
+	r.jobs[job.ID] = cloneImportJob(job)
+	return nil
+}
+
+func (r *MemoryProductImportJobRepository) GetByID(ctx context.Context, id int64) (*model.ProductImportJob, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, ErrImportJobNotFound
+	}
+	job = cloneImportJob(&job)
+	return &job, nil
+}
+
+func (r *MemoryProductImportJobRepository) Update(ctx context.Context, job *model.ProductImportJob) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[job.ID]; !ok {
+		return ErrImportJobNotFound
+	}
+	job.UpdatedAt = time.Now().UTC()
+	r.jobs[job.ID] = cloneImportJob(job)
+	return nil
+}
+
+// cloneImportJob отвязывает сохраненную копию от задачи, которую продолжает
+// заполнять импорт.
+func cloneImportJob(job *model.ProductImportJob) model.ProductImportJob {
+	clone := *job
+	clone.RowErrors = slices.Clone(job.RowErrors)
+	if clone.RowErrors == nil {
+		clone.RowErrors = []model.ImportRowError{}
+	}
+	return clone
+}
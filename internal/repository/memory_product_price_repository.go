@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryProductPriceRepository хранит цены продуктов в других валютах в
+// памяти процесса. MemoryProductRepository стирает цены продуктов при Purge.
+type MemoryProductPriceRepository struct {
+	mu sync.RWMutex
+	// Цены каждого продукта по коду валюты
+	prices map[int64]map[string]model.ProductPrice
+}
+
+func NewMemoryProductPriceRepository() *MemoryProductPriceRepository {
+	return &MemoryProductPriceRepository{
+		prices: make(map[int64]map[string]model.ProductPrice),
+	}
+}
+
+func (r *MemoryProductPriceRepository) Set(ctx context.Context, price *model.ProductPrice) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	price.CreatedAt, price.UpdatedAt = now, now
+	if existing, ok := r.prices[price.ProductID][price.Currency]; ok {
+		price.CreatedAt = existing.CreatedAt
+	}
+
+	if r.prices[price.ProductID] == nil {
+		r.prices[price.ProductID] = make(map[string]model.ProductPrice)
+	}
+	r.prices[price.ProductID][price.Currency] = *price
+	return nil
+}
+
+func (r *MemoryProductPriceRepository) Delete(ctx context.Context, productID int64, currency string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.prices[productID][currency]; !ok {
+		return ErrProductPriceNotFound
+	}
+	delete(r.prices[productID], currency)
+	if len(r.prices[productID]) == 0 {
+		delete(r.prices, productID)
+	}
+	return nil
+}
+
+func (r *MemoryProductPriceRepository) ListByProducts(ctx context.Context, productIDs []int64) (map[int64][]model.ProductPrice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[int64][]model.ProductPrice, len(productIDs))
+	for _, id := range productIDs {
+		if len(r.prices[id]) == 0 {
+			continue
+		}
+		list := make([]model.ProductPrice, 0, len(r.prices[id]))
+		for _, price := range r.prices[id] {
+			list = append(list, price)
+		}
+		slices.SortFunc(list, func(a, b model.ProductPrice) int { return strings.Compare(a.Currency, b.Currency) })
+		result[id] = list
+	}
+	return result, nil
+}
+
+// deleteProducts удаляет цены окончательно удаленных продуктов.
+func (r *MemoryProductPriceRepository) deleteProducts(productIDs []int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range productIDs {
+		delete(r.prices, id)
+	}
+}
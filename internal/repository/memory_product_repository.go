@@ -0,0 +1,663 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"demo-service/internal/model"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryProductRepository хранит продукты в памяти процесса.
+// Используется при STORAGE_BACKEND=memory для локальной разработки и тестов.
+type MemoryProductRepository struct {
+	mu         sync.RWMutex
+	products   map[int64]model.Product
+	nextID     int64
+	categories *MemoryCategoryRepository
+	images     *MemoryProductImageRepository
+	prices     *MemoryProductPriceRepository
+	// Резервы стираются вместе с продуктами, как ON DELETE CASCADE
+	reservations *MemoryStockReservationRepository
+}
+
+func NewMemoryProductRepository(categories *MemoryCategoryRepository, images *MemoryProductImageRepository, prices *MemoryProductPriceRepository, reservations *MemoryStockReservationRepository) *MemoryProductRepository {
+	return &MemoryProductRepository{
+		products:     make(map[int64]model.Product),
+		nextID:       1,
+		categories:   categories,
+		images:       images,
+		prices:       prices,
+		reservations: reservations,
+	}
+}
+
+func (r *MemoryProductRepository) Create(ctx context.Context, product *model.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.skuTaken(product.SKU, 0) {
+		return ErrSKUExists
+	}
+
+	r.insert(product)
+	return nil
+}
+
+// insert сохраняет новый продукт. Вызывается под r.mu.
+func (r *MemoryProductRepository) insert(product *model.Product) {
+	now := time.Now().UTC()
+	product.ID = r.nextID
+	product.Version = 1
+	product.CreatedAt = now
+	product.UpdatedAt = now
+	r.nextID++
+
+	r.products[product.ID] = *product
+}
+
+func (r *MemoryProductRepository) GetByID(ctx context.Context, id int64) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return nil, ErrProductNotFound
+	}
+	return &product, nil
+}
+
+func (r *MemoryProductRepository) GetBySKU(ctx context.Context, sku string) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, product := range r.products {
+		if product.DeletedAt == nil && product.SKU != nil && *product.SKU == sku {
+			return &product, nil
+		}
+	}
+	return nil, ErrProductNotFound
+}
+
+func (r *MemoryProductRepository) List(ctx context.Context, opts ProductListOptions) ([]model.Product, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var terms []searchTerm
+	if opts.Query != "" {
+		var err error
+		if terms, err = parseSearchQuery(opts.Query); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var inCategory map[int64]bool
+	if f := opts.Filter; f.CategoryID != nil {
+		inCategory = r.categories.productsIn(*f.CategoryID, f.IncludeSubcategories)
+	}
+
+	// Без явной сортировки результаты поиска идут по релевантности
+	var rank map[int64]int
+	if terms != nil && len(opts.Sort) == 0 {
+		rank = make(map[int64]int)
+	}
+
+	r.mu.RLock()
+	products := make([]model.Product, 0, len(r.products))
+	for _, product := range r.products {
+		if product.DeletedAt != nil && !opts.IncludeDeleted {
+			continue
+		}
+		if !matchProductFilter(&product, opts.Filter) || (inCategory != nil && !inCategory[product.ID]) {
+			continue
+		}
+		if terms != nil {
+			if !matchSearchTerms(terms, product.Name, product.Description) {
+				continue
+			}
+			product.Highlight = &model.ProductHighlight{
+				Name:        highlightSearchTerms(terms, product.Name),
+				Description: highlightSearchTerms(terms, product.Description),
+			}
+			if rank != nil {
+				rank[product.ID] = searchRank(terms, product.Name, product.Description)
+			}
+		}
+		products = append(products, product)
+	}
+	r.mu.RUnlock()
+
+	// Тот же порядок, что и ORDER BY в SQL-репозитории (см. orderBySQL)
+	sortFields := EffectiveProductSort(opts.Sort)
+	compare := func(a, b *model.Product) int {
+		if c := rank[b.ID] - rank[a.ID]; c != 0 {
+			return c
+		}
+		for _, field := range sortFields {
+			if c := compareProductField(a, b, field.Field); c != 0 {
+				if field.Desc {
+					return -c
+				}
+				return c
+			}
+		}
+		return 0
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return compare(&products[i], &products[j]) < 0
+	})
+
+	total := len(products)
+	if opts.SkipTotal {
+		total = 0
+	}
+
+	if opts.Keyset != nil {
+		after := &opts.Keyset.After
+		if opts.Keyset.Backward {
+			// Последние Limit строк перед границей
+			end := sort.Search(len(products), func(i int) bool {
+				return compare(&products[i], after) >= 0
+			})
+			start := max(end-opts.Limit, 0)
+			return products[start:end], total, nil
+		}
+		start := sort.Search(len(products), func(i int) bool {
+			return compare(&products[i], after) > 0
+		})
+		end := min(start+opts.Limit, len(products))
+		return products[start:end], total, nil
+	}
+
+	offset := opts.Offset
+	if offset >= len(products) {
+		return nil, total, nil
+	}
+
+	end := min(offset+opts.Limit, len(products))
+	return products[offset:end], total, nil
+}
+
+func (r *MemoryProductRepository) Update(ctx context.Context, id int64, updates map[string]interface{}, version int64) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return nil, ErrProductNotFound
+	}
+	if version != 0 && product.Version != version {
+		return nil, ErrVersionMismatch
+	}
+	if len(updates) == 0 {
+		return &product, nil
+	}
+
+	for key, value := range updates {
+		if err := setProductField(&product, key, value); err != nil {
+			return nil, fmt.Errorf("failed to update product: %w", err)
+		}
+	}
+	if r.skuTaken(product.SKU, id) {
+		return nil, ErrSKUExists
+	}
+	if product.Stock < product.Reserved {
+		return nil, ErrStockBelowReserved
+	}
+	product.UpdatedAt = time.Now().UTC()
+	product.Version++
+
+	r.products[id] = product
+	return &product, nil
+}
+
+func (r *MemoryProductRepository) Delete(ctx context.Context, id int64, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return ErrProductNotFound
+	}
+	if version != 0 && product.Version != version {
+		return ErrVersionMismatch
+	}
+
+	now := time.Now().UTC()
+	product.DeletedAt = &now
+	product.UpdatedAt = now
+	product.Version++
+	r.products[id] = product
+	return nil
+}
+
+func (r *MemoryProductRepository) Restore(ctx context.Context, id int64) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt == nil {
+		return nil, ErrProductNotFound
+	}
+	if r.skuTaken(product.SKU, id) {
+		return nil, ErrSKUExists
+	}
+
+	product.DeletedAt = nil
+	product.UpdatedAt = time.Now().UTC()
+	product.Version++
+	r.products[id] = product
+	return &product, nil
+}
+
+func (r *MemoryProductRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	var purged []int64
+	for id, product := range r.products {
+		if product.DeletedAt != nil && product.DeletedAt.Before(deletedBefore) {
+			delete(r.products, id)
+			purged = append(purged, id)
+		}
+	}
+	r.mu.Unlock()
+
+	// Как ON DELETE CASCADE у product_categories, product_images, product_prices
+	// и stock_reservations
+	r.categories.unlinkProducts(purged)
+	r.images.deleteProducts(purged)
+	r.prices.deleteProducts(purged)
+	r.reservations.deleteProducts(purged)
+	return purged, nil
+}
+
+// CreateMany, как многострочный INSERT, не создает ни одного продукта,
+// если SKU одного из них занят.
+func (r *MemoryProductRepository) CreateMany(ctx context.Context, products []*model.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, product := range products {
+		if r.skuTaken(product.SKU, 0) {
+			return ErrSKUExists
+		}
+	}
+	for _, product := range products {
+		r.insert(product)
+	}
+	return nil
+}
+
+func (r *MemoryProductRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make(map[int64]*model.Product, len(ids))
+	for _, id := range ids {
+		if product, ok := r.products[id]; ok && product.DeletedAt == nil {
+			products[id] = &product
+		}
+	}
+	return products, nil
+}
+
+func (r *MemoryProductRepository) GetByNames(ctx context.Context, names []string) ([]model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var products []model.Product
+	for _, product := range r.products {
+		if product.DeletedAt == nil && slices.Contains(names, product.Name) {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (r *MemoryProductRepository) GetBySKUs(ctx context.Context, skus []string) ([]model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var products []model.Product
+	for _, product := range r.products {
+		if product.DeletedAt == nil && product.SKU != nil && slices.Contains(skus, *product.SKU) {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+// Stream выдает снимок списка, отсортированный как в List.
+func (r *MemoryProductRepository) Stream(ctx context.Context, opts ProductListOptions, fn func(product *model.Product) error) error {
+	opts.Query = ""
+	opts.Keyset = nil
+	opts.Offset = 0
+	opts.Limit = math.MaxInt
+
+	products, _, err := r.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if err := fn(&products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryProductRepository) UpdateMany(ctx context.Context, updates []ProductUpdate) ([]model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range updates {
+		if u.SKU != nil && *u.SKU != "" && r.skuTaken(u.SKU, u.ID) {
+			return nil, ErrSKUExists
+		}
+	}
+
+	now := time.Now().UTC()
+	var updated []model.Product
+	for _, u := range updates {
+		product, ok := r.products[u.ID]
+		if !ok || product.DeletedAt != nil || (u.Version != 0 && product.Version != u.Version) {
+			continue
+		}
+		if u.Stock != nil && *u.Stock < product.Reserved {
+			continue
+		}
+		if u.Name != nil {
+			product.Name = *u.Name
+		}
+		if u.Description != nil {
+			product.Description = *u.Description
+		}
+		if u.PriceMinor != nil {
+			product.PriceMinor = *u.PriceMinor
+		}
+		if u.Currency != nil {
+			product.Currency = *u.Currency
+		}
+		if u.Stock != nil {
+			product.Stock = *u.Stock
+		}
+		if u.SKU != nil {
+			product.SKU = nil
+			if *u.SKU != "" {
+				sku := *u.SKU
+				product.SKU = &sku
+			}
+		}
+		product.UpdatedAt = now
+		product.Version++
+
+		r.products[u.ID] = product
+		updated = append(updated, product)
+	}
+	return updated, nil
+}
+
+func (r *MemoryProductRepository) DeleteMany(ctx context.Context, refs []ProductRef) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	var deleted []int64
+	for _, ref := range refs {
+		product, ok := r.products[ref.ID]
+		if !ok || product.DeletedAt != nil || (ref.Version != 0 && product.Version != ref.Version) {
+			continue
+		}
+		product.DeletedAt = &now
+		product.UpdatedAt = now
+		product.Version++
+
+		r.products[ref.ID] = product
+		deleted = append(deleted, ref.ID)
+	}
+	return deleted, nil
+}
+
+func (r *MemoryProductRepository) Reserve(ctx context.Context, id int64, quantity int) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return nil, ErrProductNotFound
+	}
+	if product.Stock-product.Reserved < quantity {
+		return nil, ErrInsufficientStock
+	}
+
+	product.Reserved += quantity
+	r.products[id] = product
+	return &product, nil
+}
+
+func (r *MemoryProductRepository) Unreserve(ctx context.Context, id int64, quantity int) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[id]
+	if !ok {
+		return nil, ErrProductNotFound
+	}
+	if product.Reserved < quantity {
+		return nil, fmt.Errorf("failed to release stock: product %d has %d units reserved", id, product.Reserved)
+	}
+
+	product.Reserved -= quantity
+	r.products[id] = product
+	return &product, nil
+}
+
+func (r *MemoryProductRepository) CommitReserved(ctx context.Context, id int64, quantity int) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return nil, ErrProductNotFound
+	}
+	if product.Reserved < quantity {
+		return nil, fmt.Errorf("failed to commit reserved stock: product %d has %d units reserved", id, product.Reserved)
+	}
+
+	product.Stock -= quantity
+	product.Reserved -= quantity
+	product.UpdatedAt = time.Now().UTC()
+	product.Version++
+	r.products[id] = product
+	return &product, nil
+}
+
+func (r *MemoryProductRepository) AdjustStock(ctx context.Context, id int64, delta int) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return nil, ErrProductNotFound
+	}
+	if product.Stock+delta < product.Reserved {
+		return nil, ErrInsufficientStock
+	}
+
+	product.Stock += delta
+	product.UpdatedAt = time.Now().UTC()
+	product.Version++
+	r.products[id] = product
+	return &product, nil
+}
+
+func (r *MemoryProductRepository) Suggest(ctx context.Context, prefix string, limit int) ([]model.ProductSuggestion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	candidates := make([]model.ProductSuggestion, 0, len(r.products))
+	for _, product := range r.products {
+		if product.DeletedAt == nil {
+			candidates = append(candidates, model.ProductSuggestion{ID: product.ID, Name: product.Name})
+		}
+	}
+	r.mu.RUnlock()
+
+	return rankSuggestions(prefix, candidates, limit), nil
+}
+
+// skuTaken сообщает, занят ли sku неудаленным продуктом, кроме exceptID.
+// Вызывается под r.mu.
+func (r *MemoryProductRepository) skuTaken(sku *string, exceptID int64) bool {
+	if sku == nil {
+		return false
+	}
+	for id, product := range r.products {
+		if id != exceptID && product.DeletedAt == nil && product.SKU != nil && *product.SKU == *sku {
+			return true
+		}
+	}
+	return false
+}
+
+// matchProductFilter проверяет поля продукта; фильтр по категории List
+// применяет отдельно, по связям из MemoryCategoryRepository.
+func matchProductFilter(product *model.Product, f ProductFilter) bool {
+	switch {
+	case f.Currency != nil && product.Currency != *f.Currency,
+		f.MinPrice != nil && product.PriceMinor < *f.MinPrice,
+		f.MaxPrice != nil && product.PriceMinor > *f.MaxPrice,
+		f.MinStock != nil && product.Stock < *f.MinStock,
+		f.MaxStock != nil && product.Stock > *f.MaxStock,
+		f.InStock != nil && (product.Stock > 0) != *f.InStock,
+		f.CreatedAfter != nil && !product.CreatedAt.After(*f.CreatedAfter),
+		f.CreatedBefore != nil && !product.CreatedAt.Before(*f.CreatedBefore),
+		f.UpdatedSince != nil && product.UpdatedAt.Before(*f.UpdatedSince):
+		return false
+	}
+	return true
+}
+
+func compareProductField(a, b *model.Product, field string) int {
+	switch field {
+	case "id":
+		return cmp.Compare(a.ID, b.ID)
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "price":
+		return cmp.Compare(a.PriceMinor, b.PriceMinor)
+	case "stock":
+		return cmp.Compare(a.Stock, b.Stock)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	return 0
+}
+
+func setProductField(product *model.Product, key string, value interface{}) error {
+	var ok bool
+	switch key {
+	case "name":
+		product.Name, ok = value.(string)
+	case "description":
+		product.Description, ok = value.(string)
+	case "price_minor":
+		product.PriceMinor, ok = value.(int64)
+	case "currency":
+		product.Currency, ok = value.(string)
+	case "stock":
+		product.Stock, ok = value.(int)
+	case "sku":
+		// nil удаляет SKU
+		var sku string
+		if sku, ok = value.(string); ok {
+			product.SKU = &sku
+		} else if ok = value == nil; ok {
+			product.SKU = nil
+		}
+	default:
+		return fmt.Errorf("unknown column %q", key)
+	}
+
+	if !ok {
+		return fmt.Errorf("invalid value %v for column %q", value, key)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"sync"
+	"time"
+)
+
+// MemoryStockMovementRepository хранит журнал остатков в памяти процесса.
+type MemoryStockMovementRepository struct {
+	mu        sync.RWMutex
+	movements []model.StockMovement
+	nextID    int64
+}
+
+func NewMemoryStockMovementRepository() *MemoryStockMovementRepository {
+	return &MemoryStockMovementRepository{nextID: 1}
+}
+
+func (r *MemoryStockMovementRepository) Add(ctx context.Context, movement *model.StockMovement) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	movement.ID = r.nextID
+	movement.CreatedAt = time.Now().UTC()
+	r.nextID++
+
+	r.movements = append(r.movements, *movement)
+	return nil
+}
+
+func (r *MemoryStockMovementRepository) AddMany(ctx context.Context, movements []*model.StockMovement) error {
+	for _, movement := range movements {
+		if err := r.Add(ctx, movement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryStockMovementRepository) List(ctx context.Context, productID int64, page, limit int) ([]model.StockMovement, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Новые движения первыми, как ORDER BY id DESC
+	var movements []model.StockMovement
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		if r.movements[i].ProductID == productID {
+			movements = append(movements, r.movements[i])
+		}
+	}
+
+	total := len(movements)
+	offset := (page - 1) * limit
+	if offset >= total {
+		return nil, total, nil
+	}
+
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return movements[offset:end], total, nil
+}
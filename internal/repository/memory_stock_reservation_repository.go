@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStockReservationRepository хранит резервы остатков в памяти
+// процесса. MemoryProductRepository стирает резервы продуктов при Purge.
+type MemoryStockReservationRepository struct {
+	mu           sync.RWMutex
+	reservations map[int64]model.StockReservation
+	nextID       int64
+}
+
+func NewMemoryStockReservationRepository() *MemoryStockReservationRepository {
+	return &MemoryStockReservationRepository{
+		reservations: make(map[int64]model.StockReservation),
+		nextID:       1,
+	}
+}
+
+func (r *MemoryStockReservationRepository) Create(ctx context.Context, reservation *model.StockReservation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	reservation.ID = r.nextID
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+	r.nextID++
+
+	r.reservations[reservation.ID] = *reservation
+	return nil
+}
+
+func (r *MemoryStockReservationRepository) GetByID(ctx context.Context, id int64) (*model.StockReservation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reservation, ok := r.reservations[id]
+	if !ok {
+		return nil, ErrReservationNotFound
+	}
+	return &reservation, nil
+}
+
+func (r *MemoryStockReservationRepository) Finish(ctx context.Context, id int64, status string) (*model.StockReservation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reservation, ok := r.reservations[id]
+	if !ok {
+		return nil, ErrReservationNotFound
+	}
+	if reservation.Status != model.ReservationStatusActive {
+		return nil, ErrReservationNotActive
+	}
+
+	reservation.Status = status
+	reservation.UpdatedAt = time.Now().UTC()
+	r.reservations[id] = reservation
+	return &reservation, nil
+}
+
+func (r *MemoryStockReservationRepository) Expire(ctx context.Context, now time.Time, limit int) ([]model.StockReservation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []model.StockReservation
+	for _, reservation := range r.reservations {
+		if reservation.Status == model.ReservationStatusActive && !reservation.ExpiresAt.After(now) {
+			expired = append(expired, reservation)
+		}
+	}
+	slices.SortFunc(expired, func(a, b model.StockReservation) int { return a.ExpiresAt.Compare(b.ExpiresAt) })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	updatedAt := time.Now().UTC()
+	for i := range expired {
+		expired[i].Status = model.ReservationStatusExpired
+		expired[i].UpdatedAt = updatedAt
+		r.reservations[expired[i].ID] = expired[i]
+	}
+	return expired, nil
+}
+
+// deleteProducts удаляет резервы окончательно удаленных продуктов.
+func (r *MemoryStockReservationRepository) deleteProducts(productIDs []int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, reservation := range r.reservations {
+		if slices.Contains(productIDs, reservation.ProductID) {
+			delete(r.reservations, id)
+		}
+	}
+}
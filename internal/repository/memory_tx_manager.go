@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTxManager - TxManager для STORAGE_BACKEND=memory. Блокирует
+// транзакционные блоки друг от друга, но не откатывает изменения при ошибке.
+type MemoryTxManager struct {
+	mu sync.Mutex
+}
+
+type memoryTxKey struct{}
+
+func NewMemoryTxManager() *MemoryTxManager {
+	return &MemoryTxManager{}
+}
+
+func (m *MemoryTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(memoryTxKey{}) != nil {
+		return fn(ctx)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return fn(context.WithValue(ctx, memoryTxKey{}, struct{}{}))
+}
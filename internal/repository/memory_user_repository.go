@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository хранит пользователей в памяти процесса.
+type MemoryUserRepository struct {
+	mu         sync.RWMutex
+	users      map[int64]model.User
+	byUsername map[string]int64
+	nextID     int64
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:      make(map[int64]model.User),
+		byUsername: make(map[string]int64),
+		nextID:     1,
+	}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Аналог UNIQUE-ограничения на users.username
+	if _, exists := r.byUsername[user.Username]; exists {
+		return ErrUserExists
+	}
+
+	user.ID = r.nextID
+	user.CreatedAt = time.Now().UTC()
+	r.nextID++
+
+	r.users[user.ID] = *user
+	r.byUsername[user.Username] = user.ID
+	return nil
+}
+
+func (r *MemoryUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byUsername[username]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	user := r.users[id]
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) Exists(ctx context.Context, username string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.byUsername[username]
+	return ok, nil
+}
+
+func (r *MemoryUserRepository) SetAdmin(ctx context.Context, username string, isAdmin bool) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.byUsername[username]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	user := r.users[id]
+	user.IsAdmin = isAdmin
+	r.users[id] = user
+	return &user, nil
+}
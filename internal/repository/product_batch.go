@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Строк в одном многострочном запросе: держит число параметров далеко
+// от лимитов драйверов (65535 в PostgreSQL, 32766 в SQLite)
+const batchChunkRows = 500
+
+// chunks делит items на части не длиннее size.
+func chunks[T any](items []T, size int) [][]T {
+	var parts [][]T
+	for len(items) > size {
+		parts = append(parts, items[:size])
+		items = items[size:]
+	}
+	if len(items) > 0 {
+		parts = append(parts, items)
+	}
+	return parts
+}
+
+// valuesSQL повторяет кортеж row для rows строк: "(?, ?), (?, ?)".
+func valuesSQL(row string, rows int) string {
+	return strings.TrimSuffix(strings.Repeat(row+", ", rows), ", ")
+}
+
+// CreateMany вставляет продукты одним INSERT на каждые batchChunkRows строк.
+func (r *ProductRepository) CreateMany(ctx context.Context, products []*model.Product) error {
+	defer observeQuery("product.create_many")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	q := database.QuerierFrom(ctx, r.db)
+	for _, chunk := range chunks(products, batchChunkRows) {
+		args := make([]interface{}, 0, len(chunk)*6)
+		for _, product := range chunk {
+			args = append(args, product.Name, product.Description, product.PriceMinor, product.Currency, product.Stock, product.SKU)
+		}
+
+		query := `INSERT INTO products (name, description, price_minor, currency, stock, sku) VALUES ` + valuesSQL("(?, ?, ?, ?, ?, ?)", len(chunk)) +
+			` RETURNING id, version, created_at, updated_at`
+		rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+		if err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return ErrSKUExists
+			}
+			return fmt.Errorf("failed to create products: %w", queryError(ctx, err))
+		}
+
+		// Порядок RETURNING не гарантирован, но id выдаются по порядку VALUES
+		created := make([]model.Product, 0, len(chunk))
+		for rows.Next() {
+			var product model.Product
+			if err := rows.Scan(&product.ID, &product.Version, &product.CreatedAt, &product.UpdatedAt); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan product: %w", err)
+			}
+			created = append(created, product)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return ErrSKUExists
+			}
+			return fmt.Errorf("failed to create products: %w", queryError(ctx, err))
+		}
+		if len(created) != len(chunk) {
+			return fmt.Errorf("failed to create products: inserted %d of %d", len(created), len(chunk))
+		}
+
+		slices.SortFunc(created, func(a, b model.Product) int { return cmp.Compare(a.ID, b.ID) })
+		for i, product := range chunk {
+			product.ID = created[i].ID
+			product.Version = created[i].Version
+			product.CreatedAt = created[i].CreatedAt
+			product.UpdatedAt = created[i].UpdatedAt
+		}
+	}
+	return nil
+}
+
+// GetByIDs возвращает неудаленные продукты по id; отсутствующих нет в результате.
+func (r *ProductRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.Product, error) {
+	defer observeQuery("product.get_by_ids")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	products := make(map[int64]*model.Product, len(ids))
+	for _, chunk := range chunks(ids, batchChunkRows) {
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		query := `SELECT ` + productColumns + ` FROM products
+		          WHERE id IN (` + valuesSQL("?", len(chunk)) + `) AND deleted_at IS NULL`
+		err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+			rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+			if err != nil {
+				return fmt.Errorf("failed to get products: %w", err)
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				product := &model.Product{}
+				if err := scanProduct(rows, product); err != nil {
+					return fmt.Errorf("failed to scan product: %w", err)
+				}
+				products[product.ID] = product
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			return nil, queryError(ctx, err)
+		}
+	}
+	return products, nil
+}
+
+// GetByNames возвращает неудаленные продукты с указанными названиями.
+func (r *ProductRepository) GetByNames(ctx context.Context, names []string) ([]model.Product, error) {
+	defer observeQuery("product.get_by_names")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var products []model.Product
+	for _, chunk := range chunks(names, batchChunkRows) {
+		args := make([]interface{}, len(chunk))
+		for i, name := range chunk {
+			args[i] = name
+		}
+
+		query := `SELECT ` + productColumns + ` FROM products
+		          WHERE name IN (` + valuesSQL("?", len(chunk)) + `) AND deleted_at IS NULL`
+		var found []model.Product
+		err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+			found = nil
+			rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+			if err != nil {
+				return fmt.Errorf("failed to get products: %w", err)
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var product model.Product
+				if err := scanProduct(rows, &product); err != nil {
+					return fmt.Errorf("failed to scan product: %w", err)
+				}
+				found = append(found, product)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			return nil, queryError(ctx, err)
+		}
+		products = append(products, found...)
+	}
+	return products, nil
+}
+
+// GetBySKUs возвращает неудаленные продукты с указанными SKU.
+func (r *ProductRepository) GetBySKUs(ctx context.Context, skus []string) ([]model.Product, error) {
+	defer observeQuery("product.get_by_skus")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var products []model.Product
+	for _, chunk := range chunks(skus, batchChunkRows) {
+		args := make([]interface{}, len(chunk))
+		for i, sku := range chunk {
+			args[i] = sku
+		}
+
+		query := `SELECT ` + productColumns + ` FROM products
+		          WHERE sku IN (` + valuesSQL("?", len(chunk)) + `) AND deleted_at IS NULL`
+		var found []model.Product
+		err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+			found = nil
+			rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+			if err != nil {
+				return fmt.Errorf("failed to get products: %w", err)
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var product model.Product
+				if err := scanProduct(rows, &product); err != nil {
+					return fmt.Errorf("failed to scan product: %w", err)
+				}
+				found = append(found, product)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			return nil, queryError(ctx, err)
+		}
+		products = append(products, found...)
+	}
+	return products, nil
+}
+
+// UpdateMany применяет изменения через UPDATE ... FROM (VALUES ...).
+// Колонки VALUES называются column1..columnN в обоих диалектах; CAST нужен
+// PostgreSQL, чтобы вывести типы параметров со значением NULL. SKU можно
+// удалить, поэтому его изменение отмечается отдельным флагом (column9).
+func (r *ProductRepository) UpdateMany(ctx context.Context, updates []ProductUpdate) ([]model.Product, error) {
+	defer observeQuery("product.update_many")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	const row = `(CAST(? AS BIGINT), CAST(? AS BIGINT), CAST(? AS TEXT), CAST(? AS TEXT), CAST(? AS BIGINT), CAST(? AS TEXT), CAST(? AS INTEGER), CAST(? AS TEXT), CAST(? AS INTEGER))`
+
+	q := database.QuerierFrom(ctx, r.db)
+	var updated []model.Product
+	for _, chunk := range chunks(updates, batchChunkRows) {
+		args := make([]interface{}, 0, len(chunk)*9)
+		for _, u := range chunk {
+			var sku *string
+			setSKU := 0
+			if u.SKU != nil {
+				setSKU = 1
+				if *u.SKU != "" {
+					sku = u.SKU
+				}
+			}
+			args = append(args, u.ID, u.Version, u.Name, u.Description, u.PriceMinor, u.Currency, u.Stock, sku, setSKU)
+		}
+
+		query := `UPDATE products SET
+		              name = COALESCE(v.column3, products.name),
+		              description = COALESCE(v.column4, products.description),
+		              price_minor = COALESCE(v.column5, products.price_minor),
+		              currency = COALESCE(v.column6, products.currency),
+		              stock = COALESCE(v.column7, products.stock),
+		              sku = CASE WHEN v.column9 = 1 THEN v.column8 ELSE products.sku END,
+		              updated_at = CURRENT_TIMESTAMP,
+		              version = products.version + 1
+		          FROM (VALUES ` + valuesSQL(row, len(chunk)) + `) AS v
+		          WHERE products.id = v.column1 AND products.deleted_at IS NULL
+		            AND (v.column2 = 0 OR products.version = v.column2)
+		            AND (v.column7 IS NULL OR v.column7 >= products.reserved)
+		          RETURNING ` + qualifyColumns(productColumns, "products")
+		rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+		if err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return nil, ErrSKUExists
+			}
+			return nil, fmt.Errorf("failed to update products: %w", queryError(ctx, err))
+		}
+		for rows.Next() {
+			var product model.Product
+			if err := scanProduct(rows, &product); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan product: %w", err)
+			}
+			updated = append(updated, product)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return nil, ErrSKUExists
+			}
+			return nil, fmt.Errorf("failed to update products: %w", queryError(ctx, err))
+		}
+	}
+	return updated, nil
+}
+
+// DeleteMany помечает продукты удаленными и возвращает id затронутых строк.
+func (r *ProductRepository) DeleteMany(ctx context.Context, refs []ProductRef) ([]int64, error) {
+	defer observeQuery("product.delete_many")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	const row = `(CAST(? AS BIGINT), CAST(? AS BIGINT))`
+
+	q := database.QuerierFrom(ctx, r.db)
+	var deleted []int64
+	for _, chunk := range chunks(refs, batchChunkRows) {
+		args := make([]interface{}, 0, len(chunk)*2)
+		for _, ref := range chunk {
+			args = append(args, ref.ID, ref.Version)
+		}
+
+		query := `UPDATE products SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP,
+		              version = products.version + 1
+		          FROM (VALUES ` + valuesSQL(row, len(chunk)) + `) AS v
+		          WHERE products.id = v.column1 AND products.deleted_at IS NULL
+		            AND (v.column2 = 0 OR products.version = v.column2)
+		          RETURNING products.id`
+		rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete products: %w", queryError(ctx, err))
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan product id: %w", err)
+			}
+			deleted = append(deleted, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to delete products: %w", queryError(ctx, err))
+		}
+	}
+	return deleted, nil
+}
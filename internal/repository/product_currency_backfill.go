@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Миграция, которая перевела цены в минимальные единицы. Валюта старых цен
+// известна только конфигурации, поэтому миграция оставляет ее пустой, а
+// назначает шаг currencyBackfill.
+const currencyBackfillVersion = 13
+
+// MigrationHooks возвращает шаги миграций на Go: товары, перенесенные
+// миграцией 0013, получают currency (PRODUCT_DEFAULT_CURRENCY).
+func MigrationHooks(currency string) (map[int64]database.MigrationHook, error) {
+	exponent, ok := model.CurrencyExponent(currency)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ISO 4217 currency code", currency)
+	}
+	return map[int64]database.MigrationHook{
+		currencyBackfillVersion: currencyBackfill(currency, exponent),
+	}, nil
+}
+
+// currencyBackfill назначает валюту товарам с пустой currency. Миграция
+// хранит цены в сотых долях, для валют с другим числом знаков они
+// пересчитываются (1500.50 JPY округляется до 1501).
+func currencyBackfill(currency string, exponent int) database.MigrationHook {
+	priceMinor := "price_minor"
+	switch {
+	case exponent > 2:
+		priceMinor = fmt.Sprintf("price_minor * %d", pow10(exponent-2))
+	case exponent < 2:
+		priceMinor = fmt.Sprintf("CAST(ROUND(price_minor / %d.0) AS BIGINT)", pow10(2-exponent))
+	}
+
+	return func(ctx context.Context, tx *sql.Tx, dialect database.Dialect) error {
+		query := dialect.Rebind(`UPDATE products SET currency = ?, price_minor = ` + priceMinor + ` WHERE currency = ''`)
+		result, err := tx.ExecContext(ctx, query, currency)
+		if err != nil {
+			return fmt.Errorf("failed to backfill product currency: %w", err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n > 0 {
+			logrus.Infof("Assigned currency %s to %d products migrated without one", currency, n)
+		}
+		return nil
+	}
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for ; n > 0; n-- {
+		p *= 10
+	}
+	return p
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"demo-service/internal/database"
+	"path/filepath"
+	"testing"
+)
+
+func TestCurrencyBackfill(t *testing.T) {
+	ctx := context.Background()
+	if err := database.Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), database.PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1}); err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		database.DB = nil
+	})
+	migrator, err := database.NewMigrator(database.DB, database.CurrentDialect)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	hooks, err := MigrationHooks("JPY")
+	if err != nil {
+		t.Fatalf("migration hooks: %v", err)
+	}
+	for version, hook := range hooks {
+		migrator.AfterUp(version, hook)
+	}
+	if _, err := MigrationHooks("XXX"); err == nil {
+		t.Fatal("MigrationHooks accepted an unknown currency")
+	}
+
+	// Цены до 0013 хранятся десятичным числом без валюты
+	if _, _, err := migrator.Goto(ctx, currencyBackfillVersion-1); err != nil {
+		t.Fatalf("Goto %d: %v", currencyBackfillVersion-1, err)
+	}
+	if _, err := database.DB.Exec(`INSERT INTO products (name, description, price, stock) VALUES ('Kettle', '', 1500.5, 1), ('Cup', '', 19.99, 1)`); err != nil {
+		t.Fatalf("insert products: %v", err)
+	}
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	want := map[string]struct {
+		priceMinor int64
+		currency   string
+	}{
+		"Kettle": {1501, "JPY"},
+		"Cup":    {20, "JPY"},
+	}
+	rows, err := database.DB.Query(`SELECT name, price_minor, currency FROM products`)
+	if err != nil {
+		t.Fatalf("select products: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			name       string
+			priceMinor int64
+			currency   string
+		)
+		if err := rows.Scan(&name, &priceMinor, &currency); err != nil {
+			t.Fatalf("scan product: %v", err)
+		}
+		if got := want[name]; got.priceMinor != priceMinor || got.currency != currency {
+			t.Errorf("%s: %d %s, want %d %s", name, priceMinor, currency, got.priceMinor, got.currency)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("iterate products: %v", err)
+	}
+}
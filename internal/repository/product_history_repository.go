@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const productHistoryColumns = `id, product_id, version, actor_id, operation, changes, created_at`
+
+type ProductHistoryRepository struct {
+	db       *sql.DB
+	replicas *database.ReplicaSet
+	dialect  database.Dialect
+}
+
+func NewProductHistoryRepository() *ProductHistoryRepository {
+	return &ProductHistoryRepository{
+		db:       database.DB,
+		replicas: database.Replicas,
+		dialect:  database.CurrentDialect,
+	}
+}
+
+func scanProductHistoryEntry(row rowScanner, entry *model.ProductHistoryEntry) error {
+	var (
+		actorID sql.NullInt64
+		changes []byte
+	)
+	err := row.Scan(
+		&entry.ID,
+		&entry.ProductID,
+		&entry.Version,
+		&actorID,
+		&entry.Operation,
+		&changes,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if actorID.Valid {
+		entry.ActorID = &actorID.Int64
+	}
+	if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+		return fmt.Errorf("invalid history changes: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductHistoryRepository) Add(ctx context.Context, entry *model.ProductHistoryEntry) error {
+	defer observeQuery("product_history.add")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode history changes: %w", err)
+	}
+
+	query := `INSERT INTO product_history (product_id, version, actor_id, operation, changes, created_at)
+	          VALUES (?, ?, ?, ?, ?, ?) RETURNING id, created_at`
+	err = database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), entry.ProductID, entry.Version, entry.ActorID, entry.Operation, string(changes), r.createdAt(entry)).
+		Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add product history: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+// AddMany записывает историю пакетной операции многострочными INSERT.
+func (r *ProductHistoryRepository) AddMany(ctx context.Context, entries []*model.ProductHistoryEntry) error {
+	defer observeQuery("product_history.add_many")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	q := database.QuerierFrom(ctx, r.db)
+	for _, chunk := range chunks(entries, batchChunkRows) {
+		args := make([]interface{}, 0, len(chunk)*6)
+		for _, entry := range chunk {
+			changes, err := json.Marshal(entry.Changes)
+			if err != nil {
+				return fmt.Errorf("failed to encode history changes: %w", err)
+			}
+			args = append(args, entry.ProductID, entry.Version, entry.ActorID, entry.Operation, string(changes), r.createdAt(entry))
+		}
+
+		query := `INSERT INTO product_history (product_id, version, actor_id, operation, changes, created_at)
+		          VALUES ` + valuesSQL("(?, ?, ?, ?, ?, ?)", len(chunk))
+		if _, err := q.ExecContext(ctx, r.dialect.Rebind(query), args...); err != nil {
+			return fmt.Errorf("failed to add product history: %w", queryError(ctx, err))
+		}
+	}
+	return nil
+}
+
+// createdAt задает время записи явно, как хранилище в памяти:
+// CURRENT_TIMESTAMP в SQLite хранит только секунды, и as_of внутри той же
+// секунды видел бы более поздние изменения.
+func (r *ProductHistoryRepository) createdAt(entry *model.ProductHistoryEntry) interface{} {
+	entry.CreatedAt = time.Now().UTC()
+	return r.dialect.TimeArg(entry.CreatedAt)
+}
+
+func (r *ProductHistoryRepository) List(ctx context.Context, productID int64, page, limit int) ([]model.ProductHistoryEntry, int, error) {
+	defer observeQuery("product_history.list")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	offset := (page - 1) * limit
+
+	var (
+		entries []model.ProductHistoryEntry
+		total   int
+	)
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		countQuery := `SELECT COUNT(*) FROM product_history WHERE product_id = ?`
+		if err := q.QueryRowContext(ctx, r.dialect.Rebind(countQuery), productID).Scan(&total); err != nil {
+			return fmt.Errorf("failed to count product history: %w", err)
+		}
+
+		// Свежие изменения первыми
+		query := `SELECT ` + productHistoryColumns + ` FROM product_history
+		          WHERE product_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`
+		var err error
+		entries, err = r.query(ctx, q, query, productID, limit, offset)
+		return err
+	})
+	if err != nil {
+		return nil, 0, queryError(ctx, err)
+	}
+
+	return entries, total, nil
+}
+
+// ListUntil возвращает изменения продукта, сделанные не позже until, в порядке применения.
+func (r *ProductHistoryRepository) ListUntil(ctx context.Context, productID int64, until time.Time) ([]model.ProductHistoryEntry, error) {
+	defer observeQuery("product_history.list_until")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var entries []model.ProductHistoryEntry
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		query := `SELECT ` + productHistoryColumns + ` FROM product_history
+		          WHERE product_id = ? AND created_at <= ? ORDER BY id`
+		var err error
+		entries, err = r.query(ctx, q, query, productID, r.dialect.TimeArg(until))
+		return err
+	})
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	return entries, nil
+}
+
+func (r *ProductHistoryRepository) query(ctx context.Context, q database.Querier, query string, args ...interface{}) ([]model.ProductHistoryEntry, error) {
+	rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.ProductHistoryEntry
+	for rows.Next() {
+		var entry model.ProductHistoryEntry
+		if err := scanProductHistoryEntry(rows, &entry); err != nil {
+			return nil, fmt.Errorf("failed to scan product history: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate product history: %w", err)
+	}
+	return entries, nil
+}
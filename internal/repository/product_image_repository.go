@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"errors"
+	"fmt"
+)
+
+const productImageColumns = `id, product_id, filename, content_type, size_bytes, width, height, blob_key, thumbnail_key, created_at`
+
+type ProductImageRepository struct {
+	db       *sql.DB
+	replicas *database.ReplicaSet
+	dialect  database.Dialect
+}
+
+func NewProductImageRepository() *ProductImageRepository {
+	return &ProductImageRepository{
+		db:       database.DB,
+		replicas: database.Replicas,
+		dialect:  database.CurrentDialect,
+	}
+}
+
+func scanProductImage(row rowScanner, image *model.ProductImage) error {
+	return row.Scan(&image.ID, &image.ProductID, &image.Filename, &image.ContentType, &image.Size,
+		&image.Width, &image.Height, &image.BlobKey, &image.ThumbnailKey, &image.CreatedAt)
+}
+
+func (r *ProductImageRepository) Create(ctx context.Context, image *model.ProductImage) error {
+	defer observeQuery("product_image.create")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO product_images (product_id, filename, content_type, size_bytes, width, height, blob_key, thumbnail_key)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at`
+	err := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), image.ProductID, image.Filename, image.ContentType, image.Size,
+			image.Width, image.Height, image.BlobKey, image.ThumbnailKey).
+		Scan(&image.ID, &image.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create product image: %w", queryError(ctx, err))
+	}
+	return nil
+}
+
+func (r *ProductImageRepository) GetByID(ctx context.Context, productID, id int64) (*model.ProductImage, error) {
+	defer observeQuery("product_image.get_by_id")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + productImageColumns + ` FROM product_images WHERE id = ? AND product_id = ?`
+
+	image := &model.ProductImage{}
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		return scanProductImage(q.QueryRowContext(ctx, r.dialect.Rebind(query), id, productID), image)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("failed to get product image: %w", queryError(ctx, err))
+	}
+	return image, nil
+}
+
+func (r *ProductImageRepository) Delete(ctx context.Context, productID, id int64) (*model.ProductImage, error) {
+	defer observeQuery("product_image.delete")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM product_images WHERE id = ? AND product_id = ? RETURNING ` + productImageColumns
+	row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), id, productID)
+
+	image := &model.ProductImage{}
+	if err := scanProductImage(row, image); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("failed to delete product image: %w", queryError(ctx, err))
+	}
+	return image, nil
+}
+
+func (r *ProductImageRepository) ListByProducts(ctx context.Context, productIDs []int64) (map[int64][]model.ProductImage, error) {
+	defer observeQuery("product_image.list_by_products")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result := make(map[int64][]model.ProductImage, len(productIDs))
+	for _, chunk := range chunks(productIDs, batchChunkRows) {
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+		query := `SELECT ` + productImageColumns + ` FROM product_images
+		          WHERE product_id IN (` + valuesSQL("?", len(chunk)) + `) ORDER BY product_id, id`
+
+		err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+			rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+			if err != nil {
+				return fmt.Errorf("failed to list product images: %w", err)
+			}
+			defer rows.Close()
+
+			images := make(map[int64][]model.ProductImage, len(chunk))
+			for rows.Next() {
+				var image model.ProductImage
+				if err := scanProductImage(rows, &image); err != nil {
+					return fmt.Errorf("failed to scan product image: %w", err)
+				}
+				images[image.ProductID] = append(images[image.ProductID], image)
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for id, list := range images {
+				result[id] = list
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, queryError(ctx, err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const productImportJobColumns = `id, status, format, upsert_key, actor_id, total_rows, processed_rows,
+	created_rows, updated_rows, failed_rows, row_errors, error, created_at, updated_at, finished_at`
+
+type ProductImportJobRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func NewProductImportJobRepository() *ProductImportJobRepository {
+	return &ProductImportJobRepository{
+		db:      database.DB,
+		dialect: database.CurrentDialect,
+	}
+}
+
+func (r *ProductImportJobRepository) Create(ctx context.Context, job *model.ProductImportJob) error {
+	defer observeQuery("product_import_job.create")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO product_import_jobs (status, format, upsert_key, actor_id, total_rows)
+	          VALUES (?, ?, ?, ?, ?) RETURNING id, created_at, updated_at`
+	err := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), job.Status, job.Format, job.Key, job.ActorID, job.TotalRows).
+		Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create import job: %w", queryError(ctx, err))
+	}
+	return nil
+}
+
+// GetByID читает задачу с primary: реплика может отставать от хода импорта.
+func (r *ProductImportJobRepository) GetByID(ctx context.Context, id int64) (*model.ProductImportJob, error) {
+	defer observeQuery("product_import_job.get_by_id")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var (
+		job       model.ProductImportJob
+		rowErrors []byte
+	)
+	query := `SELECT ` + productImportJobColumns + ` FROM product_import_jobs WHERE id = ?`
+	err := retryRead(ctx, r.dialect, func() error {
+		return database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), id).Scan(
+			&job.ID, &job.Status, &job.Format, &job.Key, &job.ActorID, &job.TotalRows, &job.ProcessedRows,
+			&job.CreatedRows, &job.UpdatedRows, &job.FailedRows, &rowErrors, &job.Error,
+			&job.CreatedAt, &job.UpdatedAt, &job.FinishedAt,
+		)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImportJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get import job: %w", queryError(ctx, err))
+	}
+
+	if err := json.Unmarshal(rowErrors, &job.RowErrors); err != nil {
+		return nil, fmt.Errorf("invalid import job errors: %w", err)
+	}
+	return &job, nil
+}
+
+// Update сохраняет ход импорта.
+func (r *ProductImportJobRepository) Update(ctx context.Context, job *model.ProductImportJob) error {
+	defer observeQuery("product_import_job.update")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rowErrors, err := json.Marshal(job.RowErrors)
+	if err != nil {
+		return fmt.Errorf("failed to encode import job errors: %w", err)
+	}
+	var finishedAt interface{}
+	if job.FinishedAt != nil {
+		finishedAt = r.dialect.TimeArg(*job.FinishedAt)
+	}
+
+	query := `UPDATE product_import_jobs SET status = ?, total_rows = ?, processed_rows = ?, created_rows = ?,
+	              updated_rows = ?, failed_rows = ?, row_errors = ?, error = ?, finished_at = ?,
+	              updated_at = CURRENT_TIMESTAMP
+	          WHERE id = ? RETURNING updated_at`
+	err = database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query),
+		job.Status, job.TotalRows, job.ProcessedRows, job.CreatedRows, job.UpdatedRows, job.FailedRows,
+		string(rowErrors), job.Error, finishedAt, job.ID,
+	).Scan(&job.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrImportJobNotFound
+		}
+		return fmt.Errorf("failed to update import job: %w", queryError(ctx, err))
+	}
+	return nil
+}
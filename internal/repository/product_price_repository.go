@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"fmt"
+)
+
+const productPriceColumns = `product_id, currency, price_minor, created_at, updated_at`
+
+type ProductPriceRepository struct {
+	db       *sql.DB
+	replicas *database.ReplicaSet
+	dialect  database.Dialect
+}
+
+func NewProductPriceRepository() *ProductPriceRepository {
+	return &ProductPriceRepository{
+		db:       database.DB,
+		replicas: database.Replicas,
+		dialect:  database.CurrentDialect,
+	}
+}
+
+func (r *ProductPriceRepository) Set(ctx context.Context, price *model.ProductPrice) error {
+	defer observeQuery("product_price.set")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO product_prices (product_id, currency, price_minor) VALUES (?, ?, ?)
+	          ON CONFLICT (product_id, currency)
+	          DO UPDATE SET price_minor = excluded.price_minor, updated_at = CURRENT_TIMESTAMP
+	          RETURNING created_at, updated_at`
+	err := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), price.ProductID, price.Currency, price.PriceMinor).
+		Scan(&price.CreatedAt, &price.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set product price: %w", queryError(ctx, err))
+	}
+	return nil
+}
+
+func (r *ProductPriceRepository) Delete(ctx context.Context, productID int64, currency string) error {
+	defer observeQuery("product_price.delete")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := database.QuerierFrom(ctx, r.db).
+		ExecContext(ctx, r.dialect.Rebind(`DELETE FROM product_prices WHERE product_id = ? AND currency = ?`), productID, currency)
+	if err != nil {
+		return fmt.Errorf("failed to delete product price: %w", queryError(ctx, err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete product price: %w", err)
+	}
+	if affected == 0 {
+		return ErrProductPriceNotFound
+	}
+	return nil
+}
+
+func (r *ProductPriceRepository) ListByProducts(ctx context.Context, productIDs []int64) (map[int64][]model.ProductPrice, error) {
+	defer observeQuery("product_price.list_by_products")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result := make(map[int64][]model.ProductPrice, len(productIDs))
+	for _, chunk := range chunks(productIDs, batchChunkRows) {
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+		query := `SELECT ` + productPriceColumns + ` FROM product_prices
+		          WHERE product_id IN (` + valuesSQL("?", len(chunk)) + `) ORDER BY product_id, currency`
+
+		err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+			rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+			if err != nil {
+				return fmt.Errorf("failed to list product prices: %w", err)
+			}
+			defer rows.Close()
+
+			prices := make(map[int64][]model.ProductPrice, len(chunk))
+			for rows.Next() {
+				var price model.ProductPrice
+				if err := rows.Scan(&price.ProductID, &price.Currency, &price.PriceMinor, &price.CreatedAt, &price.UpdatedAt); err != nil {
+					return fmt.Errorf("failed to scan product price: %w", err)
+				}
+				prices[price.ProductID] = append(prices[price.ProductID], price)
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for id, list := range prices {
+				result[id] = list
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, queryError(ctx, err)
+		}
+	}
+	return result, nil
+}
@@ -1,113 +1,425 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"demo-service/internal/database"
 	"demo-service/internal/model"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 )
 
+const productColumns = `id, name, description, price_minor, currency, stock, reserved, version, created_at, updated_at, deleted_at, sku`
+
 type ProductRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	replicas *database.ReplicaSet
+	dialect  database.Dialect
 }
 
 func NewProductRepository() *ProductRepository {
 	return &ProductRepository{
-		db: database.DB,
+		db:       database.DB,
+		replicas: database.Replicas,
+		dialect:  database.CurrentDialect,
 	}
 }
 
-func (r *ProductRepository) Create(product *model.Product) error {
-	query := `INSERT INTO products (name, description, price, stock) VALUES (?, ?, ?, ?)`
-	result, err := r.db.Exec(query, product.Name, product.Description, product.Price, product.Stock)
-	if err != nil {
-		return fmt.Errorf("failed to create product: %w", err)
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get last insert id: %w", err)
-	}
-
-	product.ID = id
-	return nil
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *ProductRepository) GetByID(id int64) (*model.Product, error) {
-	query := `SELECT id, name, description, price, stock, created_at, updated_at FROM products WHERE id = ?`
-	row := r.db.QueryRow(query, id)
-
-	product := &model.Product{}
-	err := row.Scan(
+func scanProduct(row rowScanner, product *model.Product) error {
+	return row.Scan(
 		&product.ID,
 		&product.Name,
 		&product.Description,
-		&product.Price,
+		&product.PriceMinor,
+		&product.Currency,
 		&product.Stock,
+		&product.Reserved,
+		&product.Version,
 		&product.CreatedAt,
 		&product.UpdatedAt,
+		&product.DeletedAt,
+		&product.SKU,
 	)
+}
+
+func (r *ProductRepository) Create(ctx context.Context, product *model.Product) error {
+	defer observeQuery("product.create")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	// RETURNING возвращает сгенерированные поля без отдельного SELECT
+	query := `INSERT INTO products (name, description, price_minor, currency, stock, sku) VALUES (?, ?, ?, ?, ?, ?)
+	          RETURNING id, version, created_at, updated_at`
+	err := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), product.Name, product.Description, product.PriceMinor, product.Currency, product.Stock, product.SKU).
+		Scan(&product.ID, &product.Version, &product.CreatedAt, &product.UpdatedAt)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrSKUExists
+		}
+		return fmt.Errorf("failed to create product: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) GetByID(ctx context.Context, id int64) (*model.Product, error) {
+	defer observeQuery("product.get_by_id")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = ? AND deleted_at IS NULL`
+
+	product := &model.Product{}
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		return scanProduct(q.QueryRowContext(ctx, r.dialect.Rebind(query), id), product)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("product not found")
+			return nil, ErrProductNotFound
 		}
-		return nil, fmt.Errorf("failed to get product: %w", err)
+		return nil, fmt.Errorf("failed to get product: %w", queryError(ctx, err))
 	}
 
 	return product, nil
 }
 
-func (r *ProductRepository) List(page, limit int) ([]model.Product, int, error) {
-	offset := (page - 1) * limit
+// GetBySKU ищет неудаленный продукт по SKU в нормализованном виде.
+func (r *ProductRepository) GetBySKU(ctx context.Context, sku string) (*model.Product, error) {
+	defer observeQuery("product.get_by_sku")()
 
-	// Получаем общее количество
-	var total int
-	countQuery := `SELECT COUNT(*) FROM products`
-	err := r.db.QueryRow(countQuery).Scan(&total)
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + productColumns + ` FROM products WHERE sku = ? AND deleted_at IS NULL`
+
+	product := &model.Product{}
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		return scanProduct(q.QueryRowContext(ctx, r.dialect.Rebind(query), sku), product)
+	})
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", queryError(ctx, err))
 	}
 
-	// Получаем список продуктов
-	query := `SELECT id, name, description, price, stock, created_at, updated_at 
-	          FROM products ORDER BY created_at DESC LIMIT ? OFFSET ?`
-	rows, err := r.db.Query(query, limit, offset)
+	return product, nil
+}
+
+func (r *ProductRepository) List(ctx context.Context, opts ProductListOptions) ([]model.Product, int, error) {
+	defer observeQuery("product.list")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if opts.Query != "" {
+		return r.search(ctx, opts)
+	}
+
+	offset := listOffset(opts)
+	where, args := r.filterSQL(opts, "", nil)
+	listWhere, listArgs := r.keysetWhere(where, args, opts, "")
+
+	var (
+		products []model.Product
+		total    int
+	)
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		products = nil
+
+		// Получаем общее количество
+		if !opts.SkipTotal {
+			countQuery := `SELECT COUNT(*) FROM products` + where
+			err := q.QueryRowContext(ctx, r.dialect.Rebind(countQuery), args...).Scan(&total)
+			if err != nil {
+				return fmt.Errorf("failed to count products: %w", err)
+			}
+		}
+
+		// Получаем список продуктов
+		query := `SELECT ` + productColumns + ` FROM products` + listWhere +
+			` ORDER BY ` + orderBySQL(opts.Sort, "", isBackward(opts)) + ` LIMIT ? OFFSET ?`
+		rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), append(listArgs, opts.Limit, offset)...)
+		if err != nil {
+			return fmt.Errorf("failed to list products: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var product model.Product
+			if err := scanProduct(rows, &product); err != nil {
+				return fmt.Errorf("failed to scan product: %w", err)
+			}
+			products = append(products, product)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate products: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list products: %w", err)
+		return nil, 0, queryError(ctx, err)
 	}
-	defer rows.Close()
 
-	var products []model.Product
-	for rows.Next() {
-		var product model.Product
-		err := rows.Scan(
-			&product.ID,
-			&product.Name,
-			&product.Description,
-			&product.Price,
-			&product.Stock,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		)
+	if isBackward(opts) {
+		slices.Reverse(products)
+	}
+	return products, total, nil
+}
+
+// streamPageRows - число строк, которое Stream читает одним запросом
+const streamPageRows = 500
+
+// Stream читает список страницами по курсору: соединение занято только на
+// время запроса страницы, а не пока клиент читает выгрузку. Продукты,
+// измененные во время выгрузки, попадают в нее в состоянии на момент
+// чтения своей страницы.
+func (r *ProductRepository) Stream(ctx context.Context, opts ProductListOptions, fn func(product *model.Product) error) error {
+	defer observeQuery("product.stream")()
+
+	opts.Query = ""
+	opts.Keyset = nil
+	opts.Offset = 0
+	opts.Limit = streamPageRows
+	opts.SkipTotal = true
+	for {
+		products, _, err := r.List(ctx, opts)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan product: %w", err)
+			return fmt.Errorf("failed to stream products: %w", err)
 		}
-		products = append(products, product)
+		for i := range products {
+			if err := fn(&products[i]); err != nil {
+				return err
+			}
+		}
+		if len(products) < streamPageRows {
+			return nil
+		}
+		opts.Keyset = &Keyset{After: products[len(products)-1]}
 	}
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("failed to iterate products: %w", err)
+// listOffset - OFFSET для постраничного режима; при курсоре он не нужен.
+func listOffset(opts ProductListOptions) int {
+	if opts.Keyset != nil {
+		return 0
 	}
+	return opts.Offset
+}
 
-	return products, total, nil
+func isBackward(opts ProductListOptions) bool {
+	return opts.Keyset != nil && opts.Keyset.Backward
 }
 
-func (r *ProductRepository) Update(id int64, updates map[string]interface{}) error {
+// filterSQL собирает WHERE из фильтров списка, добавляя их к conds и
+// аргументам запроса. table - псевдоним таблицы products, если он нужен.
+func (r *ProductRepository) filterSQL(opts ProductListOptions, table string, conds []string, args ...interface{}) (string, []interface{}) {
+	col := func(name string) string {
+		if table == "" {
+			return name
+		}
+		return table + "." + name
+	}
+	add := func(cond string, arg interface{}) {
+		conds = append(conds, cond)
+		args = append(args, arg)
+	}
+
+	if !opts.IncludeDeleted {
+		conds = append(conds, col("deleted_at")+" IS NULL")
+	}
+
+	f := opts.Filter
+	if f.Currency != nil {
+		add(col("currency")+" = ?", *f.Currency)
+	}
+	if f.MinPrice != nil {
+		add(col("price_minor")+" >= ?", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		add(col("price_minor")+" <= ?", *f.MaxPrice)
+	}
+	if f.MinStock != nil {
+		add(col("stock")+" >= ?", *f.MinStock)
+	}
+	if f.MaxStock != nil {
+		add(col("stock")+" <= ?", *f.MaxStock)
+	}
+	if f.InStock != nil {
+		if *f.InStock {
+			conds = append(conds, col("stock")+" > 0")
+		} else {
+			conds = append(conds, col("stock")+" = 0")
+		}
+	}
+	if f.CreatedAfter != nil {
+		add(col("created_at")+" > ?", r.dialect.TimeArg(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		add(col("created_at")+" < ?", r.dialect.TimeArg(*f.CreatedBefore))
+	}
+	if f.UpdatedSince != nil {
+		add(col("updated_at")+" >= ?", r.dialect.TimeArg(*f.UpdatedSince))
+	}
+	if f.CategoryID != nil {
+		scope := "pc.category_id = ?"
+		if f.IncludeSubcategories {
+			scope = "pc.category_id IN (" + categorySubtreeSQL + ")"
+		}
+		add(col("id")+" IN (SELECT pc.product_id FROM product_categories pc WHERE "+scope+")", *f.CategoryID)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// EffectiveProductSort дополняет сортировку до однозначной: по умолчанию
+// created_at DESC, в конце id DESC, если id не указан. Поля не из
+// ProductSortFields отбрасываются.
+func EffectiveProductSort(sort []SortField) []SortField {
+	if len(sort) == 0 {
+		sort = []SortField{{Field: "created_at", Desc: true}}
+	}
+
+	fields := make([]SortField, 0, len(sort)+1)
+	hasID := false
+	for _, field := range sort {
+		if !slices.Contains(ProductSortFields, field.Field) {
+			continue
+		}
+		fields = append(fields, field)
+		hasID = hasID || field.Field == "id"
+	}
+	if !hasID {
+		fields = append(fields, SortField{Field: "id", Desc: true})
+	}
+	return fields
+}
+
+// ProductSortValue возвращает значение поля сортировки продукта.
+func ProductSortValue(product *model.Product, field string) interface{} {
+	switch field {
+	case "id":
+		return product.ID
+	case "name":
+		return product.Name
+	case "price":
+		return product.PriceMinor
+	case "stock":
+		return product.Stock
+	case "created_at":
+		return product.CreatedAt
+	case "updated_at":
+		return product.UpdatedAt
+	}
+	return nil
+}
+
+// sortColumn - колонка поля сортировки: цена хранится в price_minor.
+func sortColumn(field string) string {
+	if field == "price" {
+		return "price_minor"
+	}
+	return field
+}
+
+// orderBySQL собирает ORDER BY из EffectiveProductSort. reverse меняет
+// направление всех полей (выборка назад от курсора).
+func orderBySQL(sort []SortField, table string, reverse bool) string {
+	if table != "" {
+		table += "."
+	}
+
+	fields := EffectiveProductSort(sort)
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		part := table + sortColumn(field.Field)
+		if field.Desc != reverse {
+			part += " DESC"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// keysetWhere добавляет к WHERE из filterSQL условие курсора. COUNT(*)
+// выполняется без него: total - число всех строк под фильтрами.
+func (r *ProductRepository) keysetWhere(where string, args []interface{}, opts ProductListOptions, table string) (string, []interface{}) {
+	if opts.Keyset == nil {
+		return where, args
+	}
+
+	cond, keysetArgs := r.keysetSQL(opts.Keyset, opts.Sort, table)
+	if where == "" {
+		where = " WHERE " + cond
+	} else {
+		where += " AND " + cond
+	}
+	return where, append(slices.Clone(args), keysetArgs...)
+}
+
+// keysetSQL строит условие "строка после границы" в порядке сортировки:
+// (a > ?) OR (a = ? AND b < ?) OR ... с учетом направления каждого поля.
+func (r *ProductRepository) keysetSQL(keyset *Keyset, sort []SortField, table string) (string, []interface{}) {
+	if table != "" {
+		table += "."
+	}
+
+	var (
+		alternatives []string
+		args         []interface{}
+		equalities   []string
+		equalArgs    []interface{}
+	)
+	for _, field := range EffectiveProductSort(sort) {
+		value := ProductSortValue(&keyset.After, field.Field)
+		if t, ok := value.(time.Time); ok {
+			value = r.dialect.TimeArg(t)
+		}
+
+		op := " > ?"
+		if field.Desc != keyset.Backward {
+			op = " < ?"
+		}
+
+		column := table + sortColumn(field.Field)
+		conds := append(slices.Clone(equalities), column+op)
+		alternatives = append(alternatives, "("+strings.Join(conds, " AND ")+")")
+		args = append(append(args, equalArgs...), value)
+
+		equalities = append(equalities, column+" = ?")
+		equalArgs = append(equalArgs, value)
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+func (r *ProductRepository) Update(ctx context.Context, id int64, updates map[string]interface{}, version int64) (*model.Product, error) {
+	defer observeQuery("product.update")()
+
 	if len(updates) == 0 {
-		return nil
+		product, err := r.GetByID(database.WithPrimary(ctx), id)
+		if err == nil && version != 0 && product.Version != version {
+			return nil, ErrVersionMismatch
+		}
+		return product, err
 	}
 
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	// Формируем SET части запроса
 	setParts := []string{}
 	args := []interface{}{}
@@ -117,50 +429,143 @@ func (r *ProductRepository) Update(id int64, updates map[string]interface{}) err
 		args = append(args, value)
 	}
 
-	// Добавляем updated_at
-	setParts = append(setParts, "updated_at = CURRENT_TIMESTAMP")
+	// Добавляем updated_at и новую версию
+	setParts = append(setParts, "updated_at = CURRENT_TIMESTAMP", "version = version + 1")
 
-	// Формируем финальный запрос
-	query := "UPDATE products SET " + fmt.Sprintf("%s", setParts[0])
-	for i := 1; i < len(setParts); i++ {
-		query += ", " + setParts[i]
-	}
-	query += " WHERE id = ?"
+	// Формируем финальный запрос, RETURNING отдает обновленную запись
+	query := "UPDATE products SET " + strings.Join(setParts, ", ") +
+		" WHERE id = ? AND deleted_at IS NULL"
 	args = append(args, id)
-
-	result, err := r.db.Exec(query, args...)
-	if err != nil {
-		return fmt.Errorf("failed to update product: %w", err)
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
 	}
+	query += " RETURNING " + productColumns
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), args...)
+
+	product := &model.Product{}
+	if err := scanProduct(row, product); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.missingOrConflict(ctx, id, version)
+		}
+		if r.dialect.IsUniqueViolation(err) {
+			return nil, ErrSKUExists
+		}
+		// products_reserved_check: остаток нельзя опустить ниже резерва
+		if r.dialect.IsCheckViolation(err) {
+			return nil, ErrStockBelowReserved
+		}
+		return nil, fmt.Errorf("failed to update product: %w", queryError(ctx, err))
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+	return product, nil
+}
+
+// missingOrConflict объясняет, почему условное изменение не затронуло строк:
+// продукта нет или его версия уже другая.
+func (r *ProductRepository) missingOrConflict(ctx context.Context, id int64, version int64) error {
+	if version == 0 {
+		return ErrProductNotFound
 	}
+	return r.missingOr(ctx, id, ErrVersionMismatch)
+}
 
-	return nil
+// missingOr возвращает ErrProductNotFound, если неудаленного продукта нет,
+// иначе reason.
+func (r *ProductRepository) missingOr(ctx context.Context, id int64, reason error) error {
+	query := `SELECT 1 FROM products WHERE id = ? AND deleted_at IS NULL`
+	var exists int
+	err := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), id).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		return fmt.Errorf("failed to check product: %w", queryError(ctx, err))
+	}
+	return reason
 }
 
-func (r *ProductRepository) Delete(id int64) error {
-	query := `DELETE FROM products WHERE id = ?`
-	result, err := r.db.Exec(query, id)
+// Delete помечает продукт удаленным. Запись остается в таблице до Purge.
+func (r *ProductRepository) Delete(ctx context.Context, id int64, version int64) error {
+	defer observeQuery("product.delete")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE products SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP,
+	          version = version + 1 WHERE id = ? AND deleted_at IS NULL`
+	args := []interface{}{id}
+	if version != 0 {
+		query += ` AND version = ?`
+		args = append(args, version)
+	}
+	result, err := database.QuerierFrom(ctx, r.db).ExecContext(ctx, r.dialect.Rebind(query), args...)
 	if err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+		return fmt.Errorf("failed to delete product: %w", queryError(ctx, err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to get rows affected: %w", queryError(ctx, err))
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+		return r.missingOrConflict(ctx, id, version)
 	}
 
 	return nil
 }
 
+func (r *ProductRepository) Restore(ctx context.Context, id int64) (*model.Product, error) {
+	defer observeQuery("product.restore")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE products SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP, version = version + 1
+	          WHERE id = ? AND deleted_at IS NOT NULL RETURNING ` + productColumns
+	row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), id)
+
+	product := &model.Product{}
+	if err := scanProduct(row, product); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProductNotFound
+		}
+		// За время удаления SKU мог получить другой продукт
+		if r.dialect.IsUniqueViolation(err) {
+			return nil, ErrSKUExists
+		}
+		return nil, fmt.Errorf("failed to restore product: %w", queryError(ctx, err))
+	}
+
+	return product, nil
+}
+
+// Purge окончательно удаляет продукты, помеченные удаленными раньше deletedBefore.
+func (r *ProductRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]int64, error) {
+	defer observeQuery("product.purge")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM products WHERE deleted_at IS NOT NULL AND deleted_at < ? RETURNING id`
+	rows, err := database.QuerierFrom(ctx, r.db).QueryContext(ctx, r.dialect.Rebind(query), r.dialect.TimeArg(deletedBefore))
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge products: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var purged []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan purged product: %w", queryError(ctx, err))
+		}
+		purged = append(purged, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to purge products: %w", queryError(ctx, err))
+	}
+	return purged, nil
+}
@@ -0,0 +1,354 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+
+	// Ограничение на число слов в запросе q
+	maxSearchTerms = 16
+)
+
+// searchTerm - слово или фраза из запроса q. prefix означает, что последнее
+// слово может быть началом более длинного ("чайн*").
+type searchTerm struct {
+	words  []string
+	prefix bool
+}
+
+// parseSearchQuery разбирает запрос q: слова через пробел ищутся все сразу,
+// "фраза в кавычках" - подряд, слово* - по префиксу. Знаки препинания
+// отбрасываются, поэтому результат безопасно подставлять в синтаксис tsquery и FTS5.
+func parseSearchQuery(q string) ([]searchTerm, error) {
+	var terms []searchTerm
+
+	for rest := strings.TrimSpace(q); rest != ""; rest = strings.TrimSpace(rest) {
+		var chunk string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				chunk, rest = rest[1:], ""
+			} else {
+				chunk, rest = rest[1:end+1], rest[end+2:]
+			}
+			if strings.HasPrefix(rest, "*") {
+				chunk += "*"
+				rest = rest[1:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			chunk, rest = rest[:end], rest[end:]
+		}
+
+		term := searchTerm{prefix: strings.HasSuffix(chunk, "*")}
+		term.words = strings.FieldsFunc(strings.ToLower(chunk), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(term.words) > 0 {
+			terms = append(terms, term)
+		}
+	}
+
+	if len(terms) == 0 {
+		return nil, ErrInvalidSearchQuery
+	}
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms, nil
+}
+
+// tsQuery собирает выражение для to_tsquery: фразы через <->, префикс как :*.
+func tsQuery(terms []searchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		phrase := strings.Join(term.words, " <-> ")
+		if term.prefix {
+			phrase += ":*"
+		}
+		if len(term.words) > 1 {
+			phrase = "(" + phrase + ")"
+		}
+		parts = append(parts, phrase)
+	}
+	return strings.Join(parts, " & ")
+}
+
+// fts5Query собирает выражение MATCH для SQLite FTS5.
+func fts5Query(terms []searchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		phrase := `"` + strings.Join(term.words, " ") + `"`
+		if term.prefix {
+			phrase += "*"
+		}
+		parts = append(parts, phrase)
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// search выполняет полнотекстовый поиск: Postgres - tsvector с GIN-индексом
+// и ts_rank, SQLite - FTS5 и bm25. Название весит больше описания.
+func (r *ProductRepository) search(ctx context.Context, opts ProductListOptions) ([]model.Product, int, error) {
+	terms, err := parseSearchQuery(opts.Query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Явная сортировка (sort) заменяет сортировку по релевантности
+	var (
+		countQuery, query string
+		args, listArgs    []interface{}
+	)
+	switch r.dialect.Name() {
+	case "postgres":
+		var where, listWhere string
+		where, args = r.filterSQL(opts, "", []string{"search_vector @@ q"}, tsQuery(terms))
+		listWhere, listArgs = r.keysetWhere(where, args, opts, "")
+		orderBy := `ts_rank(search_vector, q) DESC, created_at DESC`
+		if len(opts.Sort) > 0 {
+			orderBy = orderBySQL(opts.Sort, "", isBackward(opts))
+		}
+		countQuery = `SELECT COUNT(*) FROM products, to_tsquery('simple', ?) q` + where
+		query = `SELECT ` + productColumns + `,
+		          ts_headline('simple', name, q, 'StartSel=` + highlightStart + `, StopSel=` + highlightStop + `, HighlightAll=true'),
+		          ts_headline('simple', coalesce(description, ''), q, 'StartSel=` + highlightStart + `, StopSel=` + highlightStop + `, MaxFragments=2')
+		          FROM products, to_tsquery('simple', ?) q` + listWhere + `
+		          ORDER BY ` + orderBy + ` LIMIT ? OFFSET ?`
+	case "sqlite":
+		var where, listWhere string
+		where, args = r.filterSQL(opts, "p", []string{"products_fts MATCH ?"}, fts5Query(terms))
+		listWhere, listArgs = r.keysetWhere(where, args, opts, "p")
+		orderBy := `bm25(products_fts, 10.0, 1.0), p.created_at DESC`
+		if len(opts.Sort) > 0 {
+			orderBy = orderBySQL(opts.Sort, "p", isBackward(opts))
+		}
+		from := ` FROM products_fts JOIN products p ON p.id = products_fts.rowid`
+		countQuery = `SELECT COUNT(*)` + from + where
+		query = `SELECT ` + qualifyColumns(productColumns, "p") + `,
+		          highlight(products_fts, 0, '` + highlightStart + `', '` + highlightStop + `'),
+		          snippet(products_fts, 1, '` + highlightStart + `', '` + highlightStop + `', '…', 16)` +
+			from + listWhere + `
+		          ORDER BY ` + orderBy + ` LIMIT ? OFFSET ?`
+	default:
+		return nil, 0, fmt.Errorf("full-text search is not supported for %s", r.dialect.Name())
+	}
+
+	offset := listOffset(opts)
+
+	var (
+		products []model.Product
+		total    int
+	)
+	err = readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		products = nil
+
+		if !opts.SkipTotal {
+			if err := q.QueryRowContext(ctx, r.dialect.Rebind(countQuery), args...).Scan(&total); err != nil {
+				return fmt.Errorf("failed to count products: %w", err)
+			}
+		}
+
+		rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), append(listArgs, opts.Limit, offset)...)
+		if err != nil {
+			return fmt.Errorf("failed to search products: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				product           model.Product
+				name, description sql.NullString
+			)
+			if err := scanProduct(withExtra(rows, &name, &description), &product); err != nil {
+				return fmt.Errorf("failed to scan product: %w", err)
+			}
+			product.Highlight = &model.ProductHighlight{
+				Name:        escapeHighlight(name.String),
+				Description: escapeHighlight(description.String),
+			}
+			products = append(products, product)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate products: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, queryError(ctx, err)
+	}
+
+	if isBackward(opts) {
+		slices.Reverse(products)
+	}
+	return products, total, nil
+}
+
+// qualifyColumns добавляет к каждому столбцу списка имя таблицы.
+func qualifyColumns(columns, table string) string {
+	parts := strings.Split(columns, ", ")
+	for i, column := range parts {
+		parts[i] = table + "." + column
+	}
+	return strings.Join(parts, ", ")
+}
+
+// extraScanner дочитывает столбцы, идущие после стандартного набора.
+type extraScanner struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func withExtra(row rowScanner, extra ...interface{}) rowScanner {
+	return extraScanner{row: row, extra: extra}
+}
+
+func (s extraScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// searchToken - слово текста и его границы в байтах.
+type searchToken struct {
+	word       string
+	start, end int
+}
+
+// searchTokens делит текст на слова так же, как parseSearchQuery делит
+// запрос: по всему, что не буква и не цифра.
+func searchTokens(text string) []searchToken {
+	var tokens []searchToken
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, searchToken{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, searchToken{word: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// matchAt проверяет, что слова term идут в tokens подряд, начиная с i:
+// целыми словами, как в tsquery и FTS5, последнее - началом слова, если
+// term.prefix.
+func (term searchTerm) matchAt(tokens []searchToken, i int) bool {
+	if i+len(term.words) > len(tokens) {
+		return false
+	}
+	for k, word := range term.words {
+		token := tokens[i+k].word
+		if token != word && !(term.prefix && k == len(term.words)-1 && strings.HasPrefix(token, word)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSearchTerms проверяет, что каждое слово и фраза запроса есть в одном
+// из полей. Используется хранилищем в памяти вместо индекса.
+func matchSearchTerms(terms []searchTerm, fields ...string) bool {
+	tokens := make([][]searchToken, len(fields))
+	for i, field := range fields {
+		tokens[i] = searchTokens(field)
+	}
+
+	for _, term := range terms {
+		if !slices.ContainsFunc(tokens, term.in) {
+			return false
+		}
+	}
+	return true
+}
+
+// searchRank - релевантность для хранилища в памяти: совпадения в названии
+// весят в 10 раз больше, чем в описании, как в bm25(products_fts, 10.0, 1.0).
+func searchRank(terms []searchTerm, name, description string) int {
+	nameTokens, descriptionTokens := searchTokens(name), searchTokens(description)
+	rank := 0
+	for _, term := range terms {
+		if term.in(nameTokens) {
+			rank += 10
+		}
+		if term.in(descriptionTokens) {
+			rank++
+		}
+	}
+	return rank
+}
+
+// in проверяет, что term есть среди tokens.
+func (term searchTerm) in(tokens []searchToken) bool {
+	for i := range tokens {
+		if term.matchAt(tokens, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlightSearchTerms оборачивает совпадения с запросом в <mark>: фразу
+// целиком, слово по префиксу - до конца слова.
+func highlightSearchTerms(terms []searchTerm, text string) string {
+	tokens := searchTokens(text)
+
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(tokens); {
+		n := 0
+		for _, term := range terms {
+			if len(term.words) > n && term.matchAt(tokens, i) {
+				n = len(term.words)
+			}
+		}
+		if n == 0 {
+			i++
+			continue
+		}
+
+		start, end := tokens[i].start, tokens[i+n-1].end
+		b.WriteString(text[last:start])
+		b.WriteString(highlightStart)
+		b.WriteString(text[start:end])
+		b.WriteString(highlightStop)
+		last = end
+		i += n
+	}
+	b.WriteString(text[last:])
+	return escapeHighlight(b.String())
+}
+
+var escapedHighlight = strings.NewReplacer(
+	html.EscapeString(highlightStart), highlightStart,
+	html.EscapeString(highlightStop), highlightStop,
+)
+
+// escapeHighlight экранирует HTML в подсвеченном тексте, оставляя только
+// теги <mark>: клиенты вставляют подсветку как разметку, и название вида
+// <img onerror=...> не должно в нее попасть. Тег <mark>, набранный в самом
+// названии, тоже останется тегом, но ничего, кроме выделения, он не дает.
+func escapeHighlight(text string) string {
+	return escapedHighlight.Replace(html.EscapeString(text))
+}
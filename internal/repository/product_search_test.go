@@ -0,0 +1,70 @@
+package repository
+
+import "testing"
+
+func TestMatchSearchTerms(t *testing.T) {
+	tests := []struct {
+		query, name, description string
+		want                     bool
+	}{
+		{"cat", "Cat toy", "", true},
+		{"cat", "Concatenate", "", false},
+		{"cat", "Scatter cushion", "for cats", false},
+		{"cat*", "Scatter cushion", "for cats", true},
+		{"cat*", "Concatenate", "", false},
+		{"чайник", "Электрический Чайник", "", true},
+		{"чайн*", "Электрический чайник", "", true},
+		{"red kettle", "Kettle", "in red", true},
+		{"red kettle", "Kettle", "", false},
+		{`"red kettle"`, "Red kettle", "", true},
+		{`"red kettle"`, "Kettle, red", "", false},
+		{`"red kett"*`, "Red kettle", "", true},
+		// Фраза не переходит из названия в описание
+		{`"kettle steel"`, "Kettle", "Steel body", false},
+		{"e-mail", "E-mail gift card", "", true},
+	}
+	for _, tt := range tests {
+		terms, err := parseSearchQuery(tt.query)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.query, err)
+		}
+		if got := matchSearchTerms(terms, tt.name, tt.description); got != tt.want {
+			t.Errorf("%q in %q / %q = %v, want %v", tt.query, tt.name, tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightSearchTerms(t *testing.T) {
+	tests := []struct {
+		query, text, want string
+	}{
+		{"cat", "Cat and concatenate", "<mark>Cat</mark> and concatenate"},
+		{"cat*", "Cats, catalog", "<mark>Cats</mark>, <mark>catalog</mark>"},
+		{`"red kettle"`, "A red kettle, red", "A <mark>red kettle</mark>, red"},
+		{"kettle", "<b>Kettle</b>", "&lt;b&gt;<mark>Kettle</mark>&lt;/b&gt;"},
+		{"чай", "Зеленый чай", "Зеленый <mark>чай</mark>"},
+	}
+	for _, tt := range tests {
+		terms, err := parseSearchQuery(tt.query)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.query, err)
+		}
+		if got := highlightSearchTerms(terms, tt.text); got != tt.want {
+			t.Errorf("highlight %q in %q = %q, want %q", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSearchRank(t *testing.T) {
+	terms, err := parseSearchQuery("red kettle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inName := searchRank(terms, "Red kettle", "")
+	inBoth := searchRank(terms, "Red kettle", "A red kettle")
+	split := searchRank(terms, "Kettle", "in red")
+	inDescription := searchRank(terms, "Teapot", "red kettle")
+	if !(inBoth > inName && inName > split && split > inDescription && inDescription > 0) {
+		t.Errorf("ranks: both %d, name %d, split %d, description %d", inBoth, inName, split, inDescription)
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"errors"
+	"fmt"
+)
+
+// Reserve увеличивает reserved, только если доступного остатка хватает.
+// Условие проверяется в том же UPDATE, что и меняет строку: из двух
+// параллельных резервов последней единицы второй не затронет строк.
+func (r *ProductRepository) Reserve(ctx context.Context, id int64, quantity int) (*model.Product, error) {
+	defer observeQuery("product.reserve")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE products SET reserved = reserved + ?
+	          WHERE id = ? AND deleted_at IS NULL AND stock - reserved >= ?
+	          RETURNING ` + productColumns
+	row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), quantity, id, quantity)
+
+	product := &model.Product{}
+	if err := scanProduct(row, product); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.missingOr(ctx, id, ErrInsufficientStock)
+		}
+		return nil, fmt.Errorf("failed to reserve stock: %w", queryError(ctx, err))
+	}
+	return product, nil
+}
+
+func (r *ProductRepository) Unreserve(ctx context.Context, id int64, quantity int) (*model.Product, error) {
+	defer observeQuery("product.unreserve")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE products SET reserved = reserved - ? WHERE id = ? RETURNING ` + productColumns
+	row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), quantity, id)
+
+	product := &model.Product{}
+	if err := scanProduct(row, product); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to release stock: %w", queryError(ctx, err))
+	}
+	return product, nil
+}
+
+func (r *ProductRepository) CommitReserved(ctx context.Context, id int64, quantity int) (*model.Product, error) {
+	defer observeQuery("product.commit_reserved")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE products SET stock = stock - ?, reserved = reserved - ?,
+	              updated_at = CURRENT_TIMESTAMP, version = version + 1
+	          WHERE id = ? AND deleted_at IS NULL RETURNING ` + productColumns
+	row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), quantity, quantity, id)
+
+	product := &model.Product{}
+	if err := scanProduct(row, product); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to commit reserved stock: %w", queryError(ctx, err))
+	}
+	return product, nil
+}
+
+// AdjustStock, как и Reserve, проверяет условие в самом UPDATE: параллельные
+// списания не опустят остаток ниже резерва (а значит, и ниже нуля).
+func (r *ProductRepository) AdjustStock(ctx context.Context, id int64, delta int) (*model.Product, error) {
+	defer observeQuery("product.adjust_stock")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE products SET stock = stock + ?, updated_at = CURRENT_TIMESTAMP, version = version + 1
+	          WHERE id = ? AND deleted_at IS NULL AND stock + ? >= reserved
+	          RETURNING ` + productColumns
+	row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), delta, id, delta)
+
+	product := &model.Product{}
+	if err := scanProduct(row, product); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.missingOr(ctx, id, ErrInsufficientStock)
+		}
+		return nil, fmt.Errorf("failed to adjust stock: %w", queryError(ctx, err))
+	}
+	return product, nil
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Порог word_similarity для pg_trgm: ниже значения по умолчанию (0.6),
+// чтобы короткий ввод с одной-двумя опечатками все еще находил название
+const suggestSimilarityThreshold = "0.3"
+
+// Suggest возвращает названия продуктов, похожие на начало ввода prefix.
+// В Postgres используется pg_trgm с GIN-индексом по lower(name), в остальных
+// СУБД названия ранжируются в памяти (rankSuggestions).
+func (r *ProductRepository) Suggest(ctx context.Context, prefix string, limit int) ([]model.ProductSuggestion, error) {
+	defer observeQuery("product.suggest")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if r.dialect.Name() != "postgres" {
+		candidates, err := r.suggestCandidates(ctx)
+		if err != nil {
+			return nil, queryError(ctx, err)
+		}
+		return rankSuggestions(prefix, candidates, limit), nil
+	}
+
+	_, db := database.ReadQuerierFrom(ctx, r.db, r.replicas)
+	suggestions, err := r.suggestTrigram(ctx, db, prefix, limit)
+	if err != nil && db != r.db && ctx.Err() == nil {
+		r.replicas.MarkFailed(db)
+		suggestions, err = r.suggestTrigram(ctx, r.db, prefix, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest products: %w", queryError(ctx, err))
+	}
+	return suggestions, nil
+}
+
+// suggestTrigram выполняет поиск в отдельной read-only транзакции, чтобы
+// SET LOCAL изменил порог оператора <% только для этого запроса.
+func (r *ProductRepository) suggestTrigram(ctx context.Context, db *sql.DB, prefix string, limit int) ([]model.ProductSuggestion, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SET LOCAL pg_trgm.word_similarity_threshold = `+suggestSimilarityThreshold); err != nil {
+		return nil, err
+	}
+
+	// Совпадение по началу названия важнее похожести
+	query := `SELECT id, name, word_similarity($1, lower(name)) AS score
+	          FROM products
+	          WHERE deleted_at IS NULL AND $1 <% lower(name)
+	          ORDER BY lower(name) LIKE $2 DESC, score DESC, name
+	          LIMIT $3`
+	needle := strings.ToLower(prefix)
+	rows, err := tx.QueryContext(ctx, query, needle, escapeLike(needle)+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []model.ProductSuggestion{}
+	for rows.Next() {
+		var suggestion model.ProductSuggestion
+		if err := rows.Scan(&suggestion.ID, &suggestion.Name, &suggestion.Score); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
+
+func (r *ProductRepository) suggestCandidates(ctx context.Context) ([]model.ProductSuggestion, error) {
+	var candidates []model.ProductSuggestion
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		candidates = nil
+
+		rows, err := q.QueryContext(ctx, `SELECT id, name FROM products WHERE deleted_at IS NULL`)
+		if err != nil {
+			return fmt.Errorf("failed to load product names: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var candidate model.ProductSuggestion
+			if err := rows.Scan(&candidate.ID, &candidate.Name); err != nil {
+				return fmt.Errorf("failed to scan product name: %w", err)
+			}
+			candidates = append(candidates, candidate)
+		}
+		return rows.Err()
+	})
+	return candidates, err
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// rankSuggestions - запасной вариант без pg_trgm. Ввод сравнивается с началом
+// названия и с началом каждого слова в нем. Опечатки допускаются начиная
+// с трех символов: одна, а для ввода длиннее пяти символов - две; первая
+// буква при этом должна совпадать, иначе короткий ввод похож на все подряд.
+func rankSuggestions(prefix string, candidates []model.ProductSuggestion, limit int) []model.ProductSuggestion {
+	needle := []rune(strings.ToLower(strings.TrimSpace(prefix)))
+	if len(needle) == 0 {
+		return []model.ProductSuggestion{}
+	}
+
+	maxTypos := 0
+	switch {
+	case len(needle) > 5:
+		maxTypos = 2
+	case len(needle) >= 3:
+		maxTypos = 1
+	}
+
+	ranked := []model.ProductSuggestion{}
+	for _, candidate := range candidates {
+		name := []rune(strings.ToLower(candidate.Name))
+
+		best := -1.0
+		for i, start := range wordStarts(name) {
+			if name[start] != needle[0] {
+				continue
+			}
+			distance := prefixDistance(needle, name[start:])
+			if distance > maxTypos {
+				continue
+			}
+
+			// Совпадение с началом названия ценнее, чем со словом в середине
+			score := 1 - float64(distance)/float64(len(needle)+1)
+			if i > 0 {
+				score -= 0.1
+			}
+			best = max(best, score)
+		}
+
+		if best >= 0 {
+			candidate.Score = math.Round(best*1000) / 1000
+			ranked = append(ranked, candidate)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+func wordStarts(s []rune) []int {
+	var starts []int
+	for i, r := range s {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && (i == 0 || !(unicode.IsLetter(s[i-1]) || unicode.IsDigit(s[i-1]))) {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+// prefixDistance - наименьшее расстояние Дамерау-Левенштейна между needle
+// и каким-либо префиксом s (пользователь еще не допечатал слово).
+func prefixDistance(needle, s []rune) int {
+	// prev2, prev, cur - строки матрицы расстояний для needle[:i-2], needle[:i-1], needle[:i]
+	prev2 := make([]int, len(s)+1)
+	prev := make([]int, len(s)+1)
+	cur := make([]int, len(s)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(needle); i++ {
+		cur[0] = i
+		for j := 1; j <= len(s); j++ {
+			cost := 1
+			if needle[i-1] == s[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && needle[i-1] == s[j-2] && needle[i-2] == s[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	best := prev[0]
+	for _, d := range prev {
+		best = min(best, d)
+	}
+	return best
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"demo-service/internal/model"
+	"slices"
+	"testing"
+)
+
+func TestPrefixDistance(t *testing.T) {
+	tests := []struct {
+		needle, s string
+		want      int
+	}{
+		{"kett", "kettle", 0},
+		{"kettle", "kettle", 0},
+		{"ketle", "kettle", 1},
+		{"kettel", "kettle", 1},
+		{"kxttle", "kettle", 1},
+		{"kettles", "kettle", 1},
+		{"чайнк", "чайник", 1},
+		{"mug", "kettle", 3},
+	}
+	for _, tt := range tests {
+		if got := prefixDistance([]rune(tt.needle), []rune(tt.s)); got != tt.want {
+			t.Errorf("prefixDistance(%q, %q) = %d, want %d", tt.needle, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRankSuggestions(t *testing.T) {
+	candidates := []model.ProductSuggestion{
+		{ID: 1, Name: "Kettle"},
+		{ID: 2, Name: "Electric kettle"},
+		{ID: 3, Name: "Ketchup"},
+		{ID: 4, Name: "Teapot"},
+		{ID: 5, Name: "Chai kettle"},
+	}
+	names := func(suggestions []model.ProductSuggestion) []string {
+		result := make([]string, len(suggestions))
+		for i, suggestion := range suggestions {
+			result[i] = suggestion.Name
+		}
+		return result
+	}
+
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		// Начало названия выше слова в середине, при равенстве - по алфавиту
+		{"kett", 10, []string{"Kettle", "Chai kettle", "Electric kettle", "Ketchup"}},
+		{"kett", 2, []string{"Kettle", "Chai kettle"}},
+		{"ketle", 10, []string{"Kettle", "Chai kettle", "Electric kettle"}},
+		// Короткий ввод без опечаток
+		{"ke", 10, []string{"Ketchup", "Kettle", "Chai kettle", "Electric kettle"}},
+		{"kx", 10, []string{}},
+		// Первая буква должна совпадать
+		{"xettle", 10, []string{}},
+		{"  ", 10, []string{}},
+	}
+	for _, tt := range tests {
+		got := names(rankSuggestions(tt.prefix, candidates, tt.limit))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("rankSuggestions(%q, %d) = %q, want %q", tt.prefix, tt.limit, got, tt.want)
+		}
+	}
+}
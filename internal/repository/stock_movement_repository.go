@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"fmt"
+)
+
+const stockMovementColumns = `id, product_id, delta, stock_after, reason, reference, actor_id, created_at`
+
+type StockMovementRepository struct {
+	db       *sql.DB
+	replicas *database.ReplicaSet
+	dialect  database.Dialect
+}
+
+func NewStockMovementRepository() *StockMovementRepository {
+	return &StockMovementRepository{
+		db:       database.DB,
+		replicas: database.Replicas,
+		dialect:  database.CurrentDialect,
+	}
+}
+
+func (r *StockMovementRepository) Add(ctx context.Context, movement *model.StockMovement) error {
+	defer observeQuery("stock_movement.add")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO stock_movements (product_id, delta, stock_after, reason, reference, actor_id)
+	          VALUES (?, ?, ?, ?, ?, ?) RETURNING id, created_at`
+	err := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), movement.ProductID, movement.Delta, movement.StockAfter,
+			movement.Reason, movement.Reference, movement.ActorID).
+		Scan(&movement.ID, &movement.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add stock movement: %w", queryError(ctx, err))
+	}
+	return nil
+}
+
+// AddMany записывает движения пакетной операции многострочными INSERT.
+func (r *StockMovementRepository) AddMany(ctx context.Context, movements []*model.StockMovement) error {
+	defer observeQuery("stock_movement.add_many")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	q := database.QuerierFrom(ctx, r.db)
+	for _, chunk := range chunks(movements, batchChunkRows) {
+		args := make([]interface{}, 0, len(chunk)*6)
+		for _, movement := range chunk {
+			args = append(args, movement.ProductID, movement.Delta, movement.StockAfter,
+				movement.Reason, movement.Reference, movement.ActorID)
+		}
+
+		query := `INSERT INTO stock_movements (product_id, delta, stock_after, reason, reference, actor_id)
+		          VALUES ` + valuesSQL("(?, ?, ?, ?, ?, ?)", len(chunk))
+		if _, err := q.ExecContext(ctx, r.dialect.Rebind(query), args...); err != nil {
+			return fmt.Errorf("failed to add stock movements: %w", queryError(ctx, err))
+		}
+	}
+	return nil
+}
+
+func (r *StockMovementRepository) List(ctx context.Context, productID int64, page, limit int) ([]model.StockMovement, int, error) {
+	defer observeQuery("stock_movement.list")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	offset := (page - 1) * limit
+
+	var (
+		movements []model.StockMovement
+		total     int
+	)
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		countQuery := `SELECT COUNT(*) FROM stock_movements WHERE product_id = ?`
+		if err := q.QueryRowContext(ctx, r.dialect.Rebind(countQuery), productID).Scan(&total); err != nil {
+			return fmt.Errorf("failed to count stock movements: %w", err)
+		}
+
+		query := `SELECT ` + stockMovementColumns + ` FROM stock_movements
+		          WHERE product_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`
+		rows, err := q.QueryContext(ctx, r.dialect.Rebind(query), productID, limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to list stock movements: %w", err)
+		}
+		defer rows.Close()
+
+		movements = nil
+		for rows.Next() {
+			var movement model.StockMovement
+			err := rows.Scan(&movement.ID, &movement.ProductID, &movement.Delta, &movement.StockAfter,
+				&movement.Reason, &movement.Reference, &movement.ActorID, &movement.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to scan stock movement: %w", err)
+			}
+			movements = append(movements, movement)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, queryError(ctx, err)
+	}
+
+	return movements, total, nil
+}
+
+// Reconcile сравнивает остаток каждого продукта, включая удаленные, с суммой
+// его движений и возвращает расхождения. Сверка читает primary: реплика
+// может отставать и показать мнимое расхождение. Запрос проходит по всему
+// журналу, поэтому его длительность ограничивает только ctx вызывающего.
+// Есть только у SQL-хранилища: журнал в памяти сверять не с чем после
+// перезапуска.
+func (r *StockMovementRepository) Reconcile(ctx context.Context) ([]model.StockDrift, error) {
+	defer observeQuery("stock_movement.reconcile")()
+
+	query := `SELECT p.id, p.stock, COALESCE(SUM(m.delta), 0) FROM products p
+	          LEFT JOIN stock_movements m ON m.product_id = p.id
+	          GROUP BY p.id, p.stock
+	          HAVING p.stock <> COALESCE(SUM(m.delta), 0)
+	          ORDER BY p.id`
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile stock: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var drifts []model.StockDrift
+	for rows.Next() {
+		var drift model.StockDrift
+		if err := rows.Scan(&drift.ProductID, &drift.Stock, &drift.LedgerStock); err != nil {
+			return nil, fmt.Errorf("failed to scan stock drift: %w", err)
+		}
+		drifts = append(drifts, drift)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to reconcile stock: %w", queryError(ctx, err))
+	}
+	return drifts, nil
+}
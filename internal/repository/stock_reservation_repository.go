@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/model"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const stockReservationColumns = `id, product_id, quantity, status, actor_id, expires_at, created_at, updated_at`
+
+type StockReservationRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func NewStockReservationRepository() *StockReservationRepository {
+	return &StockReservationRepository{
+		db:      database.DB,
+		dialect: database.CurrentDialect,
+	}
+}
+
+func scanStockReservation(row rowScanner, reservation *model.StockReservation) error {
+	return row.Scan(
+		&reservation.ID,
+		&reservation.ProductID,
+		&reservation.Quantity,
+		&reservation.Status,
+		&reservation.ActorID,
+		&reservation.ExpiresAt,
+		&reservation.CreatedAt,
+		&reservation.UpdatedAt,
+	)
+}
+
+func (r *StockReservationRepository) Create(ctx context.Context, reservation *model.StockReservation) error {
+	defer observeQuery("stock_reservation.create")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO stock_reservations (product_id, quantity, status, actor_id, expires_at)
+	          VALUES (?, ?, ?, ?, ?) RETURNING id, created_at, updated_at`
+	err := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), reservation.ProductID, reservation.Quantity,
+			reservation.Status, reservation.ActorID, r.dialect.TimeArg(reservation.ExpiresAt)).
+		Scan(&reservation.ID, &reservation.CreatedAt, &reservation.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create stock reservation: %w", queryError(ctx, err))
+	}
+	return nil
+}
+
+func (r *StockReservationRepository) GetByID(ctx context.Context, id int64) (*model.StockReservation, error) {
+	defer observeQuery("stock_reservation.get_by_id")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	reservation := &model.StockReservation{}
+	query := `SELECT ` + stockReservationColumns + ` FROM stock_reservations WHERE id = ?`
+	err := retryRead(ctx, r.dialect, func() error {
+		return scanStockReservation(database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), id), reservation)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReservationNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock reservation: %w", queryError(ctx, err))
+	}
+	return reservation, nil
+}
+
+// Finish меняет статус условным UPDATE: из параллельных подтверждения,
+// снятия и истечения резерва выполнится только одно.
+func (r *StockReservationRepository) Finish(ctx context.Context, id int64, status string) (*model.StockReservation, error) {
+	defer observeQuery("stock_reservation.finish")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE stock_reservations SET status = ?, updated_at = CURRENT_TIMESTAMP
+	          WHERE id = ? AND status = ? RETURNING ` + stockReservationColumns
+	row := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), status, id, model.ReservationStatusActive)
+
+	reservation := &model.StockReservation{}
+	if err := scanStockReservation(row, reservation); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, err := r.GetByID(ctx, id); err != nil {
+				return nil, err
+			}
+			return nil, ErrReservationNotActive
+		}
+		return nil, fmt.Errorf("failed to update stock reservation: %w", queryError(ctx, err))
+	}
+	return reservation, nil
+}
+
+// Expire повторяет условие status = 'active' во внешнем UPDATE: резерв,
+// который успели подтвердить или снять после подзапроса, не истекает.
+func (r *StockReservationRepository) Expire(ctx context.Context, now time.Time, limit int) ([]model.StockReservation, error) {
+	defer observeQuery("stock_reservation.expire")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE stock_reservations SET status = ?, updated_at = CURRENT_TIMESTAMP
+	          WHERE status = ? AND id IN (
+	              SELECT id FROM stock_reservations WHERE status = ? AND expires_at <= ?
+	              ORDER BY expires_at LIMIT ?)
+	          RETURNING ` + stockReservationColumns
+	rows, err := database.QuerierFrom(ctx, r.db).QueryContext(ctx, r.dialect.Rebind(query),
+		model.ReservationStatusExpired, model.ReservationStatusActive, model.ReservationStatusActive,
+		r.dialect.TimeArg(now), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire stock reservations: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var expired []model.StockReservation
+	for rows.Next() {
+		var reservation model.StockReservation
+		if err := scanStockReservation(rows, &reservation); err != nil {
+			return nil, fmt.Errorf("failed to scan stock reservation: %w", err)
+		}
+		expired = append(expired, reservation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to expire stock reservations: %w", queryError(ctx, err))
+	}
+	return expired, nil
+}
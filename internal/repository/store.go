@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"demo-service/internal/database"
+	"demo-service/internal/metrics"
+	"demo-service/internal/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrVersionMismatch = errors.New("product version mismatch")
+	ErrSKUExists       = errors.New("product with this sku already exists")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUserExists      = errors.New("username already exists")
+
+	ErrImportJobNotFound = errors.New("import job not found")
+	ErrImageNotFound     = errors.New("product image not found")
+
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryExists      = errors.New("category with this name already exists")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	ErrCategoryCycle       = errors.New("category cannot be moved into its own subtree")
+
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrProductPriceNotFound = errors.New("product price not found")
+
+	ErrInsufficientStock    = errors.New("insufficient stock")
+	ErrStockBelowReserved   = errors.New("stock cannot be lower than the reserved quantity")
+	ErrReservationNotFound  = errors.New("stock reservation not found")
+	ErrReservationNotActive = errors.New("stock reservation is not active")
+
+	ErrInvalidSearchQuery = errors.New("search query has no words")
+)
+
+// ProductListOptions задает страницу и фильтры списка продуктов.
+// Непустой Query включает полнотекстовый поиск: результаты упорядочены
+// по релевантности и содержат подсвеченные фрагменты (Product.Highlight).
+type ProductListOptions struct {
+	// Page - номер страницы из запроса; репозиторий пропускает Offset строк
+	Page           int
+	Offset         int
+	Limit          int
+	IncludeDeleted bool
+	Query          string
+	Filter         ProductFilter
+	// Пустой Sort - по релевантности при поиске, иначе по created_at DESC
+	Sort []SortField
+	// Keyset включает курсорную пагинацию вместо OFFSET (Offset игнорируется)
+	Keyset *Keyset
+	// SkipTotal отключает COUNT(*), List возвращает total = 0
+	SkipTotal bool
+}
+
+// Keyset - граница страницы для курсорной пагинации. After - крайняя строка
+// соседней страницы, значимы только поля сортировки (EffectiveProductSort).
+// Backward выбирает строки перед границей; они все равно возвращаются
+// в порядке сортировки.
+type Keyset struct {
+	After    model.Product
+	Backward bool
+}
+
+// ProductFilter - условия отбора продуктов, nil-поля не применяются.
+// Границы цены и остатка включительные, дат создания - нет. Цена
+// сравнивается в минимальных единицах, поэтому границы цены имеют смысл
+// только вместе с Currency.
+type ProductFilter struct {
+	// Currency оставляет продукты с ценой в этой валюте
+	Currency      *string
+	MinPrice      *int64
+	MaxPrice      *int64
+	MinStock      *int
+	MaxStock      *int
+	InStock       *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedSince  *time.Time
+	// CategoryID оставляет продукты категории, а с IncludeSubcategories -
+	// и продукты всех ее подкатегорий
+	CategoryID           *int64
+	IncludeSubcategories bool
+}
+
+// SortField - поле сортировки из ProductSortFields.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ProductSortFields - поля, по которым разрешено сортировать список продуктов.
+// price сортирует по price_minor, поэтому сравнима только внутри одной
+// валюты (ProductFilter.Currency).
+var ProductSortFields = []string{"id", "name", "price", "stock", "created_at", "updated_at"}
+
+// ProductStore хранит продукты. Удаление мягкое: GetByID, List и Update
+// не видят удаленные записи, пока их не вернет Restore или не сотрет Purge.
+// SKU уникален среди неудаленных продуктов: Create, Update и Restore
+// возвращают ErrSKUExists при повторе.
+// Каждое изменение увеличивает версию продукта. Update и Delete с ненулевой
+// version выполняются, только если текущая версия совпадает, иначе
+// возвращают ErrVersionMismatch.
+type ProductStore interface {
+	Create(ctx context.Context, product *model.Product) error
+	GetByID(ctx context.Context, id int64) (*model.Product, error)
+	GetBySKU(ctx context.Context, sku string) (*model.Product, error)
+	List(ctx context.Context, opts ProductListOptions) ([]model.Product, int, error)
+	Update(ctx context.Context, id int64, updates map[string]interface{}, version int64) (*model.Product, error)
+	Delete(ctx context.Context, id int64, version int64) error
+	Restore(ctx context.Context, id int64) (*model.Product, error)
+	// Purge возвращает id стертых продуктов.
+	Purge(ctx context.Context, deletedBefore time.Time) ([]int64, error)
+	Suggest(ctx context.Context, prefix string, limit int) ([]model.ProductSuggestion, error)
+
+	// Пакетные операции выполняются многострочными запросами. UpdateMany и
+	// DeleteMany пропускают строки, которых нет или у которых другая версия.
+	CreateMany(ctx context.Context, products []*model.Product) error
+	GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.Product, error)
+	UpdateMany(ctx context.Context, updates []ProductUpdate) ([]model.Product, error)
+	DeleteMany(ctx context.Context, refs []ProductRef) ([]int64, error)
+
+	// GetByNames возвращает неудаленные продукты с указанными названиями.
+	GetByNames(ctx context.Context, names []string) ([]model.Product, error)
+	// GetBySKUs возвращает неудаленные продукты с указанными SKU.
+	GetBySKUs(ctx context.Context, skus []string) ([]model.Product, error)
+	// Stream передает fn продукты списка по одному, не загружая выборку в
+	// память. Query, Keyset и пагинация не поддерживаются.
+	Stream(ctx context.Context, opts ProductListOptions, fn func(product *model.Product) error) error
+
+	// Резервы меняют reserved условным UPDATE, не увеличивая версию: остаток
+	// никогда не становится меньше резерва. Reserve возвращает
+	// ErrInsufficientStock, если доступно меньше quantity единиц. Unreserve
+	// возвращает единицы и удаленному продукту.
+	Reserve(ctx context.Context, id int64, quantity int) (*model.Product, error)
+	Unreserve(ctx context.Context, id int64, quantity int) (*model.Product, error)
+	// CommitReserved списывает quantity единиц из резерва и остатка как
+	// обычное изменение продукта (новая версия).
+	CommitReserved(ctx context.Context, id int64, quantity int) (*model.Product, error)
+
+	// AdjustStock изменяет остаток на delta (новая версия). Если остаток
+	// стал бы меньше резерва или нуля, возвращает ErrInsufficientStock.
+	AdjustStock(ctx context.Context, id int64, delta int) (*model.Product, error)
+}
+
+// ProductUpdate - изменение одного продукта в UpdateMany; nil-поля не меняются.
+// Строка с Stock меньше текущего резерва пропускается, занятый SKU
+// отклоняет весь вызов с ErrSKUExists.
+type ProductUpdate struct {
+	ProductRef
+	Name        *string
+	Description *string
+	PriceMinor  *int64
+	Currency    *string
+	Stock       *int
+	// SKU: пустая строка удаляет SKU
+	SKU *string
+}
+
+// ProductRef ссылается на продукт в ожидаемой версии.
+type ProductRef struct {
+	ID      int64
+	Version int64
+}
+
+// ProductHistoryStore хранит журнал изменений продуктов.
+type ProductHistoryStore interface {
+	Add(ctx context.Context, entry *model.ProductHistoryEntry) error
+	AddMany(ctx context.Context, entries []*model.ProductHistoryEntry) error
+	List(ctx context.Context, productID int64, page, limit int) ([]model.ProductHistoryEntry, int, error)
+	ListUntil(ctx context.Context, productID int64, until time.Time) ([]model.ProductHistoryEntry, error)
+}
+
+// ProductImportJobStore хранит задачи импорта каталога, чтобы их состояние
+// было доступно с любого экземпляра сервиса.
+type ProductImportJobStore interface {
+	Create(ctx context.Context, job *model.ProductImportJob) error
+	GetByID(ctx context.Context, id int64) (*model.ProductImportJob, error)
+	Update(ctx context.Context, job *model.ProductImportJob) error
+}
+
+// ProductImageStore хранит описания файлов продуктов, само содержимое лежит
+// в storage.BlobStore. Записи стираются вместе с продуктом (Purge).
+type ProductImageStore interface {
+	Create(ctx context.Context, image *model.ProductImage) error
+	GetByID(ctx context.Context, productID, id int64) (*model.ProductImage, error)
+	// Delete возвращает удаленную запись, чтобы вызывающий удалил содержимое.
+	Delete(ctx context.Context, productID, id int64) (*model.ProductImage, error)
+	// ListByProducts возвращает файлы продуктов по возрастанию id.
+	ListByProducts(ctx context.Context, productIDs []int64) (map[int64][]model.ProductImage, error)
+}
+
+// CategoryStore хранит дерево категорий и связи продуктов с категориями.
+// Имена категорий уникальны среди соседей (ErrCategoryExists).
+type CategoryStore interface {
+	Create(ctx context.Context, category *model.Category) error
+	// GetByID возвращает категорию с путем от корня (Category.Path).
+	GetByID(ctx context.Context, id int64) (*model.Category, error)
+	List(ctx context.Context) ([]model.Category, error)
+	Rename(ctx context.Context, id int64, name string) (*model.Category, error)
+	// Move переносит категорию вместе с поддеревом под parentID (nil - в
+	// корень). Перенос в собственное поддерево возвращает ErrCategoryCycle.
+	Move(ctx context.Context, id int64, parentID *int64) (*model.Category, error)
+	// Delete удаляет категорию без подкатегорий (иначе ErrCategoryHasChildren)
+	// вместе с ее связями с продуктами.
+	Delete(ctx context.Context, id int64) error
+
+	// SetProductCategories заменяет категории продукта. Если какой-то
+	// категории нет, возвращает ErrCategoryNotFound и ничего не меняет.
+	SetProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error
+	// ProductCategories возвращает категории продуктов с путями от корня.
+	ProductCategories(ctx context.Context, productIDs []int64) (map[int64][]model.ProductCategory, error)
+}
+
+// ExchangeRateStore хранит историю курсов валют.
+type ExchangeRateStore interface {
+	// Save добавляет курс; курс той же пары с тем же RateAt заменяется.
+	Save(ctx context.Context, rate *model.ExchangeRate) error
+	// Current возвращает для каждой пары последний курс с RateAt не позже at.
+	Current(ctx context.Context, at time.Time) ([]model.ExchangeRate, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// ProductPriceStore хранит явные цены продуктов в других валютах. Цены
+// стираются вместе с продуктом (Purge).
+type ProductPriceStore interface {
+	// Set создает или заменяет цену продукта в валюте price.Currency.
+	Set(ctx context.Context, price *model.ProductPrice) error
+	Delete(ctx context.Context, productID int64, currency string) error
+	// ListByProducts возвращает цены продуктов по возрастанию кода валюты.
+	ListByProducts(ctx context.Context, productIDs []int64) (map[int64][]model.ProductPrice, error)
+}
+
+// StockReservationStore хранит резервы остатков. Сами единицы резервирует
+// ProductStore.Reserve; резервы стираются вместе с продуктом (Purge).
+type StockReservationStore interface {
+	Create(ctx context.Context, reservation *model.StockReservation) error
+	// GetByID читает резерв с primary: его состояние меняется сразу после создания.
+	GetByID(ctx context.Context, id int64) (*model.StockReservation, error)
+	// Finish переводит активный резерв в status. Если резерв уже не активен
+	// (его подтвердили, сняли или он истек), возвращает ErrReservationNotActive.
+	Finish(ctx context.Context, id int64, status string) (*model.StockReservation, error)
+	// Expire переводит в expired до limit активных резервов, истекших к now,
+	// и возвращает их.
+	Expire(ctx context.Context, now time.Time, limit int) ([]model.StockReservation, error)
+}
+
+// StockMovementStore - журнал движений остатка. Записи только добавляются
+// и не стираются вместе с продуктом.
+type StockMovementStore interface {
+	Add(ctx context.Context, movement *model.StockMovement) error
+	AddMany(ctx context.Context, movements []*model.StockMovement) error
+	// List возвращает движения продукта, новые первыми.
+	List(ctx context.Context, productID int64, page, limit int) ([]model.StockMovement, int, error)
+}
+
+type UserStore interface {
+	Create(ctx context.Context, user *model.User) error
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByID(ctx context.Context, id int64) (*model.User, error)
+	Exists(ctx context.Context, username string) (bool, error)
+	// SetAdmin выдает или отзывает права администратора; ErrUserNotFound,
+	// если пользователя нет.
+	SetAdmin(ctx context.Context, username string, isAdmin bool) (*model.User, error)
+}
+
+// TxManager выполняет fn в транзакции, доступной репозиториям через ctx.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Stores объединяет хранилища выбранного бэкенда (STORAGE_BACKEND).
+type Stores struct {
+	Users          UserStore
+	Products       ProductStore
+	ProductHistory ProductHistoryStore
+	ProductImports ProductImportJobStore
+	Categories     CategoryStore
+	ProductImages  ProductImageStore
+	ProductPrices  ProductPriceStore
+	ExchangeRates  ExchangeRateStore
+	Reservations   StockReservationStore
+	StockMovements StockMovementStore
+	Tx             TxManager
+}
+
+func NewStores(txManager TxManager) Stores {
+	return Stores{
+		Users:          NewUserRepository(),
+		Products:       NewProductRepository(),
+		ProductHistory: NewProductHistoryRepository(),
+		ProductImports: NewProductImportJobRepository(),
+		Categories:     NewCategoryRepository(),
+		ProductImages:  NewProductImageRepository(),
+		ProductPrices:  NewProductPriceRepository(),
+		ExchangeRates:  NewExchangeRateRepository(),
+		Reservations:   NewStockReservationRepository(),
+		StockMovements: NewStockMovementRepository(),
+		Tx:             txManager,
+	}
+}
+
+func NewMemoryStores() Stores {
+	categories := NewMemoryCategoryRepository()
+	images := NewMemoryProductImageRepository()
+	prices := NewMemoryProductPriceRepository()
+	reservations := NewMemoryStockReservationRepository()
+	return Stores{
+		Users:          NewMemoryUserRepository(),
+		Products:       NewMemoryProductRepository(categories, images, prices, reservations),
+		ProductHistory: NewMemoryProductHistoryRepository(),
+		ProductImports: NewMemoryProductImportJobRepository(),
+		Categories:     categories,
+		ProductImages:  images,
+		ProductPrices:  prices,
+		ExchangeRates:  NewMemoryExchangeRateRepository(),
+		Reservations:   reservations,
+		StockMovements: NewMemoryStockMovementRepository(),
+		Tx:             NewMemoryTxManager(),
+	}
+}
+
+// observeQuery запускает замер длительности операции репозитория,
+// например "product.list". Использование: defer observeQuery("product.list")().
+func observeQuery(operation string) func() {
+	timer := prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues(operation))
+	return func() { timer.ObserveDuration() }
+}
+
+// queryError сохраняет в цепочке ошибку контекста, если запрос прерван отменой
+// или дедлайном: драйвер может вернуть вместо нее собственную ошибку.
+func queryError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	return err
+}
+
+// readWithFallback выполняет читающую функцию на реплике (или primary, см.
+// database.ReadQuerierFrom). Если реплика вернула ошибку, не связанную с
+// отменой запроса или отсутствием строк, она исключается из ротации,
+// а чтение повторяется на primary.
+func readWithFallback(ctx context.Context, primary *sql.DB, replicas *database.ReplicaSet, dialect database.Dialect, fn func(q database.Querier) error) error {
+	q, db := database.ReadQuerierFrom(ctx, primary, replicas)
+	if db == primary {
+		return retryRead(ctx, dialect, func() error { return fn(q) })
+	}
+
+	err := fn(q)
+	if err == nil || ctx.Err() != nil || errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	replicas.MarkFailed(db)
+	return retryRead(ctx, dialect, func() error { return fn(primary) })
+}
+
+// retryRead повторяет идемпотентное чтение один раз, если соединение
+// оборвалось (рестарт или failover БД). Внутри транзакции повтор невозможен:
+// она уже прервана, и решение принимает TxManager.
+func retryRead(ctx context.Context, dialect database.Dialect, fn func() error) error {
+	err := fn()
+	if err == nil || ctx.Err() != nil || database.InTx(ctx) || !dialect.IsConnectionError(err) {
+		return err
+	}
+	return fn()
+}
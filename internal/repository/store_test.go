@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"demo-service/internal/database"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRetryRead(t *testing.T) {
+	dialect, _, err := database.ParseDatabaseURL("postgres://localhost/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		err   error
+		calls int
+	}{
+		{"success", context.Background(), nil, 1},
+		{"broken connection", context.Background(), fmt.Errorf("query: %w", driver.ErrBadConn), 2},
+		{"other error", context.Background(), errors.New("syntax error"), 1},
+		{"canceled", canceled, driver.ErrBadConn, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			got := retryRead(tt.ctx, dialect, func() error {
+				calls++
+				return tt.err
+			})
+			if calls != tt.calls || !errors.Is(got, tt.err) {
+				t.Fatalf("retryRead: %d calls, %v; want %d calls", calls, got, tt.calls)
+			}
+		})
+	}
+}
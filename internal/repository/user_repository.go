@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"demo-service/internal/database"
 	"demo-service/internal/model"
@@ -8,74 +9,125 @@ import (
 	"fmt"
 )
 
+const userColumns = `id, username, password_hash, is_admin, created_at`
+
 type UserRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	replicas *database.ReplicaSet
+	dialect  database.Dialect
 }
 
 func NewUserRepository() *UserRepository {
 	return &UserRepository{
-		db: database.DB,
+		db:       database.DB,
+		replicas: database.Replicas,
+		dialect:  database.CurrentDialect,
 	}
 }
 
-func (r *UserRepository) Create(user *model.User) error {
-	query := `INSERT INTO users (username, password_hash) VALUES (?, ?)`
-	result, err := r.db.Exec(query, user.Username, user.PasswordHash)
-	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
-	}
+func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
+	defer observeQuery("user.create")()
 
-	id, err := result.LastInsertId()
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO users (username, password_hash) VALUES (?, ?) RETURNING id, created_at`
+	err := database.QuerierFrom(ctx, r.db).
+		QueryRowContext(ctx, r.dialect.Rebind(query), user.Username, user.PasswordHash).
+		Scan(&user.ID, &user.CreatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to get last insert id: %w", err)
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrUserExists
+		}
+		return fmt.Errorf("failed to create user: %w", queryError(ctx, err))
 	}
 
-	user.ID = id
 	return nil
 }
 
-func (r *UserRepository) GetByUsername(username string) (*model.User, error) {
-	query := `SELECT id, username, password_hash, created_at FROM users WHERE username = ?`
-	row := r.db.QueryRow(query, username)
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	defer observeQuery("user.get_by_username")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = ?`
 
 	user := &model.User{}
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+	err := retryRead(ctx, r.dialect, func() error {
+		row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), username)
+		return scanUser(row, user)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", queryError(ctx, err))
 	}
 
 	return user, nil
 }
 
-func (r *UserRepository) GetByID(id int64) (*model.User, error) {
-	query := `SELECT id, username, password_hash, created_at FROM users WHERE id = ?`
-	row := r.db.QueryRow(query, id)
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	defer observeQuery("user.get_by_id")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
 
 	user := &model.User{}
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+	err := readWithFallback(ctx, r.db, r.replicas, r.dialect, func(q database.Querier) error {
+		row := q.QueryRowContext(ctx, r.dialect.Rebind(query), id)
+		return scanUser(row, user)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", queryError(ctx, err))
 	}
 
 	return user, nil
 }
 
-func (r *UserRepository) Exists(username string) (bool, error) {
+func (r *UserRepository) Exists(ctx context.Context, username string) (bool, error) {
+	defer observeQuery("user.exists")()
+
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT COUNT(*) FROM users WHERE username = ?`
 	var count int
-	err := r.db.QueryRow(query, username).Scan(&count)
+	err := retryRead(ctx, r.dialect, func() error {
+		return database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), username).Scan(&count)
+	})
 	if err != nil {
-		return false, fmt.Errorf("failed to check user existence: %w", err)
+		return false, fmt.Errorf("failed to check user existence: %w", queryError(ctx, err))
 	}
 	return count > 0, nil
 }
 
+// SetAdmin выдает или отзывает права администратора.
+func (r *UserRepository) SetAdmin(ctx context.Context, username string, isAdmin bool) (*model.User, error) {
+	defer observeQuery("user.set_admin")()
 
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
 
+	query := `UPDATE users SET is_admin = ? WHERE username = ? RETURNING ` + userColumns
+	row := database.QuerierFrom(ctx, r.db).QueryRowContext(ctx, r.dialect.Rebind(query), isAdmin, username)
 
+	user := &model.User{}
+	if err := scanUser(row, user); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update user: %w", queryError(ctx, err))
+	}
+	return user, nil
+}
+
+func scanUser(row rowScanner, user *model.User) error {
+	return row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.IsAdmin, &user.CreatedAt)
+}
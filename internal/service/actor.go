@@ -0,0 +1,18 @@
+package service
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor сохраняет в контексте ID пользователя, выполняющего операцию.
+// Он попадает в историю изменений продуктов.
+func WithActor(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+func actorFrom(ctx context.Context) *int64 {
+	if userID, ok := ctx.Value(actorKey{}).(int64); ok {
+		return &userID
+	}
+	return nil
+}
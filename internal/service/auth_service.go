@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"demo-service/internal/config"
 	"demo-service/internal/model"
 	"demo-service/internal/repository"
@@ -11,46 +12,59 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo  repository.UserStore
+	txManager repository.TxManager
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
+func NewAuthService(userRepo repository.UserStore, txManager repository.TxManager) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		txManager: txManager,
 	}
 }
 
-func (s *AuthService) Register(req *model.RegisterRequest) (*model.AuthResponse, error) {
-	// Проверяем, существует ли пользователь
-	exists, err := s.userRepo.Exists(req.Username)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check user existence: %w", err)
-	}
-	if exists {
-		return nil, errors.New("username already exists")
-	}
-
-	// Хешируем пароль
+func (s *AuthService) Register(ctx context.Context, req *model.RegisterRequest) (*model.AuthResponse, error) {
+	// Хешируем пароль до начала транзакции, чтобы не держать ее открытой
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	// Создаем пользователя
 	user := &model.User{
 		Username:     req.Username,
 		PasswordHash: string(hashedPassword),
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	// Проверка и создание выполняются атомарно
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := s.userRepo.Exists(ctx, req.Username)
+		if err != nil {
+			return fmt.Errorf("failed to check user existence: %w", err)
+		}
+		if exists {
+			return repository.ErrUserExists
+		}
+
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrUserExists) {
+				return err
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Генерируем JWT токен
 	token, err := jwt.GenerateToken(
 		user.ID,
 		user.Username,
+		user.IsAdmin,
 		config.AppConfig.JWTSecret,
 		config.AppConfig.JWTExpiry,
 	)
@@ -64,23 +78,27 @@ func (s *AuthService) Register(req *model.RegisterRequest) (*model.AuthResponse,
 	}, nil
 }
 
-func (s *AuthService) Login(req *model.LoginRequest) (*model.AuthResponse, error) {
+func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest) (*model.AuthResponse, error) {
 	// Получаем пользователя
-	user, err := s.userRepo.GetByUsername(req.Username)
+	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Проверяем пароль
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
 	}
 
 	// Генерируем JWT токен
 	token, err := jwt.GenerateToken(
 		user.ID,
 		user.Username,
+		user.IsAdmin,
 		config.AppConfig.JWTSecret,
 		config.AppConfig.JWTExpiry,
 	)
@@ -97,3 +115,49 @@ func (s *AuthService) Login(req *model.LoginRequest) (*model.AuthResponse, error
 
 
 
+
+// IsAdmin возвращает текущие права администратора пользователя. Удаленный
+// пользователь администратором не считается.
+func (s *AuthService) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.IsAdmin, nil
+}
+
+// EnsureAdmin создает пользователя username, если его нет, и выдает ему
+// права администратора. Пароль существующего пользователя не меняется.
+func (s *AuthService) EnsureAdmin(ctx context.Context, username, password string) (*model.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	var user *model.User
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := s.userRepo.Exists(ctx, username)
+		if err != nil {
+			return fmt.Errorf("failed to check user existence: %w", err)
+		}
+		if !exists {
+			err := s.userRepo.Create(ctx, &model.User{Username: username, PasswordHash: string(hashedPassword)})
+			if err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+		}
+
+		user, err = s.userRepo.SetAdmin(ctx, username, true)
+		if err != nil {
+			return fmt.Errorf("failed to grant administrator rights: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownCategory - запрос ссылается на несуществующую категорию
+// (родителя или категорию продукта).
+var ErrUnknownCategory = errors.New("unknown category")
+
+type CategoryService struct {
+	categoryRepo repository.CategoryStore
+	txManager    repository.TxManager
+}
+
+func NewCategoryService(categoryRepo repository.CategoryStore, txManager repository.TxManager) *CategoryService {
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+		txManager:    txManager,
+	}
+}
+
+func (s *CategoryService) Create(ctx context.Context, req *model.CreateCategoryRequest) (*model.Category, error) {
+	category := &model.Category{Name: req.Name, ParentID: req.ParentID}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.checkParent(ctx, req.ParentID); err != nil {
+			return err
+		}
+		return s.categoryRepo.Create(ctx, category)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+	return category, nil
+}
+
+func (s *CategoryService) GetByID(ctx context.Context, id int64) (*model.Category, error) {
+	category, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return category, nil
+}
+
+// Tree возвращает все категории деревом; соседи упорядочены по имени.
+func (s *CategoryService) Tree(ctx context.Context) (*model.CategoryTreeResponse, error) {
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	children := make(map[int64][]model.Category)
+	var roots []model.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var build func(level []model.Category) []model.CategoryNode
+	build = func(level []model.Category) []model.CategoryNode {
+		nodes := make([]model.CategoryNode, 0, len(level))
+		for _, category := range level {
+			nodes = append(nodes, model.CategoryNode{
+				Category: category,
+				Children: build(children[category.ID]),
+			})
+		}
+		return nodes
+	}
+	return &model.CategoryTreeResponse{Categories: build(roots)}, nil
+}
+
+func (s *CategoryService) Rename(ctx context.Context, id int64, req *model.UpdateCategoryRequest) (*model.Category, error) {
+	category, err := s.categoryRepo.Rename(ctx, id, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename category: %w", err)
+	}
+	return category, nil
+}
+
+// Move переносит категорию вместе с поддеревом: продукты подкатегорий
+// остаются в них, меняются только пути.
+func (s *CategoryService) Move(ctx context.Context, id int64, req *model.MoveCategoryRequest) (*model.Category, error) {
+	var category *model.Category
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.checkParent(ctx, req.ParentID); err != nil {
+			return err
+		}
+
+		var err error
+		category, err = s.categoryRepo.Move(ctx, id, req.ParentID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to move category: %w", err)
+	}
+	return category, nil
+}
+
+func (s *CategoryService) Delete(ctx context.Context, id int64) error {
+	if err := s.categoryRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	return nil
+}
+
+func (s *CategoryService) checkParent(ctx context.Context, parentID *int64) error {
+	if parentID == nil {
+		return nil
+	}
+	if _, err := s.categoryRepo.GetByID(ctx, *parentID); err != nil {
+		if errors.Is(err, repository.ErrCategoryNotFound) {
+			return fmt.Errorf("%w: parent %d", ErrUnknownCategory, *parentID)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// productCursor - содержимое курсора: значения полей сортировки граничной
+// строки и отпечаток запроса, чтобы курсор нельзя было применить к другой
+// сортировке или другим фильтрам.
+type productCursor struct {
+	Params     string     `json:"p"`
+	Backward   bool       `json:"b,omitempty"`
+	ID         int64      `json:"id"`
+	Name       *string    `json:"name,omitempty"`
+	PriceMinor *int64     `json:"price_minor,omitempty"`
+	Stock      *int       `json:"stock,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// encodeCursor возвращает курсор вида base64url(JSON).base64url(HMAC-SHA256).
+func encodeCursor(opts repository.ProductListOptions, boundary *model.Product, backward bool) string {
+	cursor := productCursor{
+		Params:   listFingerprint(opts),
+		Backward: backward,
+		ID:       boundary.ID,
+	}
+	for _, field := range repository.EffectiveProductSort(opts.Sort) {
+		switch field.Field {
+		case "name":
+			cursor.Name = &boundary.Name
+		case "price":
+			cursor.PriceMinor = &boundary.PriceMinor
+		case "stock":
+			cursor.Stock = &boundary.Stock
+		case "created_at":
+			cursor.CreatedAt = &boundary.CreatedAt
+		case "updated_at":
+			cursor.UpdatedAt = &boundary.UpdatedAt
+		}
+	}
+
+	payload, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signCursor(encoded))
+}
+
+// decodeCursor проверяет подпись и соответствие курсора параметрам запроса.
+func decodeCursor(token string, opts repository.ProductListOptions) (*repository.Keyset, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, signCursor(encoded)) {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor productCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Params != listFingerprint(opts) {
+		return nil, fmt.Errorf("%w: cursor was issued for different sort or filters", ErrInvalidCursor)
+	}
+
+	keyset := &repository.Keyset{Backward: cursor.Backward}
+	keyset.After.ID = cursor.ID
+	for _, field := range repository.EffectiveProductSort(opts.Sort) {
+		missing := false
+		switch field.Field {
+		case "name":
+			missing = cursor.Name == nil
+			if !missing {
+				keyset.After.Name = *cursor.Name
+			}
+		case "price":
+			missing = cursor.PriceMinor == nil
+			if !missing {
+				keyset.After.PriceMinor = *cursor.PriceMinor
+			}
+		case "stock":
+			missing = cursor.Stock == nil
+			if !missing {
+				keyset.After.Stock = *cursor.Stock
+			}
+		case "created_at":
+			missing = cursor.CreatedAt == nil
+			if !missing {
+				keyset.After.CreatedAt = *cursor.CreatedAt
+			}
+		case "updated_at":
+			missing = cursor.UpdatedAt == nil
+			if !missing {
+				keyset.After.UpdatedAt = *cursor.UpdatedAt
+			}
+		}
+		if missing {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return keyset, nil
+}
+
+func signCursor(encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.CursorSecret))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// listFingerprint - короткий хеш параметров, влияющих на порядок и состав
+// выборки. Limit в него не входит: размер страницы можно менять на ходу.
+func listFingerprint(opts repository.ProductListOptions) string {
+	params, _ := json.Marshal(struct {
+		Query          string
+		IncludeDeleted bool
+		Filter         repository.ProductFilter
+		Sort           []repository.SortField
+	}{opts.Query, opts.IncludeDeleted, opts.Filter, repository.EffectiveProductSort(opts.Sort)})
+
+	sum := sha256.Sum256(params)
+	return base64.RawURLEncoding.EncodeToString(sum[:9])
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Курсов в одной загрузке: больше обычно означает ошибку в файле
+const maxExchangeRatesPerUpload = 1000
+
+// ErrInvalidExchangeRate - курс из загрузки не прошел проверку; загрузка
+// отклоняется целиком.
+var ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+
+type ExchangeRateService struct {
+	rateRepo  repository.ExchangeRateStore
+	txManager repository.TxManager
+}
+
+func NewExchangeRateService(rateRepo repository.ExchangeRateStore, txManager repository.TxManager) *ExchangeRateService {
+	return &ExchangeRateService{
+		rateRepo:  rateRepo,
+		txManager: txManager,
+	}
+}
+
+// Current возвращает курсы, действующие в момент at.
+func (s *ExchangeRateService) Current(ctx context.Context, at time.Time) (*model.ExchangeRateListResponse, error) {
+	rates, err := s.rateRepo.Current(ctx, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	if rates == nil {
+		rates = []model.ExchangeRate{}
+	}
+	return &model.ExchangeRateListResponse{Rates: rates}, nil
+}
+
+// Save сохраняет курсы, введенные вручную.
+func (s *ExchangeRateService) Save(ctx context.Context, req *model.SetExchangeRatesRequest) ([]model.ExchangeRate, error) {
+	if len(req.Rates) > maxExchangeRatesPerUpload {
+		return nil, fmt.Errorf("%w: at most %d rates per upload", ErrInvalidExchangeRate, maxExchangeRatesPerUpload)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	rates := make([]model.ExchangeRate, len(req.Rates))
+	for i := range req.Rates {
+		rate, err := newExchangeRate(&req.Rates[i], now)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rates[%d]: %v", ErrInvalidExchangeRate, i, err)
+		}
+		rates[i] = *rate
+	}
+	return s.save(ctx, rates)
+}
+
+// ImportCSV сохраняет курсы из CSV с заголовком base,quote,rate и
+// необязательной колонкой rate_at (RFC 3339).
+func (s *ExchangeRateService) ImportCSV(ctx context.Context, r io.Reader) ([]model.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: CSV file is empty", ErrInvalidExchangeRate)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExchangeRate, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"base", "quote", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: CSV header must contain base, quote and rate columns", ErrInvalidExchangeRate)
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	var rates []model.ExchangeRate
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExchangeRate, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rates) == maxExchangeRatesPerUpload {
+			return nil, fmt.Errorf("%w: at most %d rates per upload", ErrInvalidExchangeRate, maxExchangeRatesPerUpload)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		input := model.ExchangeRateInput{Base: field("base"), Quote: field("quote"), Rate: model.Amount(field("rate"))}
+		if value := field("rate_at"); value != "" {
+			rateAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: rate_at must be an RFC 3339 timestamp", ErrInvalidExchangeRate, line)
+			}
+			input.RateAt = &rateAt
+		}
+
+		rate, err := newExchangeRate(&input, now)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidExchangeRate, line, err)
+		}
+		rates = append(rates, *rate)
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: CSV file has no rates", ErrInvalidExchangeRate)
+	}
+	return s.save(ctx, rates)
+}
+
+func (s *ExchangeRateService) Delete(ctx context.Context, id int64) error {
+	if err := s.rateRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete exchange rate: %w", err)
+	}
+	return nil
+}
+
+// save сохраняет курсы одной транзакцией.
+func (s *ExchangeRateService) save(ctx context.Context, rates []model.ExchangeRate) ([]model.ExchangeRate, error) {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		for i := range rates {
+			if err := s.rateRepo.Save(ctx, &rates[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save exchange rates: %w", err)
+	}
+
+	// Курс, повторенный в загрузке, заменил предыдущий: отдаем только итоговый
+	saved := make([]model.ExchangeRate, 0, len(rates))
+	for i := len(rates) - 1; i >= 0; i-- {
+		if !slices.ContainsFunc(saved, func(rate model.ExchangeRate) bool { return rate.ID == rates[i].ID }) {
+			saved = append(saved, rates[i])
+		}
+	}
+	slices.Reverse(saved)
+	return saved, nil
+}
+
+// newExchangeRate проверяет курс из запроса; без rate_at он действует с now.
+func newExchangeRate(input *model.ExchangeRateInput, now time.Time) (*model.ExchangeRate, error) {
+	base := strings.ToUpper(strings.TrimSpace(input.Base))
+	quote := strings.ToUpper(strings.TrimSpace(input.Quote))
+	for _, currency := range []string{base, quote} {
+		if !model.ValidCurrency(currency) {
+			return nil, fmt.Errorf("unknown currency %q", currency)
+		}
+	}
+	if base == quote {
+		return nil, errors.New("base and quote currencies must differ")
+	}
+
+	rate, err := model.ParseAmount(string(input.Rate))
+	if err != nil {
+		return nil, errors.New("rate must be a decimal number")
+	}
+	if value, err := rate.Rat(); err != nil || value.Sign() <= 0 {
+		return nil, errors.New("rate must be greater than 0")
+	}
+
+	rateAt := now
+	if input.RateAt != nil {
+		rateAt = input.RateAt.UTC().Truncate(time.Microsecond)
+	}
+	return &model.ExchangeRate{Base: base, Quote: quote, Rate: rate, RateAt: rateAt}, nil
+}
@@ -0,0 +1,558 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errBatchRolledBack откатывает атомарный пакет, в котором отклонена операция
+var errBatchRolledBack = errors.New("product batch rolled back")
+
+// productBatch - состояние пакета: Status == 0 означает, что операция еще
+// не выполнена и не отклонена.
+type productBatch struct {
+	ops     []model.ProductBatchOperation
+	results []model.ProductBatchResult
+	before  map[int64]*model.Product
+	// Проверенные prepare записи по индексу операции
+	creates   map[int]*model.Product
+	updates   map[int]repository.ProductUpdate
+	history   []*model.ProductHistoryEntry
+	movements []*model.StockMovement
+}
+
+// Batch выполняет пакет операций. Операции группируются по типу и
+// выполняются многострочными запросами; одинаковый id в двух операциях
+// запрещен, поэтому порядок групп не влияет на результат.
+func (s *ProductService) Batch(ctx context.Context, req *model.ProductBatchRequest) (*model.ProductBatchResponse, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = model.BatchModeAtomic
+	}
+
+	b := &productBatch{
+		ops:     req.Operations,
+		results: make([]model.ProductBatchResult, len(req.Operations)),
+	}
+	for i := range b.results {
+		b.results[i].Index = i
+	}
+	b.validate()
+
+	var err error
+	if mode == model.BatchModeAtomic {
+		err = s.applyAtomic(ctx, b)
+	} else {
+		err = s.applyBestEffort(ctx, b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply product batch: %w", err)
+	}
+
+	response := &model.ProductBatchResponse{Mode: mode, Results: b.results}
+	for _, result := range b.results {
+		if result.Status < http.StatusBadRequest {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	return response, nil
+}
+
+// applyAtomic выполняет пакет в одной транзакции и откатывает ее при первой
+// отклоненной операции; остальные получают 424 Failed Dependency. Все
+// проверки выполняются до первой записи: хранилище в памяти не откатывает
+// транзакции.
+func (s *ProductService) applyAtomic(ctx context.Context, b *productBatch) error {
+	validated := slices.Clone(b.results)
+	for attempt := 1; !b.failed(); attempt++ {
+		err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			// TxManager может повторить транзакцию - начинаем с чистого состояния
+			b.results = slices.Clone(validated)
+			b.history = nil
+			b.movements = nil
+
+			if err := s.loadBatch(ctx, b); err != nil {
+				return err
+			}
+			b.prepare()
+			for _, op := range []string{model.BatchOpCreate, model.BatchOpUpdate, model.BatchOpDelete} {
+				if b.failed() {
+					return errBatchRolledBack
+				}
+				if err := s.applyBatchOp(ctx, b, op); err != nil {
+					return err
+				}
+			}
+			if b.failed() {
+				return errBatchRolledBack
+			}
+			return s.recordBatchHistory(ctx, b)
+		})
+		if err != nil && !errors.Is(err, errBatchRolledBack) {
+			return err
+		}
+		// Пакет откатился только из-за продуктов, измененных параллельно
+		// без указанной клиентом версии: как и одиночное изменение, повторяем
+		if err == nil || attempt >= maxConcurrentUpdateAttempts || !b.onlyConflicts() {
+			break
+		}
+		b.results = slices.Clone(validated)
+	}
+
+	if b.failed() {
+		for i := range b.results {
+			if b.results[i].Status < http.StatusBadRequest {
+				b.results[i] = model.ProductBatchResult{
+					Index:  i,
+					Status: http.StatusFailedDependency,
+					Error:  "Not applied: another operation in the batch failed",
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// applyBestEffort выполняет каждую группу операций в своей транзакции.
+// Ошибка БД в группе отмечает ее операции статусом 500, не затрагивая другие.
+func (s *ProductService) applyBestEffort(ctx context.Context, b *productBatch) error {
+	if err := s.loadBatch(ctx, b); err != nil {
+		return err
+	}
+	b.prepare()
+
+	for _, op := range []string{model.BatchOpCreate, model.BatchOpUpdate, model.BatchOpDelete} {
+		for attempt := 1; ; attempt++ {
+			pending := slices.Clone(b.results)
+			err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+				b.results = slices.Clone(pending)
+				b.history = nil
+				b.movements = nil
+
+				if err := s.applyBatchOp(ctx, b, op); err != nil {
+					return err
+				}
+				return s.recordBatchHistory(ctx, b)
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				logrus.Errorf("Failed to apply %s operations of product batch: %v", op, err)
+				b.results = pending
+				for i, batchOp := range b.ops {
+					if batchOp.Op == op && b.results[i].Status == 0 {
+						b.reject(i, http.StatusInternalServerError, "Failed to apply operation")
+					}
+				}
+				break
+			}
+
+			// Операции без версии, продукт которых изменили после
+			// loadBatch, перечитываются и выполняются снова
+			conflicts := b.conflicts(op)
+			if len(conflicts) == 0 || attempt >= maxConcurrentUpdateAttempts {
+				break
+			}
+			for _, i := range conflicts {
+				b.results[i] = model.ProductBatchResult{Index: i}
+			}
+			if err := s.loadBatch(ctx, b); err != nil {
+				return err
+			}
+			b.prepare()
+		}
+	}
+	return nil
+}
+
+func (s *ProductService) applyBatchOp(ctx context.Context, b *productBatch, op string) error {
+	switch op {
+	case model.BatchOpCreate:
+		return s.createBatch(ctx, b)
+	case model.BatchOpUpdate:
+		return s.updateBatch(ctx, b)
+	default:
+		return s.deleteBatch(ctx, b)
+	}
+}
+
+// loadBatch читает изменяемые продукты: их состояние нужно для истории, а
+// версия - как условие изменения, чтобы параллельная запись не исказила "before".
+func (s *ProductService) loadBatch(ctx context.Context, b *productBatch) error {
+	if err := s.checkBatchSKUs(ctx, b); err != nil {
+		return err
+	}
+
+	var ids []int64
+	for i, op := range b.ops {
+		if b.results[i].Status == 0 && op.Op != model.BatchOpCreate {
+			ids = append(ids, op.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	before, err := s.productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	b.before = before
+
+	for i, op := range b.ops {
+		if b.results[i].Status != 0 || op.Op == model.BatchOpCreate {
+			continue
+		}
+		product, ok := before[op.ID]
+		switch {
+		case !ok:
+			b.reject(i, http.StatusNotFound, "Product not found")
+		case op.Version != 0 && product.Version != op.Version:
+			b.reject(i, http.StatusPreconditionFailed, "Product was modified, reload it and retry")
+		}
+	}
+	return nil
+}
+
+// checkBatchSKUs отклоняет операции, задающие SKU другого продукта: иначе
+// нарушение уникальности отклонило бы всю группу операций.
+func (s *ProductService) checkBatchSKUs(ctx context.Context, b *productBatch) error {
+	var skus []string
+	for i, op := range b.ops {
+		if sku := NormalizeSKU(stringValue(op.SKU)); b.results[i].Status == 0 && sku != "" {
+			skus = append(skus, sku)
+		}
+	}
+	if len(skus) == 0 {
+		return nil
+	}
+
+	products, err := s.productRepo.GetBySKUs(ctx, skus)
+	if err != nil {
+		return err
+	}
+	owners := make(map[string]int64, len(products))
+	for _, product := range products {
+		owners[*product.SKU] = product.ID
+	}
+
+	for i, op := range b.ops {
+		if b.results[i].Status != 0 {
+			continue
+		}
+		if owner, ok := owners[NormalizeSKU(stringValue(op.SKU))]; ok && owner != op.ID {
+			b.reject(i, http.StatusConflict, "Product with this SKU already exists")
+		}
+	}
+	return nil
+}
+
+// prepare проверяет и собирает записи создания и изменения. Отклонения
+// видны до первой записи, поэтому атомарный пакет с ошибкой ничего не пишет.
+func (b *productBatch) prepare() {
+	b.creates = make(map[int]*model.Product)
+	b.updates = make(map[int]repository.ProductUpdate)
+	for i, op := range b.ops {
+		if b.results[i].Status != 0 {
+			continue
+		}
+		switch op.Op {
+		case model.BatchOpCreate:
+			currency := productCurrency(stringValue(op.Currency))
+			price, err := priceMinor(op.Price, op.PriceMinor, currency)
+			if err != nil {
+				b.reject(i, http.StatusBadRequest, err.Error())
+				continue
+			}
+			product := &model.Product{Name: *op.Name, PriceMinor: price, Currency: currency, SKU: skuPtr(stringValue(op.SKU))}
+			if op.Description != nil {
+				product.Description = *op.Description
+			}
+			if op.Stock != nil {
+				product.Stock = *op.Stock
+			}
+			b.creates[i] = product
+		case model.BatchOpUpdate:
+			before := b.before[op.ID]
+			if op.Name == nil && op.Description == nil && op.Price == nil && op.PriceMinor == nil && op.Currency == nil && op.Stock == nil && op.SKU == nil {
+				b.succeed(i, http.StatusOK, before)
+				continue
+			}
+			price, currency, err := priceChange(before, op.Price, op.PriceMinor, op.Currency)
+			if err != nil {
+				b.reject(i, http.StatusBadRequest, err.Error())
+				continue
+			}
+			if op.Stock != nil && *op.Stock < before.Reserved {
+				b.reject(i, http.StatusConflict, fmt.Sprintf("stock cannot be lower than the %d reserved units", before.Reserved))
+				continue
+			}
+			b.updates[i] = repository.ProductUpdate{
+				ProductRef:  repository.ProductRef{ID: op.ID, Version: before.Version},
+				Name:        op.Name,
+				Description: op.Description,
+				PriceMinor:  price,
+				Currency:    currency,
+				Stock:       op.Stock,
+				SKU:         batchSKU(op.SKU),
+			}
+		}
+	}
+}
+
+func (s *ProductService) createBatch(ctx context.Context, b *productBatch) error {
+	var (
+		products []*model.Product
+		indexes  []int
+	)
+	for i := range b.ops {
+		if product, ok := b.creates[i]; ok && b.results[i].Status == 0 {
+			products = append(products, product)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	if err := s.productRepo.CreateMany(ctx, products); err != nil {
+		return err
+	}
+	for n, product := range products {
+		b.succeed(indexes[n], http.StatusCreated, product)
+		b.addHistory(ctx, product.ID, product.Version, model.ProductOpCreate, productChanges(nil, product))
+		b.addMovement(ctx, nil, product, model.StockReasonInitial)
+	}
+	return nil
+}
+
+func (s *ProductService) updateBatch(ctx context.Context, b *productBatch) error {
+	var updates []repository.ProductUpdate
+	indexes := make(map[int64]int)
+	for i, op := range b.ops {
+		if update, ok := b.updates[i]; ok && b.results[i].Status == 0 {
+			updates = append(updates, update)
+			indexes[op.ID] = i
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	updated, err := s.productRepo.UpdateMany(ctx, updates)
+	if err != nil {
+		return err
+	}
+	for n := range updated {
+		product := &updated[n]
+		b.succeed(indexes[product.ID], http.StatusOK, product)
+		b.addHistory(ctx, product.ID, product.Version, model.ProductOpUpdate, productChanges(b.before[product.ID], product))
+		b.addMovement(ctx, b.before[product.ID], product, model.StockReasonAdjustment)
+	}
+	b.rejectPending(indexes)
+	return nil
+}
+
+func (s *ProductService) deleteBatch(ctx context.Context, b *productBatch) error {
+	var refs []repository.ProductRef
+	indexes := make(map[int64]int)
+	for i, op := range b.ops {
+		if b.results[i].Status != 0 || op.Op != model.BatchOpDelete {
+			continue
+		}
+		refs = append(refs, repository.ProductRef{ID: op.ID, Version: b.before[op.ID].Version})
+		indexes[op.ID] = i
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	deleted, err := s.productRepo.DeleteMany(ctx, refs)
+	if err != nil {
+		return err
+	}
+	for _, id := range deleted {
+		b.succeed(indexes[id], http.StatusNoContent, nil)
+		b.addHistory(ctx, id, b.before[id].Version+1, model.ProductOpDelete, map[string]model.FieldChange{})
+	}
+	b.rejectPending(indexes)
+	return nil
+}
+
+func (s *ProductService) recordBatchHistory(ctx context.Context, b *productBatch) error {
+	if len(b.history) > 0 {
+		if err := s.historyRepo.AddMany(ctx, b.history); err != nil {
+			return fmt.Errorf("failed to record product history: %w", err)
+		}
+	}
+	if len(b.movements) > 0 {
+		if err := s.movementRepo.AddMany(ctx, b.movements); err != nil {
+			return fmt.Errorf("failed to record stock movements: %w", err)
+		}
+	}
+	return nil
+}
+
+// validate отклоняет операции с неверными полями, повторные изменения
+// одного продукта и один SKU в нескольких операциях.
+func (b *productBatch) validate() {
+	seen := make(map[int64]int)
+	skus := make(map[string]int)
+	for i, op := range b.ops {
+		if msg := validateBatchOperation(&op); msg != "" {
+			b.reject(i, http.StatusBadRequest, msg)
+			continue
+		}
+		if sku := NormalizeSKU(stringValue(op.SKU)); sku != "" {
+			if first, ok := skus[sku]; ok {
+				b.reject(i, http.StatusBadRequest, fmt.Sprintf("SKU %s is already set by operation %d", sku, first))
+				continue
+			}
+			skus[sku] = i
+		}
+		if op.Op == model.BatchOpCreate {
+			continue
+		}
+		if first, ok := seen[op.ID]; ok {
+			b.reject(i, http.StatusBadRequest, fmt.Sprintf("Product %d is already changed by operation %d", op.ID, first))
+			continue
+		}
+		seen[op.ID] = i
+	}
+}
+
+// validateBatchOperation повторяет правила CreateProductRequest и
+// UpdateProductRequest. Цену в минимальные единицы переводят createBatch и
+// updateBatch: для изменения нужна текущая валюта продукта.
+func validateBatchOperation(op *model.ProductBatchOperation) string {
+	switch op.Op {
+	case model.BatchOpCreate:
+		if op.ID != 0 || op.Version != 0 {
+			return "id and version are not allowed for create"
+		}
+		if op.Name == nil || (op.Price == nil && op.PriceMinor == nil) {
+			return "name and price are required for create"
+		}
+	case model.BatchOpUpdate, model.BatchOpDelete:
+		if op.ID <= 0 {
+			return fmt.Sprintf("id is required for %s", op.Op)
+		}
+		if op.Version < 0 {
+			return "version must not be negative"
+		}
+		if op.Op == model.BatchOpDelete && (op.Name != nil || op.Description != nil || op.Price != nil || op.PriceMinor != nil || op.Currency != nil || op.Stock != nil || op.SKU != nil) {
+			return "product fields are not allowed for delete"
+		}
+	default:
+		return "op must be one of create, update, delete"
+	}
+
+	switch {
+	case op.Name != nil && (utf8.RuneCountInString(*op.Name) < 1 || utf8.RuneCountInString(*op.Name) > 255):
+		return "name must be 1 to 255 characters long"
+	case op.Description != nil && utf8.RuneCountInString(*op.Description) > 1000:
+		return "description must be at most 1000 characters long"
+	case op.PriceMinor != nil && *op.PriceMinor <= 0:
+		return "price_minor must be greater than 0"
+	case op.Currency != nil && *op.Currency != "" && !model.ValidCurrency(strings.ToUpper(*op.Currency)):
+		return "currency must be an ISO 4217 code"
+	case op.Stock != nil && *op.Stock < 0:
+		return "stock must not be negative"
+	case NormalizeSKU(stringValue(op.SKU)) != "" && (len(NormalizeSKU(*op.SKU)) > 64 || !model.ValidSKU(NormalizeSKU(*op.SKU))):
+		return "sku must be at most 64 letters, digits, '.', '_' or '-'"
+	}
+	return ""
+}
+
+// batchSKU переводит SKU операции в изменение: nil - без изменения,
+// пустая строка удаляет SKU.
+func batchSKU(sku *string) *string {
+	if sku == nil {
+		return nil
+	}
+	normalized := NormalizeSKU(*sku)
+	return &normalized
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// conflicts возвращает операции типа op без версии, отклоненные из-за
+// параллельного изменения продукта.
+func (b *productBatch) conflicts(op string) []int {
+	var indexes []int
+	for i, batchOp := range b.ops {
+		if batchOp.Op == op && batchOp.Version == 0 && b.results[i].Status == http.StatusPreconditionFailed {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// onlyConflicts сообщает, что все отклонения пакета - конфликты версий
+// операций без версии.
+func (b *productBatch) onlyConflicts() bool {
+	for i, op := range b.ops {
+		if b.results[i].Status >= http.StatusBadRequest && (op.Version != 0 || b.results[i].Status != http.StatusPreconditionFailed) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *productBatch) failed() bool {
+	return slices.ContainsFunc(b.results, func(r model.ProductBatchResult) bool {
+		return r.Status >= http.StatusBadRequest
+	})
+}
+
+func (b *productBatch) succeed(i, status int, product *model.Product) {
+	b.results[i] = model.ProductBatchResult{Index: i, Status: status, Product: product}
+}
+
+func (b *productBatch) reject(i, status int, msg string) {
+	b.results[i] = model.ProductBatchResult{Index: i, Status: status, Error: msg}
+}
+
+// rejectPending отклоняет операции, строки которых запрос не затронул:
+// продукт изменили или удалили после loadBatch.
+func (b *productBatch) rejectPending(indexes map[int64]int) {
+	for _, i := range indexes {
+		if b.results[i].Status == 0 {
+			b.reject(i, http.StatusPreconditionFailed, "Product was modified, reload it and retry")
+		}
+	}
+}
+
+func (b *productBatch) addHistory(ctx context.Context, productID, version int64, operation string, changes map[string]model.FieldChange) {
+	b.history = append(b.history, &model.ProductHistoryEntry{
+		ProductID: productID,
+		Version:   version,
+		ActorID:   actorFrom(ctx),
+		Operation: operation,
+		Changes:   changes,
+	})
+}
+
+// addMovement добавляет в пакет движение остатка, если остаток изменился.
+func (b *productBatch) addMovement(ctx context.Context, before, after *model.Product, reason string) {
+	if movement := stockMovement(ctx, before, after, reason, ""); movement != nil {
+		b.movements = append(b.movements, movement)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"errors"
+	"fmt"
+)
+
+// SetCategories заменяет категории продукта и возвращает его с новыми путями.
+// Пустой список убирает продукт из всех категорий.
+func (s *ProductService) SetCategories(ctx context.Context, id int64, categoryIDs []int64) (*model.Product, error) {
+	var product *model.Product
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if product, err = s.productRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		err = s.categoryRepo.SetProductCategories(ctx, id, categoryIDs)
+		if errors.Is(err, repository.ErrCategoryNotFound) {
+			return ErrUnknownCategory
+		}
+		if err != nil {
+			return err
+		}
+		return s.attachDetails(ctx, product)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set product categories: %w", err)
+	}
+	return product, nil
+}
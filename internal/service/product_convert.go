@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoExchangeRate - цену продукта не в чем пересчитать: нет ни явной цены
+// в запрошенной валюте, ни курса.
+var ErrNoExchangeRate = errors.New("no exchange rate")
+
+// Режимы округления пересчитанных цен (PRICE_ROUNDING): half_up - половина
+// вверх, half_even - к четному (банковское), down и up - к меньшему и к
+// большему по модулю.
+var priceRoundingModes = []string{"half_up", "half_even", "down", "up"}
+
+// Знаков после запятой в курсе, который показывается для обратного и
+// кросс-курса: сам пересчет точный
+const displayRateDecimals = 12
+
+// ValidatePriceRounding проверяет PRICE_ROUNDING и PRICE_ROUNDING_STEPS.
+func ValidatePriceRounding() error {
+	if !slices.Contains(priceRoundingModes, config.AppConfig.PriceRounding) {
+		return fmt.Errorf("PRICE_ROUNDING must be one of %s", strings.Join(priceRoundingModes, ", "))
+	}
+	_, err := priceRoundingSteps()
+	return err
+}
+
+// priceRoundingSteps разбирает PRICE_ROUNDING_STEPS: "CHF:5,JPY:10" - цены,
+// пересчитанные в CHF, кратны 5 раппенам, в JPY - 10 иенам. Для остальных
+// валют шаг - одна минимальная единица.
+func priceRoundingSteps() (map[string]int64, error) {
+	steps := make(map[string]int64, len(config.AppConfig.PriceRoundingSteps))
+	for _, item := range config.AppConfig.PriceRoundingSteps {
+		currency, value, ok := strings.Cut(item, ":")
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		step, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if !ok || err != nil || step < 1 || !model.ValidCurrency(currency) {
+			return nil, fmt.Errorf("PRICE_ROUNDING_STEPS: %q is not CURRENCY:minor_units", item)
+		}
+		steps[currency] = step
+	}
+	return steps, nil
+}
+
+// ConvertPrices заполняет ConvertedPrice продуктов ценой в currency на
+// момент at: явной ценой продукта в этой валюте, если она задана, иначе
+// пересчетом по курсам, действующим в at. Если для какого-то продукта нет
+// курса, возвращает ErrNoExchangeRate.
+func (s *ProductService) ConvertPrices(ctx context.Context, currency string, at time.Time, products ...*model.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	overrides, err := s.priceRepo.ListByProducts(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get product prices: %w", err)
+	}
+	// Настройки проверены при запуске (ValidatePriceRounding)
+	steps, err := priceRoundingSteps()
+	if err != nil {
+		return err
+	}
+
+	var rates exchangeRates
+	for _, product := range products {
+		if product.Currency == currency {
+			product.ConvertedPrice = &model.ConvertedPrice{
+				PriceMinor: product.PriceMinor,
+				Currency:   currency,
+				Source:     model.PriceSourceNative,
+			}
+			continue
+		}
+
+		i := slices.IndexFunc(overrides[product.ID], func(price model.ProductPrice) bool { return price.Currency == currency })
+		if i >= 0 {
+			product.ConvertedPrice = &model.ConvertedPrice{
+				PriceMinor: overrides[product.ID][i].PriceMinor,
+				Currency:   currency,
+				Source:     model.PriceSourceOverride,
+			}
+			continue
+		}
+
+		// Курсы читаются один раз и только если без них не обойтись
+		if rates == nil {
+			list, err := s.rateRepo.Current(ctx, at)
+			if err != nil {
+				return fmt.Errorf("failed to get exchange rates: %w", err)
+			}
+			rates = make(exchangeRates, len(list))
+			for _, rate := range list {
+				rates[[2]string{rate.Base, rate.Quote}] = rate
+			}
+		}
+
+		rate, rateAt, ok := rates.find(product.Currency, currency)
+		if !ok {
+			return fmt.Errorf("%w from %s to %s", ErrNoExchangeRate, product.Currency, currency)
+		}
+		minor, err := convertMinor(product.PriceMinor, product.Currency, currency, rate, steps[currency])
+		if err != nil {
+			return err
+		}
+		product.ConvertedPrice = &model.ConvertedPrice{
+			PriceMinor: minor,
+			Currency:   currency,
+			Source:     model.PriceSourceExchangeRate,
+			Rate:       formatRate(rate),
+			RateAt:     &rateAt,
+		}
+	}
+	return nil
+}
+
+// exchangeRates - действующие курсы по паре (base, quote).
+type exchangeRates map[[2]string]model.ExchangeRate
+
+// find возвращает курс from -> to и время самого старого из использованных
+// курсов. Берется курс пары, обратный к курсу обратной пары или кросс-курс
+// через PRODUCT_DEFAULT_CURRENCY.
+func (rates exchangeRates) find(from, to string) (*big.Rat, time.Time, bool) {
+	if rate, rateAt, ok := rates.pair(from, to); ok {
+		return rate, rateAt, true
+	}
+
+	via := config.AppConfig.DefaultCurrency
+	if from == via || to == via {
+		return nil, time.Time{}, false
+	}
+	first, firstAt, ok := rates.pair(from, via)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	second, secondAt, ok := rates.pair(via, to)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	if secondAt.Before(firstAt) {
+		firstAt = secondAt
+	}
+	return first.Mul(first, second), firstAt, true
+}
+
+func (rates exchangeRates) pair(from, to string) (*big.Rat, time.Time, bool) {
+	if rate, ok := rates[[2]string{from, to}]; ok {
+		if value, err := rate.Rate.Rat(); err == nil && value.Sign() > 0 {
+			return value, rate.RateAt, true
+		}
+	}
+	if rate, ok := rates[[2]string{to, from}]; ok {
+		if value, err := rate.Rate.Rat(); err == nil && value.Sign() > 0 {
+			return value.Inv(value), rate.RateAt, true
+		}
+	}
+	return nil, time.Time{}, false
+}
+
+// convertMinor пересчитывает сумму в минимальных единицах from в минимальные
+// единицы to и округляет до кратного step по PRICE_ROUNDING.
+func convertMinor(minor int64, from, to string, rate *big.Rat, step int64) (int64, error) {
+	fromExponent, _ := model.CurrencyExponent(from)
+	toExponent, _ := model.CurrencyExponent(to)
+	if step < 1 {
+		step = 1
+	}
+
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(minor), rate)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExponent-fromExponent))), nil))
+	if toExponent >= fromExponent {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+	value.Quo(value, new(big.Rat).SetInt64(step))
+
+	rounded := roundRat(value, config.AppConfig.PriceRounding)
+	rounded.Mul(rounded, big.NewInt(step))
+	if !rounded.IsInt64() {
+		return 0, fmt.Errorf("converted price in %s is too large", to)
+	}
+	return rounded.Int64(), nil
+}
+
+// roundRat округляет value до целого в режиме mode (priceRoundingModes).
+func roundRat(value *big.Rat, mode string) *big.Int {
+	quo, rem := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	// Сравниваем отброшенную дробную часть с половиной: 2*|rem| и знаменатель
+	half := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(value.Denom())
+	away := false
+	switch mode {
+	case "up":
+		away = true
+	case "down":
+	case "half_even":
+		away = half > 0 || (half == 0 && quo.Bit(0) == 1)
+	default:
+		away = half >= 0
+	}
+	if away {
+		quo.Add(quo, big.NewInt(int64(value.Sign())))
+	}
+	return quo
+}
+
+// formatRate записывает курс десятичной дробью не длиннее displayRateDecimals
+// знаков после запятой.
+func formatRate(rate *big.Rat) model.Amount {
+	text := rate.FloatString(displayRateDecimals)
+	text = strings.TrimRight(text, "0")
+	return model.Amount(strings.TrimSuffix(text, "."))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"demo-service/internal/config"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestRoundRat(t *testing.T) {
+	tests := []struct {
+		value                      string
+		halfUp, halfEven, down, up int64
+	}{
+		{"7", 7, 7, 7, 7},
+		{"2.5", 3, 2, 2, 3},
+		{"3.5", 4, 4, 3, 4},
+		{"2.4", 2, 2, 2, 3},
+		{"2.6", 3, 3, 2, 3},
+		{"-2.5", -3, -2, -2, -3},
+		{"-3.5", -4, -4, -3, -4},
+		{"-2.4", -2, -2, -2, -3},
+		{"0.0001", 0, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		value, ok := new(big.Rat).SetString(tt.value)
+		if !ok {
+			t.Fatalf("parse %s", tt.value)
+		}
+		for mode, want := range map[string]int64{"half_up": tt.halfUp, "half_even": tt.halfEven, "down": tt.down, "up": tt.up} {
+			if got := roundRat(value, mode); got.Int64() != want {
+				t.Errorf("roundRat(%s, %s) = %s, want %d", tt.value, mode, got, want)
+			}
+		}
+	}
+}
+
+func TestConvertMinor(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		from, to string
+		rate     string
+		step     int64
+		mode     string
+		want     int64
+	}{
+		{1000, "USD", "EUR", "0.9215", 1, "half_up", 922},
+		{1000, "USD", "EUR", "0.9215", 1, "half_even", 922},
+		{1000, "USD", "EUR", "0.9225", 1, "half_even", 922},
+		{1000, "USD", "EUR", "0.9215", 1, "down", 921},
+		// Разное число знаков у валют: центы в иены и иены в филсы
+		{1050, "USD", "JPY", "150", 1, "half_up", 1575},
+		{1001, "USD", "JPY", "150.3", 1, "half_up", 1505},
+		{1001, "USD", "JPY", "150.3", 1, "down", 1504},
+		{1000, "JPY", "KWD", "0.00205", 1, "half_up", 2050},
+		{1, "KWD", "JPY", "490", 1, "half_up", 0},
+		{1, "KWD", "JPY", "490", 1, "up", 1},
+		// Шаг округления: CHF кратно 5 раппенам
+		{1000, "USD", "CHF", "0.8813", 5, "half_up", 880},
+		{1000, "USD", "CHF", "0.8813", 5, "up", 885},
+		{1000, "USD", "CHF", "0.8813", 0, "half_up", 881},
+	}
+	for _, tt := range tests {
+		config.AppConfig = &config.Config{PriceRounding: tt.mode}
+		rate, ok := new(big.Rat).SetString(tt.rate)
+		if !ok {
+			t.Fatalf("parse %s", tt.rate)
+		}
+		got, err := convertMinor(tt.minor, tt.from, tt.to, rate, tt.step)
+		if err != nil {
+			t.Errorf("convertMinor(%d %s -> %s at %s): %v", tt.minor, tt.from, tt.to, tt.rate, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("convertMinor(%d %s -> %s at %s, step %d, %s) = %d, want %d",
+				tt.minor, tt.from, tt.to, tt.rate, tt.step, tt.mode, got, tt.want)
+		}
+	}
+
+	config.AppConfig = &config.Config{PriceRounding: "half_up"}
+	if _, err := convertMinor(math.MaxInt64, "USD", "JPY", big.NewRat(1000, 1), 1); err == nil {
+		t.Errorf("convertMinor did not report an overflow")
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// productCSVColumns - заголовок CSV-выгрузки. Импорт принимает тот же файл:
+// лишние колонки (sku, version, даты) он пропускает.
+var productCSVColumns = []string{"id", "sku", "name", "description", "price", "currency", "stock", "version", "created_at", "updated_at"}
+
+// Через сколько строк CSV отдавать клиенту, не дожидаясь заполнения буфера
+const exportFlushRows = 500
+
+// Export пишет в w продукты списка в формате CSV или NDJSON по мере чтения
+// из БД, не собирая выгрузку в памяти.
+func (s *ProductService) Export(ctx context.Context, opts repository.ProductListOptions, format string, w io.Writer) error {
+	var err error
+	switch format {
+	case model.ProductFormatCSV:
+		err = exportCSV(ctx, s.productRepo, opts, w)
+	case model.ProductFormatNDJSON:
+		encoder := json.NewEncoder(w)
+		err = s.productRepo.Stream(ctx, opts, func(product *model.Product) error {
+			return encoder.Encode(product)
+		})
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export products: %w", err)
+	}
+	return nil
+}
+
+func exportCSV(ctx context.Context, products repository.ProductStore, opts repository.ProductListOptions, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(productCSVColumns); err != nil {
+		return err
+	}
+
+	rows := 0
+	record := make([]string, len(productCSVColumns))
+	err := products.Stream(ctx, opts, func(product *model.Product) error {
+		record[0] = strconv.FormatInt(product.ID, 10)
+		record[1] = ""
+		if product.SKU != nil {
+			record[1] = *product.SKU
+		}
+		record[2] = product.Name
+		record[3] = product.Description
+		record[4] = model.FormatMinor(product.PriceMinor, product.Currency)
+		record[5] = product.Currency
+		record[6] = strconv.Itoa(product.Stock)
+		record[7] = strconv.FormatInt(product.Version, 10)
+		record[8] = product.CreatedAt.UTC().Format(time.RFC3339)
+		record[9] = product.UpdatedAt.UTC().Format(time.RFC3339)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+
+		if rows++; rows%exportFlushRows == 0 {
+			writer.Flush()
+			return writer.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"math"
+)
+
+// legacyPrice переводит цену, записанную в историю десятичным числом до
+// перехода на минимальные единицы, по правилу миграции 0013 и назначения
+// валюты при ее применении: сотые доли, затем знаки
+// PRODUCT_DEFAULT_CURRENCY (1500.50 JPY - 1501).
+func legacyPrice(price float64) (int64, string) {
+	currency := config.AppConfig.DefaultCurrency
+	minor := math.Round(price * 100)
+	if exponent, ok := model.CurrencyExponent(currency); ok && exponent > 2 {
+		minor *= math.Pow10(exponent - 2)
+	} else if ok && exponent < 2 {
+		minor = math.Round(minor / math.Pow10(2-exponent))
+	}
+	return int64(minor), currency
+}
+
+// productChanges сравнивает поля UpdateProductRequest двух состояний продукта.
+// before == nil означает создание: все поля попадают в изменения.
+func productChanges(before, after *model.Product) map[string]model.FieldChange {
+	changes := make(map[string]model.FieldChange)
+	if before == nil {
+		before = &model.Product{}
+		changes["name"] = model.FieldChange{After: after.Name}
+		changes["description"] = model.FieldChange{After: after.Description}
+		changes["price_minor"] = model.FieldChange{After: after.PriceMinor}
+		changes["currency"] = model.FieldChange{After: after.Currency}
+		changes["stock"] = model.FieldChange{After: after.Stock}
+		if after.SKU != nil {
+			changes["sku"] = model.FieldChange{After: *after.SKU}
+		}
+		return changes
+	}
+
+	if skuValue(before.SKU) != skuValue(after.SKU) {
+		changes["sku"] = model.FieldChange{Before: skuValue(before.SKU), After: skuValue(after.SKU)}
+	}
+
+	if before.Name != after.Name {
+		changes["name"] = model.FieldChange{Before: before.Name, After: after.Name}
+	}
+	if before.Description != after.Description {
+		changes["description"] = model.FieldChange{Before: before.Description, After: after.Description}
+	}
+	if before.PriceMinor != after.PriceMinor {
+		changes["price_minor"] = model.FieldChange{Before: before.PriceMinor, After: after.PriceMinor}
+	}
+	if before.Currency != after.Currency {
+		changes["currency"] = model.FieldChange{Before: before.Currency, After: after.Currency}
+	}
+	if before.Stock != after.Stock {
+		changes["stock"] = model.FieldChange{Before: before.Stock, After: after.Stock}
+	}
+	return changes
+}
+
+// replayHistory восстанавливает состояние продукта, последовательно применяя
+// записи истории. Возвращает nil, если продукт еще не существовал или был удален.
+func replayHistory(entries []model.ProductHistoryEntry) *model.Product {
+	var (
+		product *model.Product
+		deleted bool
+	)
+
+	for _, entry := range entries {
+		switch entry.Operation {
+		case model.ProductOpCreate, model.ProductOpSnapshot:
+			product = &model.Product{ID: entry.ProductID, CreatedAt: entry.CreatedAt}
+			deleted = false
+		case model.ProductOpDelete:
+			deleted = true
+		case model.ProductOpRestore:
+			deleted = false
+		}
+		if product == nil {
+			// Изменения до первой известной записи восстановить нельзя
+			continue
+		}
+
+		applyChanges(product, entry.Changes)
+		product.Version = entry.Version
+		product.UpdatedAt = entry.CreatedAt
+	}
+
+	if product == nil || deleted {
+		return nil
+	}
+	return product
+}
+
+func applyChanges(product *model.Product, changes map[string]model.FieldChange) {
+	for field, change := range changes {
+		switch field {
+		case "name":
+			product.Name, _ = change.After.(string)
+		case "description":
+			product.Description, _ = change.After.(string)
+		case "price":
+			// Записи до перехода на минимальные единицы
+			product.PriceMinor, product.Currency = legacyPrice(jsonNumber(change.After))
+		case "price_minor":
+			product.PriceMinor = int64(jsonNumber(change.After))
+		case "currency":
+			product.Currency, _ = change.After.(string)
+		case "stock":
+			product.Stock = int(jsonNumber(change.After))
+		case "sku":
+			if sku, ok := change.After.(string); ok {
+				product.SKU = &sku
+			} else {
+				product.SKU = nil
+			}
+		}
+	}
+}
+
+// skuValue - SKU для истории: nil, если его нет.
+func skuValue(sku *string) interface{} {
+	if sku == nil {
+		return nil
+	}
+	return *sku
+}
+
+// jsonNumber приводит значение, прочитанное из JSON, к float64.
+func jsonNumber(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		return 0
+	}
+}
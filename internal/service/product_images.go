@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"demo-service/internal/storage"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	ErrFileTooLarge        = errors.New("file is too large")
+	ErrInvalidImage        = errors.New("invalid image")
+	ErrNoThumbnail         = errors.New("file has no thumbnail")
+)
+
+// productFileTypes - типы файлов продуктов, определяемые по содержимому,
+// и расширения ключей в хранилище.
+var productFileTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// ImageContent - содержимое файла продукта или его миниатюры.
+// Вызывающий закрывает его.
+type ImageContent struct {
+	io.ReadCloser
+	ContentType string
+	Filename    string
+}
+
+// AddImage сохраняет файл продукта. Тип определяется по содержимому, а не
+// по имени; для изображений строится миниатюра.
+func (s *ProductService) AddImage(ctx context.Context, productID int64, filename string, r io.Reader) (*model.ProductImage, error) {
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, fmt.Errorf("failed to add product image: %w", err)
+	}
+
+	maxBytes := int64(config.AppConfig.ImageMaxBytes)
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read product image: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := productFileTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFileType, strings.Split(contentType, ";")[0])
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, fmt.Errorf("failed to add product image: %w", err)
+	}
+	prefix := productBlobPrefix(productID) + "/" + name
+	image := &model.ProductImage{
+		ProductID:   productID,
+		Filename:    cleanFilename(filename, ext),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		BlobKey:     prefix + ext,
+	}
+
+	var thumb *thumbnail
+	if thumbnailFormats[contentType] {
+		if thumb, err = makeThumbnail(data, config.AppConfig.ThumbnailSize); err != nil {
+			return nil, err
+		}
+		key := prefix + "_thumb" + thumb.ext
+		image.ThumbnailKey = &key
+		image.Width, image.Height = &thumb.width, &thumb.height
+	}
+
+	if err := s.storeImage(ctx, image, data, thumb); err != nil {
+		s.deleteImageBlobs(ctx, image)
+		return nil, fmt.Errorf("failed to add product image: %w", err)
+	}
+
+	setImageURLs(image)
+	return image, nil
+}
+
+func (s *ProductService) storeImage(ctx context.Context, image *model.ProductImage, data []byte, thumb *thumbnail) error {
+	if err := s.blobs.Put(ctx, image.BlobKey, bytes.NewReader(data), image.Size, image.ContentType); err != nil {
+		return err
+	}
+	if thumb != nil {
+		if err := s.blobs.Put(ctx, *image.ThumbnailKey, bytes.NewReader(thumb.data), int64(len(thumb.data)), thumb.contentType); err != nil {
+			return err
+		}
+	}
+	return s.imageRepo.Create(ctx, image)
+}
+
+// getImage возвращает описание файла неудаленного продукта.
+func (s *ProductService) getImage(ctx context.Context, productID, id int64) (*model.ProductImage, error) {
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, fmt.Errorf("failed to get product image: %w", err)
+	}
+	image, err := s.imageRepo.GetByID(ctx, productID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product image: %w", err)
+	}
+	setImageURLs(image)
+	return image, nil
+}
+
+// OpenImage открывает содержимое файла продукта, а с thumbnail - его
+// миниатюры (ErrNoThumbnail, если ее нет).
+func (s *ProductService) OpenImage(ctx context.Context, productID, id int64, thumbnail bool) (*ImageContent, error) {
+	image, err := s.getImage(ctx, productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	key, contentType := image.BlobKey, image.ContentType
+	if thumbnail {
+		if image.ThumbnailKey == nil {
+			return nil, ErrNoThumbnail
+		}
+		key, contentType = *image.ThumbnailKey, thumbnailContentType(*image.ThumbnailKey)
+	}
+
+	body, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			logrus.Errorf("File %s of product image %d is missing in the blob storage", key, id)
+			return nil, repository.ErrImageNotFound
+		}
+		return nil, fmt.Errorf("failed to open product image: %w", err)
+	}
+	return &ImageContent{ReadCloser: body, ContentType: contentType, Filename: image.Filename}, nil
+}
+
+// DeleteImage удаляет файл продукта вместе с миниатюрой.
+func (s *ProductService) DeleteImage(ctx context.Context, productID, id int64) error {
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return fmt.Errorf("failed to delete product image: %w", err)
+	}
+	image, err := s.imageRepo.Delete(ctx, productID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete product image: %w", err)
+	}
+
+	s.deleteImageBlobs(ctx, image)
+	return nil
+}
+
+// deleteImageBlobs удаляет содержимое файла. Запись о файле уже удалена
+// или не создана, поэтому ошибка только оставляет мусор в хранилище.
+func (s *ProductService) deleteImageBlobs(ctx context.Context, image *model.ProductImage) {
+	// Доудаляем, даже если клиент уже отключился
+	ctx = context.WithoutCancel(ctx)
+
+	keys := []string{image.BlobKey}
+	if image.ThumbnailKey != nil {
+		keys = append(keys, *image.ThumbnailKey)
+	}
+	for _, key := range keys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			logrus.Warnf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// productBlobPrefix - общий префикс ключей файлов продукта, см.
+// storage.BlobStore.DeletePrefix.
+func productBlobPrefix(productID int64) string {
+	return "products/" + strconv.FormatInt(productID, 10)
+}
+
+func setImageURLs(image *model.ProductImage) {
+	image.URL = fmt.Sprintf("/api/v1/products/%d/images/%d", image.ProductID, image.ID)
+	if image.ThumbnailKey != nil {
+		image.ThumbnailURL = image.URL + "/thumbnail"
+	}
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// cleanFilename оставляет от имени загруженного файла последний элемент
+// пути без управляющих символов, не длиннее 255 символов.
+func cleanFilename(filename, ext string) string {
+	filename = path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	filename = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, filename))
+	if filename == "" || filename == "." || filename == "/" {
+		return "file" + ext
+	}
+	if utf8.RuneCountInString(filename) > 255 {
+		filename = string([]rune(filename)[:255])
+	}
+	return filename
+}
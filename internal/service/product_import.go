@@ -0,0 +1,620 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrInvalidImport     = errors.New("invalid import file")
+)
+
+const (
+	// Строк, применяемых одним пакетом (см. Batch)
+	importChunkRows = 500
+	// Сколько ошибок строк хранится в задаче; FailedRows считает все
+	maxImportRowErrors = 1000
+	// Предел длины строки NDJSON
+	maxNDJSONLineBytes = 1 << 20
+	// Фоновый импорт сохраняет ход после каждой части; задача, молчащая
+	// дольше, осталась от аварийно остановленного сервера
+	importStaleAfter = 10 * time.Minute
+)
+
+// importValidator проверяет строки по тегам binding, как gin проверяет
+// CreateProductRequest, и называет поля по их json-именам.
+var importValidator = func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+	if err := model.RegisterValidations(v); err != nil {
+		panic(err)
+	}
+	return v
+}()
+
+// importRow - строка файла импорта. Err - ошибка разбора строки.
+// Описание, остаток и SKU, которых нет в строке, не меняются при обновлении.
+type importRow struct {
+	line           int
+	id             *int64
+	req            model.CreateProductRequest
+	hasDescription bool
+	hasStock       bool
+	hasSKU         bool
+	err            string
+}
+
+type importReader interface {
+	// Next возвращает следующую строку или io.EOF.
+	Next() (*importRow, error)
+}
+
+// Import загружает продукты из CSV или NDJSON, создавая новые и обновляя
+// найденные по key. Файл сохраняется во временный: до PRODUCT_IMPORT_SYNC_ROWS
+// строк импорт выполняется сразу, больше - в фоне, и возвращенная задача
+// (async == true) еще выполняется.
+func (s *ProductService) Import(ctx context.Context, format, key string, upload io.Reader) (job *model.ProductImportJob, async bool, err error) {
+	if format != model.ProductFormatCSV && format != model.ProductFormatNDJSON {
+		return nil, false, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	if key != model.ImportKeyID && key != model.ImportKeyName && key != model.ImportKeySKU {
+		return nil, false, fmt.Errorf("%w: key must be id, name or sku", ErrInvalidImport)
+	}
+
+	file, err := os.CreateTemp("", "product-import-*")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to store import file: %w", err)
+	}
+	defer func() {
+		// В фоновом режиме файл удаляет runImport
+		if !async {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+
+	if _, err := io.Copy(file, upload); err != nil {
+		return nil, false, fmt.Errorf("failed to store import file: %w", err)
+	}
+	total, err := countImportRows(file, format)
+	if err != nil {
+		return nil, false, err
+	}
+
+	job = &model.ProductImportJob{
+		Status:    model.ImportStatusRunning,
+		Format:    format,
+		Key:       key,
+		ActorID:   actorFrom(ctx),
+		TotalRows: total,
+		RowErrors: []model.ImportRowError{},
+	}
+	if err := s.importJobs.Create(ctx, job); err != nil {
+		return nil, false, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	if total <= config.AppConfig.ImportSyncRows {
+		s.runImport(ctx, job, file)
+		return job, false, nil
+	}
+
+	// Импорт переживает запрос, но сохраняет из контекста автора изменений
+	// и прерывается остановкой сервера
+	snapshot := *job
+	s.imports.Add(1)
+	go func() {
+		defer s.imports.Done()
+		defer os.Remove(file.Name())
+		defer file.Close()
+
+		ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		defer cancel()
+		defer context.AfterFunc(s.importsCtx, cancel)()
+		s.runImport(ctx, job, file)
+	}()
+	return &snapshot, true, nil
+}
+
+// StopImports прерывает фоновые импорты и ждет, пока они сохранят свои
+// задачи как неудачные, но не дольше ctx. Новые импорты после этого
+// начинать нельзя.
+func (s *ProductService) StopImports(ctx context.Context) error {
+	s.stopImports()
+
+	done := make(chan struct{})
+	go func() {
+		s.imports.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetImportJob возвращает состояние задачи импорта. Задачу, которую
+// не обновляли дольше importStaleAfter, прервала аварийная остановка
+// сервера: она сохраняется как неудачная.
+func (s *ProductService) GetImportJob(ctx context.Context, id int64) (*model.ProductImportJob, error) {
+	job, err := s.importJobs.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+
+	if job.Status == model.ImportStatusRunning && time.Since(job.UpdatedAt) > importStaleAfter {
+		now := time.Now().UTC()
+		job.Status = model.ImportStatusFailed
+		job.Error = "Import was interrupted after " + strconv.Itoa(job.ProcessedRows) + " rows"
+		job.FinishedAt = &now
+		if err := s.importJobs.Update(ctx, job); err != nil {
+			return nil, fmt.Errorf("failed to save import job: %w", err)
+		}
+	}
+	return job, nil
+}
+
+// runImport применяет файл частями по importChunkRows строк и сохраняет ход
+// задачи после каждой части.
+func (s *ProductService) runImport(ctx context.Context, job *model.ProductImportJob, file *os.File) {
+	err := s.importFile(ctx, job, file)
+
+	now := time.Now().UTC()
+	job.FinishedAt = &now
+	job.Status = model.ImportStatusCompleted
+	if err != nil {
+		logrus.Errorf("Product import %d failed: %v", job.ID, err)
+		job.Status = model.ImportStatusFailed
+		job.Error = "Import failed after " + strconv.Itoa(job.ProcessedRows) + " rows"
+		if s.importsCtx.Err() != nil {
+			job.Error = "Import was interrupted by server shutdown after " + strconv.Itoa(job.ProcessedRows) + " rows"
+		}
+	}
+
+	if err := s.importJobs.Update(context.WithoutCancel(ctx), job); err != nil {
+		logrus.Errorf("Failed to save product import %d: %v", job.ID, err)
+	}
+}
+
+func (s *ProductService) importFile(ctx context.Context, job *model.ProductImportJob, file *os.File) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader, err := newImportReader(file, job.Format)
+	if err != nil {
+		return err
+	}
+
+	// Ключи уже загруженных строк: повтор ключа в файле - скорее всего ошибка
+	seen := make(map[string]int)
+	chunk := make([]*importRow, 0, importChunkRows)
+	for {
+		row, err := reader.Next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if row != nil {
+			chunk = append(chunk, row)
+		}
+		if len(chunk) == importChunkRows || (errors.Is(err, io.EOF) && len(chunk) > 0) {
+			if err := s.importChunk(ctx, job, chunk, seen); err != nil {
+				return err
+			}
+			if err := s.importJobs.Update(ctx, job); err != nil {
+				return err
+			}
+			chunk = chunk[:0]
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
+
+// importChunk превращает строки в операции пакета и применяет их в режиме
+// best_effort: ошибка строки не мешает остальным.
+func (s *ProductService) importChunk(ctx context.Context, job *model.ProductImportJob, rows []*importRow, seen map[string]int) error {
+	byName, err := s.importNameKeys(ctx, job.Key, rows)
+	if err != nil {
+		return err
+	}
+	bySKU, err := s.importSKUKeys(ctx, job.Key, rows)
+	if err != nil {
+		return err
+	}
+
+	var (
+		ops   []model.ProductBatchOperation
+		lines []int
+	)
+	for _, row := range rows {
+		job.ProcessedRows++
+		if row.err == "" {
+			row.err = validateImportRow(&row.req)
+		}
+
+		op := model.ProductBatchOperation{
+			Op:         model.BatchOpCreate,
+			Name:       &row.req.Name,
+			Price:      row.req.Price,
+			PriceMinor: row.req.PriceMinor,
+		}
+		if row.req.Currency != "" {
+			op.Currency = &row.req.Currency
+		}
+		if row.hasDescription {
+			op.Description = &row.req.Description
+		}
+		if row.hasStock {
+			op.Stock = &row.req.Stock
+		}
+		if row.hasSKU {
+			op.SKU = &row.req.SKU
+		}
+		keyValue := ""
+		switch {
+		case row.err != "":
+		case job.Key == model.ImportKeyID && row.id != nil:
+			keyValue = strconv.FormatInt(*row.id, 10)
+			op.Op = model.BatchOpUpdate
+			op.ID = *row.id
+		case job.Key == model.ImportKeyName:
+			keyValue = row.req.Name
+			switch ids := byName[row.req.Name]; len(ids) {
+			case 0:
+			case 1:
+				op.Op = model.BatchOpUpdate
+				op.ID = ids[0]
+			default:
+				row.err = fmt.Sprintf("%d products are named %q", len(ids), row.req.Name)
+			}
+		case job.Key == model.ImportKeySKU:
+			keyValue = NormalizeSKU(row.req.SKU)
+			if keyValue == "" {
+				row.err = "sku is required"
+			} else if id, ok := bySKU[keyValue]; ok {
+				op.Op = model.BatchOpUpdate
+				op.ID = id
+			}
+		}
+		if first, ok := seen[keyValue]; ok && keyValue != "" && row.err == "" {
+			row.err = fmt.Sprintf("%s %q is already imported from row %d", job.Key, keyValue, first)
+		}
+
+		if row.err != "" {
+			addImportError(job, row.line, row.err)
+			continue
+		}
+		if keyValue != "" {
+			seen[keyValue] = row.line
+		}
+		ops = append(ops, op)
+		lines = append(lines, row.line)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	b := &productBatch{ops: ops, results: make([]model.ProductBatchResult, len(ops))}
+	for i := range b.results {
+		b.results[i].Index = i
+	}
+	b.validate()
+	if err := s.applyBestEffort(ctx, b); err != nil {
+		return err
+	}
+
+	for i, result := range b.results {
+		switch result.Status {
+		case http.StatusCreated:
+			job.CreatedRows++
+		case http.StatusOK:
+			job.UpdatedRows++
+		default:
+			addImportError(job, lines[i], result.Error)
+		}
+	}
+	return nil
+}
+
+// importNameKeys находит id существующих продуктов по названиям строк
+// (для key=name); одно название может быть у нескольких продуктов.
+func (s *ProductService) importNameKeys(ctx context.Context, key string, rows []*importRow) (map[string][]int64, error) {
+	byName := make(map[string][]int64)
+	if key != model.ImportKeyName {
+		return byName, nil
+	}
+
+	var names []string
+	for _, row := range rows {
+		if row.err == "" && row.req.Name != "" {
+			names = append(names, row.req.Name)
+		}
+	}
+	if len(names) == 0 {
+		return byName, nil
+	}
+	products, err := s.productRepo.GetByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		byName[product.Name] = append(byName[product.Name], product.ID)
+	}
+	return byName, nil
+}
+
+// importSKUKeys находит id существующих продуктов по SKU строк (для key=sku).
+func (s *ProductService) importSKUKeys(ctx context.Context, key string, rows []*importRow) (map[string]int64, error) {
+	bySKU := make(map[string]int64)
+	if key != model.ImportKeySKU {
+		return bySKU, nil
+	}
+
+	var skus []string
+	for _, row := range rows {
+		if sku := NormalizeSKU(row.req.SKU); row.err == "" && sku != "" {
+			skus = append(skus, sku)
+		}
+	}
+	if len(skus) == 0 {
+		return bySKU, nil
+	}
+	products, err := s.productRepo.GetBySKUs(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		bySKU[*product.SKU] = product.ID
+	}
+	return bySKU, nil
+}
+
+func addImportError(job *model.ProductImportJob, line int, msg string) {
+	job.FailedRows++
+	if len(job.RowErrors) < maxImportRowErrors {
+		job.RowErrors = append(job.RowErrors, model.ImportRowError{Row: line, Error: msg})
+	}
+}
+
+// validateImportRow применяет правила CreateProductRequest.
+func validateImportRow(req *model.CreateProductRequest) string {
+	err := importValidator.Struct(req)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		if err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	fe := validationErrors[0]
+	switch fe.Tag() {
+	case "required", "required_without":
+		return fe.Field() + " is required"
+	case "currency":
+		return fe.Field() + " must be an ISO 4217 code"
+	case "sku":
+		return fe.Field() + " may only contain letters, digits, '.', '_' and '-'"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters long", fe.Field(), fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters long", fe.Field(), fe.Param())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", fe.Field(), fe.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", fe.Field(), fe.Param())
+	}
+	return fmt.Sprintf("%s is invalid (%s)", fe.Field(), fe.Tag())
+}
+
+// countImportRows проверяет заголовок и считает строки файла.
+func countImportRows(file *os.File, format string) (int, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	reader, err := newImportReader(file, format)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for {
+		row, err := reader.Next()
+		if row != nil {
+			total++
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("%w: file has no rows", ErrInvalidImport)
+	}
+	return total, nil
+}
+
+func newImportReader(r io.Reader, format string) (importReader, error) {
+	if format == model.ProductFormatNDJSON {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxNDJSONLineBytes)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	}
+	return newCSVImportReader(r)
+}
+
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: file is empty", ErrInvalidImport)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: CSV header must contain a %q column", ErrInvalidImport, required)
+		}
+	}
+	return &csvImportReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvImportReader) Next() (*importRow, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return &importRow{line: parseErr.StartLine, err: parseErr.Err.Error()}, nil
+		}
+		return nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	row := &importRow{line: line}
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	_, row.hasDescription = r.columns["description"]
+	_, row.hasStock = r.columns["stock"]
+	_, row.hasSKU = r.columns["sku"]
+
+	row.req.Name = field("name")
+	row.req.Description = field("description")
+	if value := field("id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			row.err = "id must be a positive integer"
+			return row, nil
+		}
+		row.id = &id
+	}
+	if value := field("price"); value != "" {
+		price, err := model.ParseAmount(value)
+		if err != nil {
+			row.err = "price must be a decimal number"
+			return row, nil
+		}
+		row.req.Price = &price
+	}
+	row.req.Currency = field("currency")
+	row.req.SKU = field("sku")
+	if value := field("stock"); value != "" {
+		stock, err := strconv.Atoi(value)
+		if err != nil {
+			row.err = "stock must be an integer"
+			return row, nil
+		}
+		row.req.Stock = stock
+	}
+	return row, nil
+}
+
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// ndjsonProduct - строка NDJSON: поля CreateProductRequest и необязательный id.
+type ndjsonProduct struct {
+	ID          *int64        `json:"id"`
+	Name        string        `json:"name"`
+	Description *string       `json:"description"`
+	Price       *model.Amount `json:"price"`
+	PriceMinor  *int64        `json:"price_minor"`
+	Currency    string        `json:"currency"`
+	Stock       *int          `json:"stock"`
+	SKU         *string       `json:"sku"`
+}
+
+func (r *ndjsonImportReader) Next() (*importRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := strings.TrimSpace(r.scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		var product ndjsonProduct
+		if err := json.Unmarshal([]byte(data), &product); err != nil {
+			return &importRow{line: r.line, err: "invalid JSON: " + err.Error()}, nil
+		}
+		req := model.CreateProductRequest{
+			Name:       product.Name,
+			Price:      product.Price,
+			PriceMinor: product.PriceMinor,
+			Currency:   product.Currency,
+		}
+		row := &importRow{
+			line:           r.line,
+			id:             product.ID,
+			req:            req,
+			hasDescription: product.Description != nil,
+			hasStock:       product.Stock != nil,
+			hasSKU:         product.SKU != nil,
+		}
+		if product.Description != nil {
+			row.req.Description = *product.Description
+		}
+		if product.Stock != nil {
+			row.req.Stock = *product.Stock
+		}
+		if product.SKU != nil {
+			row.req.SKU = *product.SKU
+		}
+		if product.ID != nil && *product.ID <= 0 {
+			row.err = "id must be a positive integer"
+		}
+		return row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: line %d is longer than %d bytes", ErrInvalidImport, r.line+1, maxNDJSONLineBytes)
+		}
+		return nil, err
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidPrice - цену из запроса нельзя точно перевести в минимальные
+// единицы валюты.
+var ErrInvalidPrice = errors.New("invalid price")
+
+// productCurrency нормализует код валюты из запроса; пустой заменяется
+// PRODUCT_DEFAULT_CURRENCY.
+func productCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return config.AppConfig.DefaultCurrency
+	}
+	return currency
+}
+
+// priceMinor переводит цену из запроса в минимальные единицы currency.
+// price_minor берется как есть, десятичная price не округляется: в ней не
+// может быть больше знаков, чем у валюты. Если заданы обе, они должны совпадать.
+func priceMinor(price *model.Amount, minor *int64, currency string) (int64, error) {
+	if !model.ValidCurrency(currency) {
+		return 0, fmt.Errorf("%w: unknown currency %q", ErrInvalidPrice, currency)
+	}
+
+	var value int64
+	switch {
+	case price != nil:
+		var err error
+		if value, err = price.Minor(currency); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidPrice, err)
+		}
+		if minor != nil && *minor != value {
+			return 0, fmt.Errorf("%w: price %s %s does not match price_minor %d", ErrInvalidPrice, *price, currency, *minor)
+		}
+	case minor != nil:
+		value = *minor
+	default:
+		return 0, fmt.Errorf("%w: price or price_minor is required", ErrInvalidPrice)
+	}
+
+	if value <= 0 {
+		return 0, fmt.Errorf("%w: price must be greater than 0", ErrInvalidPrice)
+	}
+	return value, nil
+}
+
+// priceChange переводит цену из запроса на изменение product; nil - цена
+// или валюта не меняется. Без currency цена задается в текущей валюте
+// продукта; валюта меняется только вместе с ценой, иначе сумма поменяла бы смысл.
+func priceChange(product *model.Product, price *model.Amount, minor *int64, currency *string) (*int64, *string, error) {
+	code := product.Currency
+	if currency != nil && strings.TrimSpace(*currency) != "" {
+		code = productCurrency(*currency)
+	}
+
+	if price == nil && minor == nil {
+		if code != product.Currency {
+			return nil, nil, fmt.Errorf("%w: currency can only be changed together with the price", ErrInvalidPrice)
+		}
+		return nil, nil, nil
+	}
+
+	value, err := priceMinor(price, minor, code)
+	if err != nil {
+		return nil, nil, err
+	}
+	if code == product.Currency {
+		return &value, nil, nil
+	}
+	return &value, &code, nil
+}
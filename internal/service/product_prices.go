@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ListPrices возвращает явные цены продукта в других валютах.
+func (s *ProductService) ListPrices(ctx context.Context, productID int64) (*model.ProductPriceListResponse, error) {
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, fmt.Errorf("failed to get product prices: %w", err)
+	}
+	prices, err := s.priceRepo.ListByProducts(ctx, []int64{productID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product prices: %w", err)
+	}
+
+	list := prices[productID]
+	if list == nil {
+		list = []model.ProductPrice{}
+	}
+	return &model.ProductPriceListResponse{Prices: list}, nil
+}
+
+// SetPrice задает явную цену продукта в currency: в этой валюте она
+// показывается вместо пересчета по курсу. Цена в валюте самого продукта
+// меняется обычным обновлением, а не здесь (ErrInvalidPrice).
+func (s *ProductService) SetPrice(ctx context.Context, productID int64, currency string, req *model.SetProductPriceRequest) (*model.ProductPrice, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	price, err := priceMinor(req.Price, req.PriceMinor, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	productPrice := &model.ProductPrice{ProductID: productID, Currency: currency, PriceMinor: price}
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		product, err := s.productRepo.GetByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if product.Currency == currency {
+			return fmt.Errorf("%w: %s is the product currency, update the product price instead", ErrInvalidPrice, currency)
+		}
+		return s.priceRepo.Set(ctx, productPrice)
+	})
+	if errors.Is(err, ErrInvalidPrice) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set product price: %w", err)
+	}
+	return productPrice, nil
+}
+
+// DeletePrice удаляет явную цену продукта в currency: цена снова
+// пересчитывается по курсу.
+func (s *ProductService) DeletePrice(ctx context.Context, productID int64, currency string) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+			return err
+		}
+		return s.priceRepo.Delete(ctx, productID, currency)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete product price: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/config"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrReservationExpired - срок резерва истек: его единицы возвращены или
+// будут возвращены в доступный остаток очисткой.
+var ErrReservationExpired = errors.New("stock reservation expired")
+
+// Сколько истекших резервов снимает одна транзакция очистки
+const reservationSweepBatch = 500
+
+// reservationTTL - срок резерва: ttlSeconds из запроса или RESERVATION_TTL,
+// но не больше RESERVATION_MAX_TTL.
+func reservationTTL(ttlSeconds int) time.Duration {
+	ttl := config.AppConfig.ReservationTTL
+	if ttlSeconds > 0 {
+		ttl = time.Duration(ttlSeconds) * time.Second
+	}
+	if maxTTL := config.AppConfig.ReservationMaxTTL; maxTTL > 0 && ttl > maxTTL {
+		ttl = maxTTL
+	}
+	return ttl
+}
+
+// Reserve резервирует req.Quantity единиц продукта. Если доступно меньше,
+// возвращает repository.ErrInsufficientStock. Версия продукта не меняется:
+// резерв не мешает параллельному редактированию карточки.
+func (s *ProductService) Reserve(ctx context.Context, productID int64, req *model.CreateReservationRequest) (*model.ReservationResponse, error) {
+	reservation := &model.StockReservation{
+		ProductID: productID,
+		Quantity:  req.Quantity,
+		Status:    model.ReservationStatusActive,
+		ActorID:   actorFrom(ctx),
+		ExpiresAt: time.Now().UTC().Add(reservationTTL(req.TTLSeconds)).Truncate(time.Microsecond),
+	}
+
+	var product *model.Product
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if product, err = s.productRepo.Reserve(ctx, productID, req.Quantity); err != nil {
+			return err
+		}
+		return s.reservationRepo.Create(ctx, reservation)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+	return &model.ReservationResponse{Reservation: *reservation, Product: product}, nil
+}
+
+// GetReservation возвращает резерв продукта productID.
+func (s *ProductService) GetReservation(ctx context.Context, productID, id int64) (*model.StockReservation, error) {
+	reservation, err := s.reservationRepo.GetByID(ctx, id)
+	if err == nil && reservation.ProductID != productID {
+		err = repository.ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock reservation: %w", err)
+	}
+	return reservation, nil
+}
+
+// CommitReservation списывает зарезервированные единицы со склада. Это
+// изменение остатка попадает в историю продукта и в журнал остатка как
+// sale. Истекший резерв подтвердить нельзя (ErrReservationExpired), уже
+// подтвержденный или снятый - repository.ErrReservationNotActive.
+func (s *ProductService) CommitReservation(ctx context.Context, productID, id int64) (*model.ReservationResponse, error) {
+	var response model.ReservationResponse
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		reservation, err := s.GetReservation(ctx, productID, id)
+		if err != nil {
+			return err
+		}
+		if reservation.Status == model.ReservationStatusExpired ||
+			(reservation.Status == model.ReservationStatusActive && !reservation.ExpiresAt.After(time.Now())) {
+			return ErrReservationExpired
+		}
+
+		finished, err := s.reservationRepo.Finish(ctx, id, model.ReservationStatusCommitted)
+		if err != nil {
+			return err
+		}
+		before, err := s.productRepo.GetByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+		product, err := s.productRepo.CommitReserved(ctx, productID, finished.Quantity)
+		if err != nil {
+			return err
+		}
+		response = model.ReservationResponse{Reservation: *finished, Product: product}
+		if err := s.recordStockChange(ctx, before, product, model.StockReasonSale, fmt.Sprintf("reservation:%d", id)); err != nil {
+			return err
+		}
+		return s.addHistory(ctx, productID, product.Version, model.ProductOpUpdate, productChanges(before, product))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit stock reservation: %w", err)
+	}
+	return &response, nil
+}
+
+// ReleaseReservation снимает активный резерв: его единицы снова доступны.
+func (s *ProductService) ReleaseReservation(ctx context.Context, productID, id int64) (*model.ReservationResponse, error) {
+	var response model.ReservationResponse
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.GetReservation(ctx, productID, id); err != nil {
+			return err
+		}
+		finished, err := s.reservationRepo.Finish(ctx, id, model.ReservationStatusReleased)
+		if err != nil {
+			return err
+		}
+		product, err := s.productRepo.Unreserve(ctx, productID, finished.Quantity)
+		if err != nil {
+			return err
+		}
+		response = model.ReservationResponse{Reservation: *finished, Product: product}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to release stock reservation: %w", err)
+	}
+	return &response, nil
+}
+
+// ExpireReservations снимает истекшие резервы и возвращает их число.
+// Резервы снимаются порциями по reservationSweepBatch, каждая в своей
+// транзакции.
+func (s *ProductService) ExpireReservations(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		var expired []model.StockReservation
+		err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			if expired, err = s.reservationRepo.Expire(ctx, time.Now(), reservationSweepBatch); err != nil {
+				return err
+			}
+			for _, reservation := range expired {
+				if _, err := s.productRepo.Unreserve(ctx, reservation.ProductID, reservation.Quantity); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return total, fmt.Errorf("failed to expire stock reservations: %w", err)
+		}
+		total += len(expired)
+		if len(expired) < reservationSweepBatch {
+			return total, nil
+		}
+	}
+}
+
+// RunReservationSweeper периодически вызывает ExpireReservations, пока не
+// будет отменен ctx.
+func (s *ProductService) RunReservationSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireReservations(ctx)
+			if err != nil {
+				logrus.Errorf("Failed to release expired stock reservations: %v", err)
+			}
+			if expired > 0 {
+				logrus.Infof("Released %d expired stock reservation(s)", expired)
+			}
+		}
+	}
+}
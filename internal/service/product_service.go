@@ -1,108 +1,481 @@
 package service
 
 import (
+	"context"
+	"demo-service/internal/config"
 	"demo-service/internal/model"
 	"demo-service/internal/repository"
+	"demo-service/internal/storage"
+	"errors"
 	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// Сколько раз повторять изменение без If-Match, если продукт успели изменить
+// между чтением состояния "до" и записью
+const maxConcurrentUpdateAttempts = 3
+
 type ProductService struct {
-	productRepo *repository.ProductRepository
+	productRepo     repository.ProductStore
+	historyRepo     repository.ProductHistoryStore
+	importJobs      repository.ProductImportJobStore
+	categoryRepo    repository.CategoryStore
+	imageRepo       repository.ProductImageStore
+	priceRepo       repository.ProductPriceStore
+	rateRepo        repository.ExchangeRateStore
+	reservationRepo repository.StockReservationStore
+	movementRepo    repository.StockMovementStore
+	txManager       repository.TxManager
+	blobs           storage.BlobStore
+	suggestions     *suggestCache
+
+	// Фоновые импорты прерываются StopImports при остановке сервера
+	importsCtx  context.Context
+	stopImports context.CancelFunc
+	imports     sync.WaitGroup
+}
+
+// ProductStores - хранилища ProductService. Поля именованные: многие
+// интерфейсы совпадают по набору методов, и перепутанные позиционные
+// аргументы компилятор бы не заметил.
+type ProductStores struct {
+	Products       repository.ProductStore
+	History        repository.ProductHistoryStore
+	ImportJobs     repository.ProductImportJobStore
+	Categories     repository.CategoryStore
+	Images         repository.ProductImageStore
+	Prices         repository.ProductPriceStore
+	ExchangeRates  repository.ExchangeRateStore
+	Reservations   repository.StockReservationStore
+	StockMovements repository.StockMovementStore
+	Tx             repository.TxManager
 }
 
-func NewProductService(productRepo *repository.ProductRepository) *ProductService {
+func NewProductService(stores ProductStores, blobs storage.BlobStore) *ProductService {
+	importsCtx, stopImports := context.WithCancel(context.Background())
 	return &ProductService{
-		productRepo: productRepo,
+		productRepo:     stores.Products,
+		historyRepo:     stores.History,
+		importJobs:      stores.ImportJobs,
+		categoryRepo:    stores.Categories,
+		imageRepo:       stores.Images,
+		priceRepo:       stores.Prices,
+		rateRepo:        stores.ExchangeRates,
+		reservationRepo: stores.Reservations,
+		movementRepo:    stores.StockMovements,
+		txManager:       stores.Tx,
+		blobs:           blobs,
+		suggestions:     newSuggestCache(config.AppConfig.SuggestCacheTTL),
+		importsCtx:      importsCtx,
+		stopImports:     stopImports,
 	}
 }
 
-func (s *ProductService) Create(req *model.CreateProductRequest) (*model.Product, error) {
+// Create создает продукт. Цену, которую нельзя точно перевести в
+// минимальные единицы валюты, отклоняет с ErrInvalidPrice.
+func (s *ProductService) Create(ctx context.Context, req *model.CreateProductRequest) (*model.Product, error) {
+	currency := productCurrency(req.Currency)
+	price, err := priceMinor(req.Price, req.PriceMinor, currency)
+	if err != nil {
+		return nil, err
+	}
 	product := &model.Product{
+		SKU:         skuPtr(req.SKU),
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		PriceMinor:  price,
+		Currency:    currency,
 		Stock:       req.Stock,
 	}
 
-	if err := s.productRepo.Create(product); err != nil {
-		return nil, fmt.Errorf("failed to create product: %w", err)
-	}
-
-	// Получаем созданный продукт с временными метками
-	createdProduct, err := s.productRepo.GetByID(product.ID)
+	// Временные метки заполняются репозиторием в том же запросе
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.Create(ctx, product); err != nil {
+			return err
+		}
+		if err := s.recordStockChange(ctx, nil, product, model.StockReasonInitial, ""); err != nil {
+			return err
+		}
+		return s.addHistory(ctx, product.ID, product.Version, model.ProductOpCreate, productChanges(nil, product))
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get created product: %w", err)
+		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
 
-	return createdProduct, nil
+	return product, nil
 }
 
-func (s *ProductService) GetByID(id int64) (*model.Product, error) {
-	product, err := s.productRepo.GetByID(id)
+func (s *ProductService) GetByID(ctx context.Context, id int64) (*model.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
+	if err := s.attachDetails(ctx, product); err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
 	return product, nil
 }
 
-func (s *ProductService) List(page, limit int) (*model.ProductListResponse, error) {
-	if page < 1 {
-		page = 1
+// List возвращает страницу продуктов. Непустой cursor (next_cursor или
+// prev_cursor предыдущего ответа) включает keyset-пагинацию вместо page.
+func (s *ProductService) List(ctx context.Context, opts repository.ProductListOptions, cursor string) (*model.ProductListResponse, error) {
+	if opts.Page < 1 {
+		opts.Page = 1
 	}
-	if limit < 1 {
-		limit = 10
+	if opts.Limit < 1 {
+		opts.Limit = 10
 	}
-	if limit > 100 {
-		limit = 100
+	if opts.Limit > 100 {
+		opts.Limit = 100
 	}
+	opts.Offset = (opts.Page - 1) * opts.Limit
 
-	products, total, err := s.productRepo.List(page, limit)
+	if cursor != "" {
+		// Релевантность не хранится в строке, поэтому по ней курсор не построить
+		if opts.Query != "" && len(opts.Sort) == 0 {
+			return nil, fmt.Errorf("%w: cursor pagination of search results requires sort", ErrInvalidCursor)
+		}
+		keyset, err := decodeCursor(cursor, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts.Keyset = keyset
+	}
+
+	// Лишняя строка показывает, есть ли продолжение в направлении выборки
+	fetch := opts
+	fetch.Limit++
+	products, total, err := s.productRepo.List(ctx, fetch)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list products: %w", err)
 	}
 
-	return &model.ProductListResponse{
+	backward := opts.Keyset != nil && opts.Keyset.Backward
+	hasMore := len(products) > opts.Limit
+	if hasMore {
+		if backward {
+			products = products[1:]
+		} else {
+			products = products[:opts.Limit]
+		}
+	}
+
+	if err := s.attachDetails(ctx, sliceRefs(products)...); err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	response := &model.ProductListResponse{
 		Products: products,
-		Total:    total,
-		Page:     page,
-		Limit:    limit,
-	}, nil
+		Limit:    opts.Limit,
+	}
+	if !opts.SkipTotal {
+		response.Total = &total
+	}
+	if opts.Keyset == nil {
+		response.Page = opts.Page
+	}
+
+	// Для поиска без sort курсоры не выдаются, остается page
+	if len(products) == 0 || (opts.Query != "" && len(opts.Sort) == 0) {
+		return response, nil
+	}
+	first, last := &products[0], &products[len(products)-1]
+	if hasMore || backward {
+		response.NextCursor = encodeCursor(opts, last, false)
+	}
+	if (backward && hasMore) || (!backward && (opts.Keyset != nil || opts.Page > 1)) {
+		response.PrevCursor = encodeCursor(opts, first, true)
+	}
+	return response, nil
 }
 
-func (s *ProductService) Update(id int64, req *model.UpdateProductRequest) (*model.Product, error) {
+// Update применяет изменения. Ненулевой version включает проверку версии
+// (optimistic locking), см. repository.ProductStore. Неверная цена
+// отклоняется с ErrInvalidPrice, остаток - с ErrStockOverwrite.
+func (s *ProductService) Update(ctx context.Context, id int64, req *model.UpdateProductRequest, version int64) (*model.Product, error) {
+	if req.Stock != nil {
+		return nil, ErrStockOverwrite
+	}
 	updates := make(map[string]interface{})
 
+	if req.SKU != nil {
+		if sku := skuPtr(*req.SKU); sku != nil {
+			updates["sku"] = *sku
+		} else {
+			updates["sku"] = nil
+		}
+	}
 	if req.Name != nil {
 		updates["name"] = *req.Name
 	}
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
-	if req.Price != nil {
-		updates["price"] = *req.Price
+
+	var product *model.Product
+	err := s.withVersionRetry(version, func() error {
+		return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			before, expected, err := s.loadForChange(ctx, id, version)
+			if err != nil {
+				return err
+			}
+
+			// Десятичная цена без валюты переводится по валюте продукта
+			price, currency, err := priceChange(before, req.Price, req.PriceMinor, req.Currency)
+			if err != nil {
+				return err
+			}
+			changes := maps.Clone(updates)
+			if price != nil {
+				changes["price_minor"] = *price
+			}
+			if currency != nil {
+				changes["currency"] = *currency
+			}
+			product, err = s.productRepo.Update(ctx, id, changes, expected)
+			if err != nil {
+				return err
+			}
+			if product.Version == before.Version {
+				return nil
+			}
+			return s.addHistory(ctx, id, product.Version, model.ProductOpUpdate, productChanges(before, product))
+		})
+	})
+	if err == nil {
+		err = s.attachDetails(ctx, product)
 	}
-	if req.Stock != nil {
-		updates["stock"] = *req.Stock
+	if errors.Is(err, ErrInvalidPrice) {
+		return nil, err
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+
+	return product, nil
+}
+
+func (s *ProductService) Delete(ctx context.Context, id int64, version int64) error {
+	err := s.withVersionRetry(version, func() error {
+		return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			before, expected, err := s.loadForChange(ctx, id, version)
+			if err != nil {
+				return err
+			}
 
-	if len(updates) == 0 {
-		return s.GetByID(id)
+			if err := s.productRepo.Delete(ctx, id, expected); err != nil {
+				return err
+			}
+			return s.addHistory(ctx, id, before.Version+1, model.ProductOpDelete, map[string]model.FieldChange{})
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
 	}
+	return nil
+}
 
-	if err := s.productRepo.Update(id, updates); err != nil {
-		return nil, fmt.Errorf("failed to update product: %w", err)
+func (s *ProductService) Restore(ctx context.Context, id int64) (*model.Product, error) {
+	var product *model.Product
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = s.productRepo.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.addHistory(ctx, id, product.Version, model.ProductOpRestore, map[string]model.FieldChange{})
+	})
+	if err == nil {
+		err = s.attachDetails(ctx, product)
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+	return product, nil
+}
 
-	return s.GetByID(id)
+// Suggest возвращает подсказки названий для автодополнения. Результаты
+// кешируются на SUGGEST_CACHE_TTL, поэтому свежие изменения видны с задержкой.
+func (s *ProductService) Suggest(ctx context.Context, prefix string, limit int) ([]model.ProductSuggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 20 {
+		limit = 20
+	}
+
+	key := strings.ToLower(prefix) + "|" + strconv.Itoa(limit)
+	if suggestions, ok := s.suggestions.get(key); ok {
+		return suggestions, nil
+	}
+
+	suggestions, err := s.productRepo.Suggest(ctx, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest products: %w", err)
+	}
+
+	s.suggestions.set(key, suggestions)
+	return suggestions, nil
 }
 
-func (s *ProductService) Delete(id int64) error {
-	if err := s.productRepo.Delete(id); err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+// GetAsOf восстанавливает состояние продукта на момент asOf по истории изменений.
+func (s *ProductService) GetAsOf(ctx context.Context, id int64, asOf time.Time) (*model.Product, error) {
+	entries, err := s.historyRepo.ListUntil(ctx, id, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product history: %w", err)
+	}
+
+	product := replayHistory(entries)
+	if product == nil {
+		return nil, fmt.Errorf("failed to get product: %w", repository.ErrProductNotFound)
+	}
+	return product, nil
+}
+
+func (s *ProductService) History(ctx context.Context, id int64, page, limit int) (*model.ProductHistoryResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	entries, total, err := s.historyRepo.List(ctx, id, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product history: %w", err)
+	}
+
+	return &model.ProductHistoryResponse{
+		Entries: entries,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+// loadForChange читает текущее состояние продукта для истории и возвращает
+// версию, с которой должно выполняться изменение: версию клиента из If-Match
+// или прочитанную, чтобы параллельная запись не исказила "before".
+func (s *ProductService) loadForChange(ctx context.Context, id int64, version int64) (*model.Product, int64, error) {
+	before, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version != 0 && before.Version != version {
+		return nil, 0, repository.ErrVersionMismatch
+	}
+	return before, before.Version, nil
+}
+
+// withVersionRetry повторяет fn при конфликте версий, если клиент не
+// передавал версию сам: для него конфликт - внутренняя деталь.
+func (s *ProductService) withVersionRetry(version int64, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if version != 0 || attempt >= maxConcurrentUpdateAttempts || !errors.Is(err, repository.ErrVersionMismatch) {
+			return err
+		}
+	}
+}
+
+func (s *ProductService) addHistory(ctx context.Context, productID, version int64, operation string, changes map[string]model.FieldChange) error {
+	err := s.historyRepo.Add(ctx, &model.ProductHistoryEntry{
+		ProductID: productID,
+		Version:   version,
+		ActorID:   actorFrom(ctx),
+		Operation: operation,
+		Changes:   changes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record product history: %w", err)
 	}
 	return nil
 }
 
+// PurgeDeleted окончательно удаляет продукты, удаленные раньше чем retention
+// назад, вместе с их файлами.
+func (s *ProductService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.productRepo.Purge(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge products: %w", err)
+	}
+
+	// Записи о файлах стерты вместе с продуктами, ошибка оставит в хранилище
+	// только недоступный мусор
+	for _, id := range purged {
+		if err := s.blobs.DeletePrefix(ctx, productBlobPrefix(id)); err != nil {
+			logrus.Warnf("Failed to delete files of purged product %d: %v", id, err)
+		}
+	}
+	return int64(len(purged)), nil
+}
+
+// RunPurge периодически вызывает PurgeDeleted, пока не будет отменен ctx.
+func (s *ProductService) RunPurge(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeDeleted(ctx, retention)
+			if err != nil {
+				logrus.Errorf("Failed to purge deleted products: %v", err)
+				continue
+			}
+			if purged > 0 {
+				logrus.Infof("Purged %d deleted product(s)", purged)
+			}
+		}
+	}
+}
 
+// attachDetails заполняет категории и файлы продуктов, по одному запросу
+// на все продукты.
+func (s *ProductService) attachDetails(ctx context.Context, products ...*model.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
 
+	ids := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	categories, err := s.categoryRepo.ProductCategories(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get product categories: %w", err)
+	}
+	images, err := s.imageRepo.ListByProducts(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get product images: %w", err)
+	}
+
+	for _, product := range products {
+		product.Categories = categories[product.ID]
+		product.Images = images[product.ID]
+		for i := range product.Images {
+			setImageURLs(&product.Images[i])
+		}
+	}
+	return nil
+}
+
+func sliceRefs(products []model.Product) []*model.Product {
+	refs := make([]*model.Product, len(products))
+	for i := range products {
+		refs[i] = &products[i]
+	}
+	return refs
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"demo-service/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSKUMismatch - sku в теле запроса не совпадает с SKU из пути.
+var ErrSKUMismatch = errors.New("sku in the body does not match the path")
+
+// NormalizeSKU приводит SKU к виду, в котором он хранится: SKU сравниваются
+// без учета регистра.
+func NormalizeSKU(sku string) string {
+	return strings.ToUpper(strings.TrimSpace(sku))
+}
+
+// skuPtr возвращает нормализованный SKU или nil для пустого.
+func skuPtr(sku string) *string {
+	sku = NormalizeSKU(sku)
+	if sku == "" {
+		return nil
+	}
+	return &sku
+}
+
+func (s *ProductService) GetBySKU(ctx context.Context, sku string) (*model.Product, error) {
+	product, err := s.productRepo.GetBySKU(ctx, NormalizeSKU(sku))
+	if err == nil {
+		err = s.attachDetails(ctx, product)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	return product, nil
+}
+
+// UpsertBySKU заменяет поля продукта с указанным SKU или создает его, если
+// такого нет; created сообщает, что продукт создан. Ненулевой version
+// требует, чтобы продукт существовал в этой версии.
+func (s *ProductService) UpsertBySKU(ctx context.Context, sku string, req *model.CreateProductRequest, version int64) (product *model.Product, created bool, err error) {
+	sku = NormalizeSKU(sku)
+	if req.SKU != "" && NormalizeSKU(req.SKU) != sku {
+		return nil, false, ErrSKUMismatch
+	}
+	currency := productCurrency(req.Currency)
+	price, err := priceMinor(req.Price, req.PriceMinor, currency)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = s.withVersionRetry(version, func() error {
+		return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			created = false
+			before, err := s.productRepo.GetBySKU(ctx, sku)
+			if errors.Is(err, repository.ErrProductNotFound) {
+				if version != 0 {
+					return repository.ErrVersionMismatch
+				}
+				product, err = s.createWithSKU(ctx, sku, req, price, currency)
+				created = err == nil
+				return err
+			}
+			if err != nil {
+				return err
+			}
+			if version != 0 && before.Version != version {
+				return repository.ErrVersionMismatch
+			}
+
+			updates := map[string]interface{}{
+				"name":        req.Name,
+				"description": req.Description,
+				"price_minor": price,
+				"currency":    currency,
+				"stock":       req.Stock,
+			}
+			if product, err = s.productRepo.Update(ctx, before.ID, updates, before.Version); err != nil {
+				return err
+			}
+			if err := s.recordStockChange(ctx, before, product, model.StockReasonAdjustment, ""); err != nil {
+				return err
+			}
+			return s.addHistory(ctx, product.ID, product.Version, model.ProductOpUpdate, productChanges(before, product))
+		})
+	})
+	if err == nil {
+		err = s.attachDetails(ctx, product)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to upsert product: %w", err)
+	}
+	return product, created, nil
+}
+
+func (s *ProductService) createWithSKU(ctx context.Context, sku string, req *model.CreateProductRequest, price int64, currency string) (*model.Product, error) {
+	product := &model.Product{
+		SKU:         &sku,
+		Name:        req.Name,
+		Description: req.Description,
+		PriceMinor:  price,
+		Currency:    currency,
+		Stock:       req.Stock,
+	}
+	if err := s.productRepo.Create(ctx, product); err != nil {
+		if errors.Is(err, repository.ErrSKUExists) {
+			// Параллельный запрос успел создать продукт: withVersionRetry
+			// повторит upsert, и он станет обновлением
+			return nil, repository.ErrVersionMismatch
+		}
+		return nil, err
+	}
+	if err := s.recordStockChange(ctx, nil, product, model.StockReasonInitial, ""); err != nil {
+		return nil, err
+	}
+	return product, s.addHistory(ctx, product.ID, product.Version, model.ProductOpCreate, productChanges(nil, product))
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"demo-service/internal/model"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidStockAdjustment - знак изменения не соответствует причине.
+var ErrInvalidStockAdjustment = errors.New("invalid stock adjustment")
+
+// ErrStockOverwrite - остаток в изменении продукта: он меняется только
+// через AdjustStock, чтобы у каждого движения была причина.
+var ErrStockOverwrite = errors.New("stock cannot be set by update")
+
+// AdjustStock изменяет остаток на req.Delta и записывает движение в журнал.
+// Остаток не может опуститься ниже зарезервированного
+// (repository.ErrInsufficientStock). Версия продукта увеличивается, поэтому
+// параллельный PUT с устаревшим If-Match получит конфликт.
+func (s *ProductService) AdjustStock(ctx context.Context, id int64, req *model.StockAdjustRequest) (*model.StockAdjustResponse, error) {
+	if err := validateStockAdjustment(req.Delta, req.Reason); err != nil {
+		return nil, err
+	}
+
+	var response model.StockAdjustResponse
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		product, err := s.productRepo.AdjustStock(ctx, id, req.Delta)
+		if err != nil {
+			return err
+		}
+
+		before := *product
+		before.Stock -= req.Delta
+		movement := stockMovement(ctx, &before, product, req.Reason, req.Reference)
+		if err := s.movementRepo.Add(ctx, movement); err != nil {
+			return fmt.Errorf("failed to record stock movement: %w", err)
+		}
+		response = model.StockAdjustResponse{Movement: *movement, Product: product}
+		return s.addHistory(ctx, id, product.Version, model.ProductOpUpdate, productChanges(&before, product))
+	})
+	if err == nil {
+		err = s.attachDetails(ctx, response.Product)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+	return &response, nil
+}
+
+// StockMovements возвращает журнал остатка продукта, новые движения первыми.
+func (s *ProductService) StockMovements(ctx context.Context, id int64, page, limit int) (*model.StockMovementListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	movements, total, err := s.movementRepo.List(ctx, id, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+
+	return &model.StockMovementListResponse{
+		Movements: movements,
+		Total:     total,
+		Page:      page,
+		Limit:     limit,
+	}, nil
+}
+
+// validateStockAdjustment проверяет, что знак delta соответствует причине.
+func validateStockAdjustment(delta int, reason string) error {
+	switch {
+	case delta == 0:
+		return fmt.Errorf("%w: delta must not be zero", ErrInvalidStockAdjustment)
+	case reason == model.StockReasonSale && delta > 0:
+		return fmt.Errorf("%w: delta must be negative for sale", ErrInvalidStockAdjustment)
+	case (reason == model.StockReasonRestock || reason == model.StockReasonReturn) && delta < 0:
+		return fmt.Errorf("%w: delta must be positive for %s", ErrInvalidStockAdjustment, reason)
+	}
+	return nil
+}
+
+// recordStockChange записывает в журнал изменение остатка между before и
+// after, если оно есть.
+func (s *ProductService) recordStockChange(ctx context.Context, before, after *model.Product, reason, reference string) error {
+	movement := stockMovement(ctx, before, after, reason, reference)
+	if movement == nil {
+		return nil
+	}
+	if err := s.movementRepo.Add(ctx, movement); err != nil {
+		return fmt.Errorf("failed to record stock movement: %w", err)
+	}
+	return nil
+}
+
+// stockMovement - движение, переводящее остаток из before в after, или nil,
+// если остаток не изменился. before == nil означает создание продукта.
+func stockMovement(ctx context.Context, before, after *model.Product, reason, reference string) *model.StockMovement {
+	delta := after.Stock
+	if before != nil {
+		delta -= before.Stock
+	}
+	if delta == 0 {
+		return nil
+	}
+	return &model.StockMovement{
+		ProductID:  after.ID,
+		Delta:      delta,
+		StockAfter: after.Stock,
+		Reason:     reason,
+		Reference:  reference,
+		ActorID:    actorFrom(ctx),
+	}
+}
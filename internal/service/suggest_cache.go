@@ -0,0 +1,71 @@
+package service
+
+import (
+	"demo-service/internal/model"
+	"sync"
+	"time"
+)
+
+// Предел числа закешированных префиксов, чтобы случайный ввод не раздувал память
+const suggestCacheMaxEntries = 10000
+
+// suggestCache - короткоживущий кеш подсказок: автодополнение вызывается на
+// каждое нажатие клавиши, и одинаковые префиксы приходят от многих клиентов.
+type suggestCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]suggestCacheEntry
+}
+
+type suggestCacheEntry struct {
+	suggestions []model.ProductSuggestion
+	expiresAt   time.Time
+}
+
+func newSuggestCache(ttl time.Duration) *suggestCache {
+	return &suggestCache{
+		ttl:     ttl,
+		entries: make(map[string]suggestCacheEntry),
+	}
+}
+
+func (c *suggestCache) get(key string) ([]model.ProductSuggestion, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.suggestions, true
+}
+
+func (c *suggestCache) set(key string, suggestions []model.ProductSuggestion) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= suggestCacheMaxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= suggestCacheMaxEntries {
+			c.entries = make(map[string]suggestCacheEntry)
+		}
+	}
+
+	c.entries[key] = suggestCacheEntry{
+		suggestions: suggestions,
+		expiresAt:   now.Add(c.ttl),
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+)
+
+// Предел размера декодируемого изображения: PNG в несколько килобайт
+// может распаковаться в гигабайты пикселей
+const maxImagePixels = 50_000_000
+
+// thumbnailFormats - типы изображений, которые умеет декодировать image.Decode
+// (WebP стандартная библиотека не поддерживает).
+var thumbnailFormats = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// thumbnail - уменьшенная копия изображения и размеры оригинала.
+type thumbnail struct {
+	data          []byte
+	contentType   string
+	ext           string
+	width, height int
+}
+
+// makeThumbnail вписывает изображение в квадрат size x size. Фотографии
+// (JPEG) остаются JPEG, остальные сохраняются в PNG, чтобы не потерять
+// прозрачность.
+func makeThumbnail(data []byte, size int) (*thumbnail, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return nil, fmt.Errorf("%w: image must be at most %d megapixels", ErrInvalidImage, maxImagePixels/1_000_000)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	dst := scaleDown(src, size)
+
+	thumb := &thumbnail{width: cfg.Width, height: cfg.Height}
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		thumb.contentType, thumb.ext = "image/jpeg", ".jpg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		thumb.contentType, thumb.ext = "image/png", ".png"
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	thumb.data = buf.Bytes()
+	return thumb, nil
+}
+
+// thumbnailContentType восстанавливает тип миниатюры по расширению ключа.
+func thumbnailContentType(key string) string {
+	if strings.HasSuffix(key, ".png") {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+// scaleDown уменьшает src, сохраняя пропорции, чтобы он вписался в квадрат
+// size x size. Каждый пиксель результата - среднее покрытых им пикселей
+// исходника; маленькие изображения не увеличиваются.
+func scaleDown(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	dw, dh := sw, sh
+	switch {
+	case sw <= size && sh <= size:
+	case sw >= sh:
+		dw, dh = size, max(1, sh*size/sw)
+	default:
+		dw, dh = max(1, sw*size/sh), size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := bounds.Min.Y+dy*sh/dh, bounds.Min.Y+(dy+1)*sh/dh
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := bounds.Min.X+dx*sw/dw, bounds.Min.X+(dx+1)*sw/dw
+
+			// RGBA() возвращает 16-битные компоненты с умножением на альфу,
+			// их можно усреднять напрямую
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, pa := src.At(x, y).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA(dx, dy, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
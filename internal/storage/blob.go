@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound - по ключу нет объекта.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore хранит содержимое файлов по ключам вида "products/1/ab12.png".
+// Ключи составляет сервис; "/" в них разделяет уровни, как каталоги.
+// Delete и DeletePrefix не считают ошибкой отсутствие объектов.
+type BlobStore interface {
+	// Put сохраняет объект размером size байт, заменяя существующий.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get возвращает содержимое объекта, вызывающий его закрывает.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix удаляет все объекты с ключами вида prefix + "/...".
+	DeletePrefix(ctx context.Context, prefix string) error
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(key, body string) {
+		t.Helper()
+		if err := store.Put(ctx, key, strings.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	get := func(key string) (string, error) {
+		t.Helper()
+		r, err := store.Get(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		return string(data), err
+	}
+
+	put("products/1/a.png", "first")
+	put("products/1/b.png", "second")
+	put("products/2/a.png", "other")
+	if body, err := get("products/1/a.png"); err != nil || body != "first" {
+		t.Fatalf("get = %q, %v", body, err)
+	}
+	put("products/1/a.png", "replaced")
+	if body, _ := get("products/1/a.png"); body != "replaced" {
+		t.Fatalf("get after replace = %q", body)
+	}
+
+	if err := store.Delete(ctx, "products/1/a.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get("products/1/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get deleted: %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "products/1/a.png"); err != nil {
+		t.Fatalf("delete missing: %v", err)
+	}
+
+	if err := store.DeletePrefix(ctx, "products/1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get("products/1/b.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get after delete prefix: %v, want ErrNotFound", err)
+	}
+	if body, _ := get("products/2/a.png"); body != "other" {
+		t.Fatalf("other prefix = %q", body)
+	}
+
+	// Недописанный объект не появляется под своим ключом
+	err = store.Put(ctx, "products/3/short.png", strings.NewReader("abc"), 10, "image/png")
+	if err == nil {
+		t.Fatal("put with a wrong size succeeded")
+	}
+	if _, err := get("products/3/short.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get short: %v, want ErrNotFound", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, "products", "3"))
+	if len(entries) != 0 {
+		t.Fatalf("leftover files: %v", entries)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := store.Put(canceled, "products/4/a.png", strings.NewReader("abc"), 3, "image/png"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("put with canceled context: %v", err)
+	}
+
+	for _, key := range []string{"../escape", "/etc/passwd", "products/../../escape"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("put %q outside root succeeded", key)
+		}
+	}
+}
@@ -15,13 +15,15 @@ var (
 type Claims struct {
 	UserID   int64  `json:"user_id"`
 	Username string `json:"username"`
+	IsAdmin  bool   `json:"is_admin,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int64, username, secret string, expiry time.Duration) (string, error) {
+func GenerateToken(userID int64, username string, isAdmin bool, secret string, expiry time.Duration) (string, error) {
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
+		IsAdmin:  isAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),